@@ -0,0 +1,151 @@
+// Command ingest backfills and updates the daily price history in Postgres
+// that company charts are served from.
+//
+//	go run ./cmd/ingest                        every company, incremental
+//	go run ./cmd/ingest -companies 2222,1120 -backfill
+//	go run ./cmd/ingest -every 1h              keep running, once an hour
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/config"
+	"patient-chatbot/internal/database"
+	"patient-chatbot/internal/history"
+	"patient-chatbot/internal/mapping"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+func main() {
+	companies := flag.String("companies", "", "comma separated tadawul ids, every company in the directory when empty")
+	backfill := flag.Bool("backfill", false, "download the longest upstream period even for companies with history")
+	workers := flag.Int("workers", 2, "companies ingested concurrently")
+	delay := flag.Duration("delay", 500*time.Millisecond, "pause between upstream calls of a worker, to spare the API quota")
+	every := flag.Duration("every", 0, "run again at this interval instead of exiting")
+	flag.Parse()
+
+	cfg, err := config.LoadIngest()
+	if err != nil {
+		log.Fatal().Msg("error loading config: " + err.Error())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := database.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal().Msg("error opening database: " + err.Error())
+	}
+	defer pool.Close()
+	if err := database.Migrate(ctx, pool); err != nil {
+		log.Fatal().Msg("error migrating database: " + err.Error())
+	}
+
+	// @NOTE: Uncached on purpose, every call is for data the cache can't have yet
+	provider, err := stock.NewMarketDataProvider(cfg)
+	if err != nil {
+		log.Fatal().Msg("error creating market data provider: " + err.Error())
+	}
+	ingester := history.NewIngester(provider, history.NewPostgresStore(pool))
+	restoreDirectory(ctx, cfg, pool, provider)
+
+	for {
+		ids := tadawulIDs(*companies)
+		failed := run(ctx, ingester, ids, *backfill, max(*workers, 1), *delay)
+		if *every <= 0 {
+			if failed > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+		// @NOTE: Only the first round backfills, the next ones are incremental
+		*backfill = false
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*every):
+		}
+	}
+}
+
+// run ingests every company and returns how many failed.
+func run(ctx context.Context, ingester *history.Ingester, ids []string, backfill bool, workers int, delay time.Duration) int {
+	start := time.Now()
+	var failed, stored atomic.Int64
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			result, err := ingester.Ingest(ctx, id, backfill)
+			if err != nil {
+				failed.Add(1)
+				log.Error().Msg(err.Error())
+			} else {
+				stored.Add(int64(result.Stored))
+				log.Info().Msgf("ingest :: %s :: period %s, %d bars fetched, %d new or changed, covered %s to %s",
+					id, result.Period, result.Fetched, result.Stored, result.Coverage.From, result.Coverage.To)
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(delay):
+			}
+			return nil
+		})
+	}
+	group.Wait()
+
+	log.Info().Msgf("ingest :: %d companies in %s, %d bars new or changed, %d failed",
+		len(ids), time.Since(start).Round(time.Second), stored.Load(), failed.Load())
+	return int(failed.Load())
+}
+
+// restoreDirectory loads the latest company directory snapshot so companies
+// listed since the embedded file are ingested too.
+func restoreDirectory(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, provider stock.MarketDataProvider) {
+	var store mapping.SnapshotStore
+	switch cfg.DirectorySnapshot {
+	case "off":
+		return
+	case "postgres":
+		store = mapping.NewPostgresSnapshotStore(pool)
+	default:
+		store = mapping.NewFileSnapshotStore(cfg.DirectorySnapshotPath)
+	}
+	if err := mapping.NewRefresher(provider, store).Restore(ctx); err != nil {
+		log.Warn().Msg("using the embedded company directory: " + err.Error())
+	}
+}
+
+// tadawulIDs returns the requested companies, or every company of the
+// directory.
+func tadawulIDs(companies string) []string {
+	var ids []string
+	if companies != "" {
+		for _, id := range strings.Split(companies, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	for _, company := range mapping.Current().Companies() {
+		ids = append(ids, company.TadawulID)
+	}
+	return ids
+}
@@ -1,19 +1,30 @@
 package main
 
 import (
+	"context"
 	"os"
+	"patient-chatbot/internal/alert"
 	"patient-chatbot/internal/client/llm"
 	"patient-chatbot/internal/client/stock"
 	"patient-chatbot/internal/config"
+	"patient-chatbot/internal/conversation"
+	"patient-chatbot/internal/database"
 	"patient-chatbot/internal/handler"
+	"patient-chatbot/internal/history"
 	logger "patient-chatbot/internal/log"
+	"patient-chatbot/internal/mapping"
 	"patient-chatbot/internal/middleware"
+	"patient-chatbot/internal/portfolio"
+	"patient-chatbot/internal/realtime"
 	"patient-chatbot/internal/service"
 	"patient-chatbot/internal/utils"
+	"patient-chatbot/internal/watchlist"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
 )
 
 type Server struct {
@@ -25,8 +36,8 @@ func NewServer(cfg *config.Config) *Server {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{cfg.FrontendURL},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-User-Id"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -35,22 +46,175 @@ func NewServer(cfg *config.Config) *Server {
 	r.Use(middleware.LocaleMiddleware(utils.Bundle))
 	r.Use(middleware.RequestID())
 	r.Use(middleware.RequestID())
+	if cfg.GatewaySecret == "" {
+		log.Warn().Msg("GATEWAY_SECRET is not set, X-User-Id is trusted as sent and the API must only be reachable through a gateway that sets it")
+	}
+	r.Use(middleware.UserID(cfg.GatewaySecret))
 
 	r.Use(gin.Recovery())
 	r.Use(cors.Default())
 
 	r.Use(logger.Init())
 
-	stockClient := stock.NewStockClient(cfg)
-	llmClient := llm.NewLLMClient(cfg, stockClient)
-	chatService := service.NewService(cfg, llmClient, stockClient)
-	h := handler.NewHandler(chatService)
+	pool := openDatabase(cfg)
+	marketData := newMarketDataProvider(cfg, pool)
+	llmProvider, err := llm.NewProvider(cfg)
+	if err != nil {
+		log.Fatal().Msg("error creating llm provider: " + err.Error())
+	}
+	prompts, err := llm.LoadPrompts("internal/locales/prompts")
+	if err != nil {
+		log.Fatal().Msg("error loading prompts: " + err.Error())
+	}
+	llmClient := llm.NewLLMClient(cfg, llmProvider, prompts)
+	conversations := newConversationRepository(pool)
+	watchlists := newWatchlistRepository(pool)
+	portfolios := newPortfolioRepository(pool)
+	alerts := newAlertRepository(pool)
+	prices := newPriceHistory(cfg, pool)
+	directory := startDirectoryRefresher(cfg, pool, marketData)
+	startAlerts(cfg, alerts, marketData)
+	chatService := service.NewService(cfg, llmClient, marketData, conversations, watchlists, portfolios, alerts, prices, directory)
+	hub := realtime.NewHub(chatService, cfg.RealtimePollInterval, cfg.FrontendURL)
+	go hub.Run(context.Background())
+	h := handler.NewHandler(chatService, hub)
 
-	RegisterRoutes(r, h)
+	RegisterRoutes(r, h, cfg)
 
 	return &Server{router: r}
 }
 
+// openDatabase connects to Postgres and applies migrations when
+// DATABASE_URL is set, it returns nil otherwise.
+func openDatabase(cfg *config.Config) *pgxpool.Pool {
+	if cfg.DatabaseURL == "" {
+		log.Warn().Msg("DATABASE_URL is not set, using in-memory storage")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := database.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal().Msg("error opening database: " + err.Error())
+	}
+	if err := database.Migrate(ctx, pool); err != nil {
+		log.Fatal().Msg("error migrating database: " + err.Error())
+	}
+	return pool
+}
+
+func newConversationRepository(pool *pgxpool.Pool) conversation.Repository {
+	if pool == nil {
+		return conversation.NewMemoryRepository()
+	}
+	return conversation.NewPostgresRepository(pool)
+}
+
+func newWatchlistRepository(pool *pgxpool.Pool) watchlist.Repository {
+	if pool == nil {
+		return watchlist.NewMemoryRepository()
+	}
+	return watchlist.NewPostgresRepository(pool)
+}
+
+func newPortfolioRepository(pool *pgxpool.Pool) portfolio.Repository {
+	if pool == nil {
+		return portfolio.NewMemoryRepository()
+	}
+	return portfolio.NewPostgresRepository(pool)
+}
+
+func newAlertRepository(pool *pgxpool.Pool) alert.Repository {
+	if pool == nil {
+		return alert.NewMemoryRepository()
+	}
+	return alert.NewPostgresRepository(pool)
+}
+
+// newPriceHistory returns the price store charts are served from, nil when
+// there is no database or PRICE_HISTORY is off.
+func newPriceHistory(cfg *config.Config, pool *pgxpool.Pool) history.Store {
+	if pool == nil || cfg.PriceHistory == "off" {
+		return nil
+	}
+	return history.NewPostgresStore(pool)
+}
+
+// startAlerts runs the poller evaluating the alert rules and the deliverer
+// sending fired alerts to their webhooks.
+func startAlerts(cfg *config.Config, alerts alert.Repository, marketData stock.MarketDataProvider) {
+	if cfg.AlertWebhookURL == "" {
+		log.Warn().Msg("ALERT_WEBHOOK_URL is not set, alerts without their own webhook are only kept in the history")
+	}
+	if cfg.AlertWebhookSecret == "" {
+		log.Warn().Msg("ALERT_WEBHOOK_SECRET is not set, alert webhooks are disabled and alerts are only kept in the history")
+	}
+	poller := alert.NewPoller(alerts, marketData, cfg.AlertWebhookURL)
+	deliverer := alert.NewDeliverer(alerts, cfg.AlertWebhookURL, cfg.AlertWebhookSecret, cfg.AlertWebhookMaxAttempts, cfg.AlertWebhookBackoff, cfg.AlertWebhookTimeout)
+	go poller.Run(context.Background(), cfg.AlertPollInterval)
+	// @NOTE: Retries are scheduled in seconds, checking every few keeps them on time
+	go deliverer.Run(context.Background(), min(cfg.AlertWebhookBackoff, 5*time.Second))
+}
+
+// newMarketDataProvider builds the configured provider, putting the cache in
+// front of RapidAPI since every call there costs quota.
+func newMarketDataProvider(cfg *config.Config, pool *pgxpool.Pool) stock.MarketDataProvider {
+	provider, err := stock.NewMarketDataProvider(cfg)
+	if err != nil {
+		log.Fatal().Msg("error creating market data provider: " + err.Error())
+	}
+	if cfg.MarketDataProvider != stock.ProviderRapidAPI {
+		return provider
+	}
+
+	var store stock.CacheStore
+	switch cfg.StockCache {
+	case "off":
+		return provider
+	case "postgres":
+		if pool == nil {
+			log.Fatal().Msg("STOCK_CACHE=postgres requires DATABASE_URL")
+		}
+		store = stock.NewPostgresCacheStore(pool)
+	default:
+		store = stock.NewMemoryCacheStore(cfg.StockCacheSize)
+	}
+	return stock.NewCachedClient(provider, store, stock.CacheTTLs{
+		Prices:      cfg.StockCachePricesTTL,
+		Movers:      cfg.StockCacheMoversTTL,
+		Search:      cfg.StockCacheSearchTTL,
+		MarketWatch: cfg.StockCacheMarketWatchTTL,
+		Stale:       cfg.StockCacheStaleTTL,
+	})
+}
+
+// startDirectoryRefresher restores the last company directory snapshot, falling
+// back to the embedded one, and keeps it refreshed from the market watch.
+func startDirectoryRefresher(cfg *config.Config, pool *pgxpool.Pool, marketData stock.MarketDataProvider) *mapping.Refresher {
+	var store mapping.SnapshotStore
+	switch cfg.DirectorySnapshot {
+	case "off":
+	case "postgres":
+		if pool == nil {
+			log.Fatal().Msg("DIRECTORY_SNAPSHOT=postgres requires DATABASE_URL")
+		}
+		store = mapping.NewPostgresSnapshotStore(pool)
+	default:
+		store = mapping.NewFileSnapshotStore(cfg.DirectorySnapshotPath)
+	}
+
+	refresher := mapping.NewRefresher(marketData, store)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := refresher.Restore(ctx); err != nil {
+		log.Warn().Msg("using the embedded company directory: " + err.Error())
+	}
+	go refresher.Run(context.Background(), cfg.DirectoryRefreshInterval)
+	return refresher
+}
+
 func (s *Server) Run() error {
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -59,12 +223,63 @@ func (s *Server) Run() error {
 	return s.router.Run(":" + port)
 }
 
-func RegisterRoutes(r *gin.Engine, h *handler.Handler) {
+func RegisterRoutes(r *gin.Engine, h *handler.Handler, cfg *config.Config) {
 	api := r.Group("/api/v1")
 	{
 		api.GET("/health", h.HandleGetHealth)
+		api.GET("/ws", h.HandleWebSocket)
 		api.POST("/chat", h.HandleChat)
+		api.POST("/chat/stream", h.HandleChatStream)
 		api.GET("/dashboard", h.HandleGetDashboard)
 		api.GET("/dashboard/chart", h.HandleGetCompanyChart)
+		api.GET("/dashboard/sectors", h.HandleGetSectors)
+		api.GET("/companies", h.HandleListCompanies)
+		api.GET("/companies/:tadawulId", h.HandleGetCompany)
+		api.GET("/companies/:tadawulId/indicators", h.HandleGetCompanyIndicators)
+		api.GET("/screener", h.HandleScreen)
+		api.POST("/screener", h.HandleScreen)
+	}
+
+	conversations := api.Group("/conversations", handler.RequireUser())
+	{
+		conversations.GET("", h.HandleListConversations)
+		conversations.GET("/:id", h.HandleGetConversation)
+		conversations.PATCH("/:id", h.HandleRenameConversation)
+		conversations.DELETE("/:id", h.HandleDeleteConversation)
+	}
+
+	watchlists := api.Group("/watchlists", handler.RequireUser())
+	{
+		watchlists.GET("", h.HandleListWatchlists)
+		watchlists.POST("", h.HandleCreateWatchlist)
+		watchlists.GET("/:id", h.HandleGetWatchlist)
+		watchlists.PATCH("/:id", h.HandleRenameWatchlist)
+		watchlists.DELETE("/:id", h.HandleDeleteWatchlist)
+		watchlists.POST("/:id/items", h.HandleAddToWatchlist)
+		watchlists.DELETE("/:id/items/:tadawulId", h.HandleRemoveFromWatchlist)
+		watchlists.GET("/:id/quotes", h.HandleGetWatchlistQuotes)
+	}
+
+	portfolios := api.Group("/portfolio", handler.RequireUser())
+	{
+		portfolios.GET("", h.HandleGetPortfolio)
+		portfolios.GET("/transactions", h.HandleListTransactions)
+		portfolios.POST("/transactions", h.HandleAddTransaction)
+		portfolios.DELETE("/transactions/:id", h.HandleDeleteTransaction)
+	}
+
+	alerts := api.Group("/alerts", handler.RequireUser())
+	{
+		alerts.GET("", h.HandleListAlerts)
+		alerts.POST("", h.HandleCreateAlert)
+		alerts.DELETE("/:id", h.HandleDeleteAlert)
+		alerts.GET("/history", h.HandleGetAlertHistory)
+		alerts.GET("/dead-letters", h.HandleGetDeadLetters)
+		alerts.POST("/history/:id/retry", h.HandleRetryAlertEvent)
+	}
+
+	admin := api.Group("/admin", handler.RequireAdmin(cfg.AdminToken))
+	{
+		admin.POST("/directory/refresh", h.HandleRefreshDirectory)
 	}
 }
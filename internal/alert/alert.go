@@ -0,0 +1,176 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"patient-chatbot/internal/client/stock"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("alert not found")
+	ErrLimitReached     = errors.New("alert limit reached")
+	ErrInvalidRule      = errors.New("invalid alert rule")
+	ErrNotDeadLettered  = errors.New("alert event is not dead lettered")
+	ErrMissingBaseline  = errors.New("no volume baseline")
+	ErrWebhooksDisabled = errors.New("alert webhooks are disabled without a signing secret")
+	errUnknownCondition = errors.New("unknown condition")
+)
+
+const MaxRules = 50
+
+type Condition string
+
+const (
+	PriceAbove Condition = "price_above"
+	PriceBelow Condition = "price_below"
+	// ChangeUp and ChangeDown compare the session change percentage with a
+	// positive threshold, ChangeDown firing on a drop of at least that much.
+	ChangeUp   Condition = "change_up"
+	ChangeDown Condition = "change_down"
+	// VolumeSpike fires when the session volume reaches threshold times the
+	// average daily volume of the last month.
+	VolumeSpike Condition = "volume_spike"
+	// High52Week and Low52Week fire when the session high or low breaks the 52
+	// week range, they take no threshold.
+	High52Week Condition = "high_52w"
+	Low52Week  Condition = "low_52w"
+)
+
+var Conditions = []Condition{PriceAbove, PriceBelow, ChangeUp, ChangeDown, VolumeSpike, High52Week, Low52Week}
+
+// Rule watches one company. Rules fire once when their condition becomes true,
+// repeating rules re-arm once it turns false again while the others stay
+// triggered.
+type Rule struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"-"`
+	TadawulID       string     `json:"tadawulId"`
+	Condition       Condition  `json:"condition"`
+	Threshold       float64    `json:"threshold,omitempty"`
+	Repeat          bool       `json:"repeat"`
+	WebhookURL      string     `json:"webhookUrl,omitempty"`
+	Triggered       bool       `json:"triggered"`
+	LastTriggeredAt *time.Time `json:"lastTriggeredAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// Validate checks the condition is known, the threshold makes sense for it and
+// the webhook, when set, is an absolute http(s) URL. Hostnames are checked
+// again when delivering, once they are resolved.
+func (r Rule) Validate() error {
+	if r.WebhookURL != "" {
+		u, err := url.Parse(r.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook url must be an http or https url", ErrInvalidRule)
+		}
+		if u.Hostname() == "localhost" {
+			return fmt.Errorf("%w: %w", ErrInvalidRule, ErrBlockedAddress)
+		}
+		if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !isPublic(ip) {
+			return fmt.Errorf("%w: %w", ErrInvalidRule, ErrBlockedAddress)
+		}
+	}
+	switch r.Condition {
+	case PriceAbove, PriceBelow, ChangeUp, ChangeDown, VolumeSpike:
+		if r.Threshold <= 0 {
+			return fmt.Errorf("%w: %s needs a positive threshold", ErrInvalidRule, r.Condition)
+		}
+	case High52Week, Low52Week:
+	default:
+		return fmt.Errorf("%w: %w %q", ErrInvalidRule, errUnknownCondition, r.Condition)
+	}
+	return nil
+}
+
+// Evaluate reports whether q meets the rule and the value it was judged on.
+// baseline is the average daily volume, only volume spikes use it.
+func (r Rule) Evaluate(q stock.MarketWatchResponse, baseline float64) (float64, bool, error) {
+	switch r.Condition {
+	case PriceAbove:
+		return q.Price, q.Price > 0 && q.Price >= r.Threshold, nil
+	case PriceBelow:
+		return q.Price, q.Price > 0 && q.Price <= r.Threshold, nil
+	case ChangeUp:
+		return q.ChangePercentage, q.ChangePercentage >= r.Threshold, nil
+	case ChangeDown:
+		return q.ChangePercentage, q.ChangePercentage <= -r.Threshold, nil
+	case VolumeSpike:
+		if baseline <= 0 {
+			return 0, false, ErrMissingBaseline
+		}
+		ratio := float64(q.Volume) / baseline
+		return ratio, ratio >= r.Threshold, nil
+	case High52Week:
+		return q.HighPrice, q.Highest52Price > 0 && q.HighPrice >= q.Highest52Price, nil
+	case Low52Week:
+		return q.LowPrice, q.Lowest52Price > 0 && q.LowPrice > 0 && q.LowPrice <= q.Lowest52Price, nil
+	}
+	return 0, false, fmt.Errorf("alert :: Rule.Evaluate :: %w %q", errUnknownCondition, r.Condition)
+}
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	// StatusDead events exhausted their delivery attempts, they can be
+	// retried by hand.
+	StatusDead Status = "dead"
+	// StatusSkipped events had no webhook to go to, they only live in the
+	// history.
+	StatusSkipped Status = "skipped"
+)
+
+// Event is a rule firing and the state of its webhook delivery.
+type Event struct {
+	ID            string     `json:"id"`
+	RuleID        string     `json:"ruleId"`
+	UserID        string     `json:"-"`
+	TadawulID     string     `json:"tadawulId"`
+	Condition     Condition  `json:"condition"`
+	Threshold     float64    `json:"threshold,omitempty"`
+	Value         float64    `json:"value"`
+	Price         float64    `json:"price"`
+	WebhookURL    string     `json:"-"`
+	Status        Status     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"lastError,omitempty"`
+	NextAttemptAt time.Time  `json:"-"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+	TriggeredAt   time.Time  `json:"triggeredAt"`
+}
+
+// Summary is what the chat tools return: the user's rules and, when asked
+// for, their recent events.
+type Summary struct {
+	Alerts  []Rule  `json:"alerts"`
+	History []Event `json:"history,omitempty"`
+}
+
+// Repository stores rules and events. User facing methods are scoped by
+// userID, the others serve the poller and the webhook deliverer across users.
+type Repository interface {
+	CreateRule(ctx context.Context, userID string, rule Rule) (*Rule, error)
+	ListRules(ctx context.Context, userID string) ([]Rule, error)
+	DeleteRule(ctx context.Context, userID string, id string) error
+	ListEvents(ctx context.Context, userID string, status Status, limit int) ([]Event, error)
+	RetryEvent(ctx context.Context, userID string, id string) (*Event, error)
+
+	// ActiveRules returns the rules the poller evaluates: every repeating
+	// rule and the one-shot rules that have not fired yet.
+	ActiveRules(ctx context.Context) ([]Rule, error)
+	// Fire records a rule firing with its event in one step. It fails with
+	// ErrNotFound when the rule is gone or already triggered, so pollers of
+	// several instances fire it once.
+	Fire(ctx context.Context, rule Rule, event Event) (*Event, error)
+	// Rearm fails with ErrNotFound when the rule is gone or not triggered.
+	Rearm(ctx context.Context, ruleID string) error
+	// ClaimDue returns up to limit pending events due at now and pushes their
+	// next attempt back by lease so no other deliverer picks them up.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Event, error)
+	UpdateDelivery(ctx context.Context, event Event) error
+}
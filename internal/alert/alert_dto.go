@@ -0,0 +1,17 @@
+package alert
+
+const (
+	FunctionCreateAlert = "CreateAlert"
+	FunctionGetAlerts   = "GetAlerts"
+)
+
+type CreateAlertArguments struct {
+	Company   string  `json:"company" description:"Company to watch, by name, acronym or tadawul id, in English or Arabic"`
+	Condition string  `json:"condition" enum:"price_above,price_below,change_up,change_down,volume_spike,high_52w,low_52w" description:"price_above/price_below compare the price in SAR with threshold, change_up/change_down the day's change percentage with threshold (positive for both), volume_spike the day's volume with threshold times the average daily volume of the last month, high_52w/low_52w fire on a new 52 week high or low"`
+	Threshold float64 `json:"threshold,omitempty" description:"Price in SAR, change percentage or volume multiple depending on the condition, not needed for high_52w and low_52w"`
+	Repeat    bool    `json:"repeat,omitempty" description:"Fire again every time the condition becomes true, instead of only once"`
+}
+
+type GetAlertsArguments struct {
+	History bool `json:"history,omitempty" description:"Return the alerts that fired recently instead of the alerts set up"`
+}
@@ -0,0 +1,162 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"patient-chatbot/internal/client/stock"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	quote := stock.MarketWatchResponse{
+		Price:            100,
+		ChangePercentage: -3,
+		Volume:           3000,
+		HighPrice:        105,
+		LowPrice:         95,
+		Highest52Price:   105,
+		Lowest52Price:    90,
+	}
+
+	tests := []struct {
+		name      string
+		rule      Rule
+		baseline  float64
+		quote     stock.MarketWatchResponse
+		wantValue float64
+		wantHit   bool
+		wantErr   error
+	}{
+		{name: "price above reached", rule: Rule{Condition: PriceAbove, Threshold: 100}, quote: quote, wantValue: 100, wantHit: true},
+		{name: "price above not reached", rule: Rule{Condition: PriceAbove, Threshold: 101}, quote: quote, wantValue: 100},
+		{name: "price below reached", rule: Rule{Condition: PriceBelow, Threshold: 100}, quote: quote, wantValue: 100, wantHit: true},
+		{name: "price below ignores a missing price", rule: Rule{Condition: PriceBelow, Threshold: 100}, quote: stock.MarketWatchResponse{}, wantValue: 0},
+		{name: "change up not reached on a drop", rule: Rule{Condition: ChangeUp, Threshold: 2}, quote: quote, wantValue: -3},
+		{name: "change down reached", rule: Rule{Condition: ChangeDown, Threshold: 3}, quote: quote, wantValue: -3, wantHit: true},
+		{name: "change down not reached", rule: Rule{Condition: ChangeDown, Threshold: 4}, quote: quote, wantValue: -3},
+		{name: "volume spike reached", rule: Rule{Condition: VolumeSpike, Threshold: 3}, baseline: 1000, quote: quote, wantValue: 3, wantHit: true},
+		{name: "volume spike not reached", rule: Rule{Condition: VolumeSpike, Threshold: 3}, baseline: 1500, quote: quote, wantValue: 2},
+		{name: "volume spike without a baseline", rule: Rule{Condition: VolumeSpike, Threshold: 3}, quote: quote, wantErr: ErrMissingBaseline},
+		{name: "52 week high touched", rule: Rule{Condition: High52Week}, quote: quote, wantValue: 105, wantHit: true},
+		{name: "52 week low not broken", rule: Rule{Condition: Low52Week}, quote: quote, wantValue: 95},
+		{name: "52 week low broken", rule: Rule{Condition: Low52Week}, quote: stock.MarketWatchResponse{LowPrice: 89, Lowest52Price: 90}, wantValue: 89, wantHit: true},
+		{name: "52 week low ignores a missing low", rule: Rule{Condition: Low52Week}, quote: stock.MarketWatchResponse{Lowest52Price: 90}},
+		{name: "unknown condition", rule: Rule{Condition: "price_equal"}, quote: quote, wantErr: errUnknownCondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, hit, err := tt.rule.Evaluate(tt.quote, tt.baseline)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if value != tt.wantValue || hit != tt.wantHit {
+				t.Errorf("Evaluate() = %v, %v, want %v, %v", value, hit, tt.wantValue, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr error
+	}{
+		{name: "public webhook", rule: Rule{Condition: PriceAbove, Threshold: 1, WebhookURL: "https://example.com/hook"}},
+		{name: "public literal", rule: Rule{Condition: PriceAbove, Threshold: 1, WebhookURL: "http://93.184.216.34/hook"}},
+		{name: "threshold free condition", rule: Rule{Condition: High52Week}},
+		{name: "missing threshold", rule: Rule{Condition: PriceAbove}, wantErr: ErrInvalidRule},
+		{name: "unknown condition", rule: Rule{Condition: "price_equal", Threshold: 1}, wantErr: errUnknownCondition},
+		{name: "not http", rule: Rule{Condition: High52Week, WebhookURL: "ftp://example.com"}, wantErr: ErrInvalidRule},
+		{name: "relative url", rule: Rule{Condition: High52Week, WebhookURL: "/hook"}, wantErr: ErrInvalidRule},
+		{name: "localhost", rule: Rule{Condition: High52Week, WebhookURL: "http://localhost:8080/hook"}, wantErr: ErrBlockedAddress},
+		{name: "loopback", rule: Rule{Condition: High52Week, WebhookURL: "http://127.0.0.1/hook"}, wantErr: ErrBlockedAddress},
+		{name: "ipv6 loopback", rule: Rule{Condition: High52Week, WebhookURL: "http://[::1]/hook"}, wantErr: ErrBlockedAddress},
+		{name: "private", rule: Rule{Condition: High52Week, WebhookURL: "http://10.0.0.5/hook"}, wantErr: ErrBlockedAddress},
+		{name: "private ipv6", rule: Rule{Condition: High52Week, WebhookURL: "http://[fd00::1]/hook"}, wantErr: ErrBlockedAddress},
+		{name: "cgnat", rule: Rule{Condition: High52Week, WebhookURL: "http://100.64.0.1/hook"}, wantErr: ErrBlockedAddress},
+		{name: "link local", rule: Rule{Condition: High52Week, WebhookURL: "http://169.254.169.254/latest"}, wantErr: ErrBlockedAddress},
+		{name: "unspecified", rule: Rule{Condition: High52Week, WebhookURL: "http://0.0.0.0/hook"}, wantErr: ErrBlockedAddress},
+		{name: "ipv4 mapped loopback", rule: Rule{Condition: High52Week, WebhookURL: "http://[::ffff:127.0.0.1]/hook"}, wantErr: ErrBlockedAddress},
+		{name: "ipv4 mapped private", rule: Rule{Condition: High52Week, WebhookURL: "http://[::ffff:192.168.1.1]/hook"}, wantErr: ErrBlockedAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("error %v does not wrap ErrInvalidRule", err)
+			}
+		})
+	}
+}
+
+type quotes struct {
+	price float64
+}
+
+func (q *quotes) GetMarketWatch() ([]stock.MarketWatchResponse, error) {
+	return []stock.MarketWatchResponse{{TadawulID: "2222", Price: q.price}}, nil
+}
+
+func (q *quotes) GetDetailedCompanyStockPrices(string, stock.PriceQuery) ([]stock.GetDetailedCompanyStockPricesResponse, error) {
+	return nil, nil
+}
+
+func TestPollRearming(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	once, err := repo.CreateRule(ctx, "once", Rule{TadawulID: "2222", Condition: PriceAbove, Threshold: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repeat, err := repo.CreateRule(ctx, "repeat", Rule{TadawulID: "2222", Condition: PriceAbove, Threshold: 100, Repeat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &quotes{}
+	poller := NewPoller(repo, source, "")
+
+	steps := []struct {
+		price         float64
+		wantFired     int
+		wantTriggered map[string]bool
+	}{
+		{price: 101, wantFired: 2, wantTriggered: map[string]bool{once.ID: true, repeat.ID: true}},
+		{price: 102, wantFired: 0, wantTriggered: map[string]bool{once.ID: true, repeat.ID: true}},
+		{price: 99, wantFired: 0, wantTriggered: map[string]bool{once.ID: true, repeat.ID: false}},
+		{price: 101, wantFired: 1, wantTriggered: map[string]bool{once.ID: true, repeat.ID: true}},
+	}
+	for i, step := range steps {
+		source.price = step.price
+		fired, err := poller.Poll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fired != step.wantFired {
+			t.Errorf("poll %d at %v fired %d, want %d", i+1, step.price, fired, step.wantFired)
+		}
+		for _, userID := range []string{"once", "repeat"} {
+			rules, _ := repo.ListRules(ctx, userID)
+			if rules[0].Triggered != step.wantTriggered[rules[0].ID] {
+				t.Errorf("poll %d at %v %s triggered = %v", i+1, step.price, userID, rules[0].Triggered)
+			}
+		}
+	}
+
+	for userID, want := range map[string]int{"once": 1, "repeat": 2} {
+		events, _ := repo.ListEvents(ctx, userID, "", 0)
+		if len(events) != want {
+			t.Errorf("%s has %d events, want %d", userID, len(events), want)
+		}
+		for _, event := range events {
+			if event.Status != StatusSkipped {
+				t.Errorf("event without a webhook is %s, want %s", event.Status, StatusSkipped)
+			}
+		}
+	}
+}
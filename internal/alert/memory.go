@@ -0,0 +1,182 @@
+package alert
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps rules and events in process memory. It is used when
+// no database is configured, events still pending are lost on restart.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	rules  map[string]*Rule
+	events map[string]*Event
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{rules: map[string]*Rule{}, events: map[string]*Event{}}
+}
+
+func (r *MemoryRepository) CreateRule(ctx context.Context, userID string, rule Rule) (*Rule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, existing := range r.rules {
+		if existing.UserID == userID {
+			count++
+		}
+	}
+	if count >= MaxRules {
+		return nil, ErrLimitReached
+	}
+
+	rule.ID = uuid.NewString()
+	rule.UserID = userID
+	rule.Triggered = false
+	rule.LastTriggeredAt = nil
+	rule.CreatedAt = time.Now().UTC()
+	r.rules[rule.ID] = &rule
+	copied := rule
+	return &copied, nil
+}
+
+func (r *MemoryRepository) ListRules(ctx context.Context, userID string) ([]Rule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := []Rule{}
+	for _, rule := range r.rules {
+		if rule.UserID == userID {
+			rules = append(rules, *rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].CreatedAt.Before(rules[j].CreatedAt) })
+	return rules, nil
+}
+
+func (r *MemoryRepository) DeleteRule(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.rules[id]
+	if !ok || rule.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.rules, id)
+	return nil
+}
+
+func (r *MemoryRepository) ListEvents(ctx context.Context, userID string, status Status, limit int) ([]Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []Event{}
+	for _, event := range r.events {
+		if event.UserID == userID && (status == "" || event.Status == status) {
+			events = append(events, *event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].TriggeredAt.After(events[j].TriggeredAt) })
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryRepository) RetryEvent(ctx context.Context, userID string, id string) (*Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[id]
+	if !ok || event.UserID != userID {
+		return nil, ErrNotFound
+	}
+	if event.Status != StatusDead {
+		return nil, ErrNotDeadLettered
+	}
+	event.Status = StatusPending
+	event.Attempts = 0
+	event.NextAttemptAt = time.Now().UTC()
+	copied := *event
+	return &copied, nil
+}
+
+func (r *MemoryRepository) ActiveRules(ctx context.Context) ([]Rule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := []Rule{}
+	for _, rule := range r.rules {
+		if rule.Repeat || !rule.Triggered {
+			rules = append(rules, *rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *MemoryRepository) Fire(ctx context.Context, rule Rule, event Event) (*Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.rules[rule.ID]
+	if !ok || stored.Triggered {
+		return nil, ErrNotFound
+	}
+	stored.Triggered = true
+	stored.LastTriggeredAt = &event.TriggeredAt
+
+	event.ID = uuid.NewString()
+	r.events[event.ID] = &event
+	copied := event
+	return &copied, nil
+}
+
+func (r *MemoryRepository) Rearm(ctx context.Context, ruleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.rules[ruleID]
+	if !ok || !rule.Triggered {
+		return ErrNotFound
+	}
+	rule.Triggered = false
+	return nil
+}
+
+func (r *MemoryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []Event
+	for _, event := range r.events {
+		if len(due) >= limit {
+			break
+		}
+		if event.Status == StatusPending && !event.NextAttemptAt.After(now) {
+			event.NextAttemptAt = now.Add(lease)
+			due = append(due, *event)
+		}
+	}
+	return due, nil
+}
+
+func (r *MemoryRepository) UpdateDelivery(ctx context.Context, event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.events[event.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Status = event.Status
+	stored.Attempts = event.Attempts
+	stored.LastError = event.LastError
+	stored.NextAttemptAt = event.NextAttemptAt
+	stored.DeliveredAt = event.DeliveredAt
+	return nil
+}
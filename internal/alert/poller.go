@@ -0,0 +1,183 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/timeseries"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PriceSource is the market data the poller evaluates rules against.
+type PriceSource interface {
+	GetMarketWatch() ([]stock.MarketWatchResponse, error)
+	GetDetailedCompanyStockPrices(companyID string, query stock.PriceQuery) ([]stock.GetDetailedCompanyStockPricesResponse, error)
+}
+
+type baseline struct {
+	day    string
+	volume float64
+}
+
+// Poller evaluates the active rules against the market watch and records an
+// event for every rule that fires. Rules without a webhook URL fall back to
+// defaultWebhookURL, events with neither are kept in the history only.
+type Poller struct {
+	repo              Repository
+	source            PriceSource
+	defaultWebhookURL string
+
+	mu        sync.Mutex
+	baselines map[string]baseline
+}
+
+func NewPoller(repo Repository, source PriceSource, defaultWebhookURL string) *Poller {
+	return &Poller{
+		repo:              repo,
+		source:            source,
+		defaultWebhookURL: defaultWebhookURL,
+		baselines:         map[string]baseline{},
+	}
+}
+
+// Poll runs one evaluation round and returns how many rules fired.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	rules, err := p.repo.ActiveRules(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("alert :: Poller.Poll :: %w", err)
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	marketWatch, err := p.source.GetMarketWatch()
+	if err != nil {
+		return 0, fmt.Errorf("alert :: Poller.Poll :: error fetching market watch: %w", err)
+	}
+	quotes := make(map[string]stock.MarketWatchResponse, len(marketWatch))
+	for _, q := range marketWatch {
+		quotes[q.TadawulID] = q
+	}
+
+	fired := 0
+	now := time.Now().UTC()
+	for _, rule := range rules {
+		q, ok := quotes[rule.TadawulID]
+		if !ok {
+			continue
+		}
+		var volume float64
+		if rule.Condition == VolumeSpike {
+			if volume, err = p.averageVolume(rule.TadawulID, now); err != nil {
+				log.Warn().Msg("alert :: Poller.Poll :: " + err.Error())
+				continue
+			}
+		}
+		value, hit, err := rule.Evaluate(q, volume)
+		if err != nil {
+			log.Warn().Msg("alert :: Poller.Poll :: " + err.Error())
+			continue
+		}
+
+		switch {
+		case hit && !rule.Triggered:
+			_, err := p.repo.Fire(ctx, rule, p.newEvent(rule, q, value, now))
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				log.Error().Msg("alert :: Poller.Poll :: " + err.Error())
+				continue
+			}
+			fired++
+		case !hit && rule.Triggered && rule.Repeat:
+			if err := p.repo.Rearm(ctx, rule.ID); err != nil && !errors.Is(err, ErrNotFound) {
+				log.Error().Msg("alert :: Poller.Poll :: " + err.Error())
+			}
+		}
+	}
+	return fired, nil
+}
+
+// Run polls right away and then every interval until ctx is done.
+func (p *Poller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if fired, err := p.Poll(ctx); err != nil {
+			log.Error().Msg(err.Error())
+		} else if fired > 0 {
+			log.Info().Msgf("alert :: Poller.Run :: %d alerts fired", fired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) newEvent(rule Rule, q stock.MarketWatchResponse, value float64, now time.Time) Event {
+	event := Event{
+		RuleID:        rule.ID,
+		UserID:        rule.UserID,
+		TadawulID:     rule.TadawulID,
+		Condition:     rule.Condition,
+		Threshold:     rule.Threshold,
+		Value:         value,
+		Price:         q.Price,
+		WebhookURL:    rule.WebhookURL,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		TriggeredAt:   now,
+	}
+	if event.WebhookURL == "" {
+		event.WebhookURL = p.defaultWebhookURL
+	}
+	if event.WebhookURL == "" {
+		event.Status = StatusSkipped
+	}
+	return event
+}
+
+// averageVolume is the average daily volume of the last month before today,
+// computed once a day per company.
+func (p *Poller) averageVolume(tadawulID string, now time.Time) (float64, error) {
+	day := now.In(timeseries.Riyadh).Format("2006-01-02")
+
+	p.mu.Lock()
+	cached, ok := p.baselines[tadawulID]
+	p.mu.Unlock()
+	if ok && cached.day == day {
+		return cached.volume, nil
+	}
+
+	bars, err := p.source.GetDetailedCompanyStockPrices(tadawulID, stock.PriceQuery{Period: stock.Period1M})
+	if err != nil {
+		return 0, fmt.Errorf("alert :: Poller.averageVolume :: %w", err)
+	}
+	var total float64
+	var days int
+	for _, bar := range bars {
+		date, err := stock.ParseBarDate(bar.Date)
+		if err != nil || date.In(timeseries.Riyadh).Format("2006-01-02") == day {
+			continue
+		}
+		total += float64(bar.Volume)
+		days++
+	}
+	if days == 0 {
+		return 0, fmt.Errorf("alert :: Poller.averageVolume :: %w for %s", ErrMissingBaseline, tadawulID)
+	}
+
+	volume := total / float64(days)
+	p.mu.Lock()
+	p.baselines[tadawulID] = baseline{day: day, volume: volume}
+	p.mu.Unlock()
+	return volume, nil
+}
@@ -0,0 +1,242 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ruleColumns  = "id, user_id, tadawul_id, condition, threshold, repeat, webhook_url, triggered, last_triggered_at, created_at"
+	eventColumns = "id, rule_id, user_id, tadawul_id, condition, threshold, value, price, webhook_url, status, attempts, last_error, next_attempt_at, delivered_at, triggered_at"
+)
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) CreateRule(ctx context.Context, userID string, rule Rule) (*Rule, error) {
+	var created Rule
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		// @NOTE: Serializes creations per user so two requests can't both pass the limit
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('alerts:' || $1))", userID); err != nil {
+			return err
+		}
+		var count int
+		if err := tx.QueryRow(ctx, "SELECT count(*) FROM alert_rules WHERE user_id = $1", userID).Scan(&count); err != nil {
+			return err
+		}
+		if count >= MaxRules {
+			return ErrLimitReached
+		}
+
+		return scanRule(tx.QueryRow(ctx, `
+			INSERT INTO alert_rules (id, user_id, tadawul_id, condition, threshold, repeat, webhook_url)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING `+ruleColumns,
+			uuid.NewString(), userID, rule.TadawulID, rule.Condition, rule.Threshold, rule.Repeat, rule.WebhookURL,
+		), &created)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("alert :: CreateRule :: %w", err)
+	}
+	return &created, nil
+}
+
+func (r *PostgresRepository) ListRules(ctx context.Context, userID string) ([]Rule, error) {
+	return r.queryRules(ctx, "ListRules", `
+		SELECT `+ruleColumns+` FROM alert_rules WHERE user_id = $1 ORDER BY created_at`,
+		userID,
+	)
+}
+
+func (r *PostgresRepository) DeleteRule(ctx context.Context, userID string, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+	tag, err := r.pool.Exec(ctx, "DELETE FROM alert_rules WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("alert :: DeleteRule :: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListEvents(ctx context.Context, userID string, status Status, limit int) ([]Event, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+eventColumns+` FROM alert_events
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY triggered_at DESC LIMIT $3`,
+		userID, status, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("alert :: ListEvents :: %w", err)
+	}
+	return collectEvents(rows, "ListEvents")
+}
+
+func (r *PostgresRepository) RetryEvent(ctx context.Context, userID string, id string) (*Event, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	var event Event
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		err := scanEvent(tx.QueryRow(ctx, `
+			SELECT `+eventColumns+` FROM alert_events WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+			id, userID,
+		), &event)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if event.Status != StatusDead {
+			return ErrNotDeadLettered
+		}
+		return scanEvent(tx.QueryRow(ctx, `
+			UPDATE alert_events SET status = $2, attempts = 0, next_attempt_at = now()
+			WHERE id = $1 RETURNING `+eventColumns,
+			id, StatusPending,
+		), &event)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("alert :: RetryEvent :: %w", err)
+	}
+	return &event, nil
+}
+
+func (r *PostgresRepository) ActiveRules(ctx context.Context) ([]Rule, error) {
+	return r.queryRules(ctx, "ActiveRules", `
+		SELECT `+ruleColumns+` FROM alert_rules WHERE repeat OR NOT triggered`,
+	)
+}
+
+func (r *PostgresRepository) Fire(ctx context.Context, rule Rule, event Event) (*Event, error) {
+	event.ID = uuid.NewString()
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE alert_rules SET triggered = true, last_triggered_at = $2 WHERE id = $1 AND (NOT triggered)`,
+			rule.ID, event.TriggeredAt,
+		)
+		if err != nil {
+			return err
+		}
+		// @NOTE: The rule was deleted, or another poller fired it, since it was loaded
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO alert_events (`+eventColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+			event.ID, event.RuleID, event.UserID, event.TadawulID, event.Condition, event.Threshold, event.Value, event.Price,
+			event.WebhookURL, event.Status, event.Attempts, event.LastError, event.NextAttemptAt, event.DeliveredAt, event.TriggeredAt,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("alert :: Fire :: %w", err)
+	}
+	return &event, nil
+}
+
+func (r *PostgresRepository) Rearm(ctx context.Context, ruleID string) error {
+	tag, err := r.pool.Exec(ctx, "UPDATE alert_rules SET triggered = false WHERE id = $1 AND triggered", ruleID)
+	if err != nil {
+		return fmt.Errorf("alert :: Rearm :: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Event, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE alert_events SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM alert_events
+			WHERE status = $3 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+eventColumns,
+		now, now.Add(lease), StatusPending, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("alert :: ClaimDue :: %w", err)
+	}
+	return collectEvents(rows, "ClaimDue")
+}
+
+func (r *PostgresRepository) UpdateDelivery(ctx context.Context, event Event) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE alert_events
+		SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, delivered_at = $6
+		WHERE id = $1`,
+		event.ID, event.Status, event.Attempts, event.LastError, event.NextAttemptAt, event.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("alert :: UpdateDelivery :: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) queryRules(ctx context.Context, method string, sql string, args ...any) ([]Rule, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("alert :: %s :: %w", method, err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		var rule Rule
+		if err := scanRule(rows, &rule); err != nil {
+			return nil, fmt.Errorf("alert :: %s :: error scanning: %w", method, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func collectEvents(rows pgx.Rows, method string) ([]Event, error) {
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		if err := scanEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("alert :: %s :: error scanning: %w", method, err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func scanRule(row pgx.Row, rule *Rule) error {
+	return row.Scan(
+		&rule.ID, &rule.UserID, &rule.TadawulID, &rule.Condition, &rule.Threshold, &rule.Repeat,
+		&rule.WebhookURL, &rule.Triggered, &rule.LastTriggeredAt, &rule.CreatedAt,
+	)
+}
+
+func scanEvent(row pgx.Row, event *Event) error {
+	return row.Scan(
+		&event.ID, &event.RuleID, &event.UserID, &event.TadawulID, &event.Condition, &event.Threshold,
+		&event.Value, &event.Price, &event.WebhookURL, &event.Status, &event.Attempts, &event.LastError,
+		&event.NextAttemptAt, &event.DeliveredAt, &event.TriggeredAt,
+	)
+}
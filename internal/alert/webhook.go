@@ -0,0 +1,240 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"patient-chatbot/internal/mapping"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxBackoff      = time.Hour
+	claimBatch      = 20
+	SignatureHeader = "X-Alert-Signature"
+	TimestampHeader = "X-Alert-Timestamp"
+	EventIDHeader   = "X-Alert-Event-Id"
+)
+
+var (
+	// ErrBlockedAddress is returned for webhooks resolving to a loopback,
+	// private, link-local or otherwise non public address.
+	ErrBlockedAddress = errors.New("webhook address is not public")
+	// ErrDeliveryFailed is what the history shows for every other failure,
+	// the details only go to the logs so the status codes and dial errors
+	// of arbitrary hosts aren't handed back to users.
+	ErrDeliveryFailed = errors.New("webhook delivery failed")
+	errRedirect       = errors.New("webhook redirects are not followed")
+	cgnat             = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	ID            string    `json:"id"`
+	RuleID        string    `json:"ruleId"`
+	TadawulID     string    `json:"tadawulId"`
+	CompanyName   string    `json:"companyName"`
+	CompanyNameAr string    `json:"companyNameAr"`
+	Condition     Condition `json:"condition"`
+	Threshold     float64   `json:"threshold,omitempty"`
+	Value         float64   `json:"value"`
+	Price         float64   `json:"price"`
+	TriggeredAt   time.Time `json:"triggeredAt"`
+	Attempt       int       `json:"attempt"`
+}
+
+// Deliverer posts pending events to their webhook, retrying failures with an
+// exponential backoff and dead lettering them after maxAttempts. Webhooks set
+// by users may only reach public addresses, the operator's defaultURL is
+// trusted and may point at the internal network.
+type Deliverer struct {
+	repo        Repository
+	client      *http.Client
+	trusted     *http.Client
+	defaultURL  string
+	secret      string
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewDeliverer(repo Repository, defaultURL string, secret string, maxAttempts int, backoff time.Duration, timeout time.Duration) *Deliverer {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// @NOTE: A proxy would dial on our behalf, past the address check
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: timeout, Control: refuseNonPublic}).DialContext
+
+	return &Deliverer{
+		repo:        repo,
+		client:      &http.Client{Timeout: timeout, Transport: transport, CheckRedirect: refuseRedirect},
+		trusted:     &http.Client{Timeout: timeout, CheckRedirect: refuseRedirect},
+		defaultURL:  defaultURL,
+		secret:      secret,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// refuseNonPublic runs after DNS resolution, on the address actually dialed,
+// so a hostname can't be pointed at the internal network after the rule was
+// created.
+func refuseNonPublic(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrBlockedAddress
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !isPublic(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !cgnat.Contains(ip)
+}
+
+func refuseRedirect(req *http.Request, via []*http.Request) error {
+	return errRedirect
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body" under secret, sent as
+// "sha256=<hex>" in the X-Alert-Signature header. Receivers recompute it
+// and should reject stale timestamps to prevent replays.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliverDue attempts every event that is due and returns how many were
+// delivered.
+func (d *Deliverer) DeliverDue(ctx context.Context) (int, error) {
+	// @NOTE: The lease outlives a request so a slow webhook isn't claimed twice
+	events, err := d.repo.ClaimDue(ctx, time.Now().UTC(), 2*d.client.Timeout, claimBatch)
+	if err != nil {
+		return 0, fmt.Errorf("alert :: Deliverer.DeliverDue :: %w", err)
+	}
+
+	delivered := 0
+	for _, event := range events {
+		event.Attempts++
+		now := time.Now().UTC()
+		if err := d.post(ctx, event); err != nil {
+			log.Warn().Msgf("alert :: Deliverer.DeliverDue :: event %s attempt %d failed: %s", event.ID, event.Attempts, err)
+			switch {
+			case errors.Is(err, ErrBlockedAddress):
+				event.LastError = ErrBlockedAddress.Error()
+			case errors.Is(err, ErrWebhooksDisabled):
+				event.LastError = ErrWebhooksDisabled.Error()
+			default:
+				event.LastError = ErrDeliveryFailed.Error()
+			}
+			if event.Attempts >= d.maxAttempts {
+				event.Status = StatusDead
+				log.Warn().Msgf("alert :: Deliverer.DeliverDue :: event %s dead lettered after %d attempts: %s", event.ID, event.Attempts, err)
+			} else {
+				event.NextAttemptAt = now.Add(d.retryDelay(event.Attempts))
+			}
+		} else {
+			event.Status = StatusDelivered
+			event.LastError = ""
+			event.DeliveredAt = &now
+			delivered++
+		}
+		if err := d.repo.UpdateDelivery(ctx, event); err != nil {
+			log.Error().Msg("alert :: Deliverer.DeliverDue :: " + err.Error())
+		}
+	}
+	return delivered, nil
+}
+
+// Run delivers due events every interval until ctx is done.
+func (d *Deliverer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil {
+			log.Error().Msg(err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Deliverer) post(ctx context.Context, event Event) error {
+	payload := Payload{
+		ID:          event.ID,
+		RuleID:      event.RuleID,
+		TadawulID:   event.TadawulID,
+		Condition:   event.Condition,
+		Threshold:   event.Threshold,
+		Value:       event.Value,
+		Price:       event.Price,
+		TriggeredAt: event.TriggeredAt,
+		Attempt:     event.Attempts,
+	}
+	if company, err := mapping.Lookup(event.TadawulID); err == nil {
+		payload.CompanyName, payload.CompanyNameAr = company.CompanyName, company.CompanyNameAr
+	}
+	if d.secret == "" {
+		return ErrWebhooksDisabled
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshalling payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, event.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, event.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(d.secret, timestamp, body))
+
+	client := d.client
+	if event.WebhookURL == d.defaultURL {
+		client = d.trusted
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %d", res.StatusCode)
+	}
+	return nil
+}
+
+// retryDelay doubles the backoff after every failed attempt, up to an hour.
+func (d *Deliverer) retryDelay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	d := NewDeliverer(NewMemoryRepository(), "", "secret", 20, time.Minute, time.Second)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 4, want: 8 * time.Minute},
+		{attempts: 6, want: 32 * time.Minute},
+		{attempts: 7, want: maxBackoff},
+		{attempts: 1000, want: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := d.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	const want = "sha256=086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54"
+
+	if got := Sign("secret", "1700000000", body); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+	if Sign("secret", "1700000001", body) == want {
+		t.Error("signature does not cover the timestamp")
+	}
+	if Sign("other", "1700000000", body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
@@ -0,0 +1,250 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"patient-chatbot/internal/dto"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	SUMMARY_SYSTEM_PROMPT = `
+	You summarize conversations between a user and Mudawul, a Saudi stock market assistant.
+	Write a compact plain text summary of the conversation so far so the assistant can continue it.
+	Keep company names, tadawul ids, prices, dates, figures and any open questions or user preferences.
+	Do NOT use any Markdown. Answer with the summary only.
+	`
+
+	messageOverheadTokens = 4
+	minToolResultTokens   = 64
+	maxSummaryCacheSize   = 1024
+)
+
+// ContextManager keeps a conversation within the model's token budget. It
+// always keeps the system prompt and the most recent turns, and replaces the
+// older turns with a rolling LLM-generated summary.
+type ContextManager struct {
+	provider      Provider
+	defaultBudget int
+	budgets       map[string]int
+	reserved      int
+
+	mu        sync.Mutex
+	summaries map[string]string
+	order     []string
+}
+
+func NewContextManager(provider Provider, defaultBudget int, budgets map[string]int, reserved int) *ContextManager {
+	return &ContextManager{
+		provider:      provider,
+		defaultBudget: defaultBudget,
+		budgets:       budgets,
+		reserved:      reserved,
+		summaries:     map[string]string{},
+	}
+}
+
+// EstimateTokens approximates the tokenizer without depending on one: about
+// four characters per token for Latin script and two for Arabic.
+func EstimateTokens(text string) int {
+	var quarterTokens int
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			quarterTokens++
+		} else {
+			quarterTokens += 2
+		}
+	}
+	return (quarterTokens + 3) / 4
+}
+
+func EstimateMessageTokens(message ChatMessageBlock) int {
+	tokens := messageOverheadTokens + EstimateTokens(message.Content)
+	for _, toolCall := range message.ToolCalls {
+		tokens += EstimateTokens(toolCall.Function.Name) + EstimateTokens(string(toolCall.Function.Arguments))
+	}
+	return tokens
+}
+
+func (m *ContextManager) Budget(model string) int {
+	if budget, ok := m.budgets[model]; ok {
+		return budget - m.reserved
+	}
+	return m.defaultBudget - m.reserved
+}
+
+// Fit returns the system messages followed by as many recent turns as fit in
+// the model's budget, with a summary of the dropped turns when there are any,
+// and then the tool calls and results of the current answer. Those are always
+// kept whole, their payloads trimmed to at most half the budget.
+func (m *ContextManager) Fit(ctx context.Context, model string, system []ChatMessageBlock, history []ChatMessageBlock, turn []ChatMessageBlock) []ChatMessageBlock {
+	budget := m.Budget(model)
+	for _, message := range system {
+		budget -= EstimateMessageTokens(message)
+	}
+	turn = trimToolResults(turn, budget/2)
+	for _, message := range turn {
+		budget -= EstimateMessageTokens(message)
+	}
+
+	used, start := 0, len(history)
+	for i := len(history) - 1; i >= 0; i-- {
+		tokens := EstimateMessageTokens(history[i])
+		if used+tokens > budget && start < len(history) {
+			break
+		}
+		used += tokens
+		start = i
+	}
+	// @NOTE: Never open the window on a reply, the model needs the question it answers
+	for start < len(history)-1 && history[start].Role != dto.UserRole {
+		start++
+	}
+
+	fitted := append([]ChatMessageBlock{}, system...)
+	if start > 0 {
+		summary, err := m.summarize(ctx, model, history[:start])
+		if err != nil {
+			log.Error().Msg("llm client :: ContextManager.Fit :: " + err.Error())
+		} else if summary != "" {
+			fitted = append(fitted, ChatMessageBlock{
+				Role:    dto.SystemRole,
+				Content: "Summary of the earlier conversation:\n" + summary,
+			})
+		}
+		log.Info().Msgf("llm client :: ContextManager.Fit :: summarized %d of %d messages for model %s", start, len(history), model)
+	}
+	fitted = append(fitted, history[start:]...)
+	return append(fitted, turn...)
+}
+
+// trimToolResults shortens the tool results of turn so the whole turn takes
+// at most limit tokens. The smallest results are kept whole first and the
+// rest share what is left evenly.
+func trimToolResults(turn []ChatMessageBlock, limit int) []ChatMessageBlock {
+	total, remaining := 0, limit
+	var results []int
+	for i, message := range turn {
+		total += EstimateMessageTokens(message)
+		if message.Role == dto.ToolRole {
+			results = append(results, i)
+		} else {
+			remaining -= EstimateMessageTokens(message)
+		}
+	}
+	if total <= limit || len(results) == 0 {
+		return turn
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		return EstimateMessageTokens(turn[results[a]]) < EstimateMessageTokens(turn[results[b]])
+	})
+	trimmed := append([]ChatMessageBlock{}, turn...)
+	for n, i := range results {
+		share := max(remaining/(len(results)-n), minToolResultTokens)
+		if tokens := EstimateMessageTokens(turn[i]); tokens > share {
+			trimmed[i].Content = truncateTokens(turn[i].Content, share-messageOverheadTokens) + "\n[truncated]"
+			remaining -= share
+		} else {
+			remaining -= tokens
+		}
+	}
+	return trimmed
+}
+
+// truncateTokens cuts text to about tokens tokens, with the estimate of
+// EstimateTokens.
+func truncateTokens(text string, tokens int) string {
+	quarterTokens := 0
+	for i, r := range text {
+		if r < utf8.RuneSelf {
+			quarterTokens++
+		} else {
+			quarterTokens += 2
+		}
+		if quarterTokens > tokens*4 {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// summarize extends the longest already summarized prefix of older instead
+// of re-summarizing the whole conversation on every request.
+func (m *ContextManager) summarize(ctx context.Context, model string, older []ChatMessageBlock) (string, error) {
+	keys := prefixKeys(older)
+
+	m.mu.Lock()
+	from, previous := 0, ""
+	for i := len(keys) - 1; i >= 0; i-- {
+		if summary, ok := m.summaries[keys[i]]; ok {
+			from, previous = i+1, summary
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if from == len(older) {
+		return previous, nil
+	}
+
+	var transcript strings.Builder
+	if previous != "" {
+		transcript.WriteString("Summary so far:\n" + previous + "\n\nNew messages:\n")
+	}
+	for _, message := range older[from:] {
+		if message.Content == "" {
+			continue
+		}
+		transcript.WriteString(fmt.Sprintf("%s: %s\n", message.Role, message.Content))
+	}
+
+	summary, _, err := m.provider.Complete(ctx, ChatRequest{
+		Model: model,
+		Messages: []ChatMessageBlock{
+			{Role: dto.SystemRole, Content: SUMMARY_SYSTEM_PROMPT},
+			{Role: dto.UserRole, Content: transcript.String()},
+		},
+		Temperature:         0,
+		MaxCompletionTokens: 512,
+		TopP:                1.0,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error summarizing conversation: %w", err)
+	}
+	summary = strings.TrimSpace(summary)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := keys[len(keys)-1]
+	if _, ok := m.summaries[key]; !ok {
+		m.order = append(m.order, key)
+	}
+	m.summaries[key] = summary
+	for len(m.order) > maxSummaryCacheSize {
+		delete(m.summaries, m.order[0])
+		m.order = m.order[1:]
+	}
+	return summary, nil
+}
+
+// prefixKeys returns a running hash of messages so keys[i] identifies messages[:i+1].
+func prefixKeys(messages []ChatMessageBlock) []string {
+	keys := make([]string, len(messages))
+	h := sha256.New()
+	for i, message := range messages {
+		h.Write([]byte(message.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(message.Content))
+		h.Write([]byte{0})
+		keys[i] = hex.EncodeToString(h.Sum(nil))
+	}
+	return keys
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"patient-chatbot/internal/dto"
+	"strings"
+	"testing"
+)
+
+func turns(n int, words int) []ChatMessageBlock {
+	content := strings.Repeat("word ", words)
+	history := make([]ChatMessageBlock, 0, n)
+	for i := 0; i < n; i++ {
+		role := dto.UserRole
+		if i%2 == 1 {
+			role = dto.AssistantRole
+		}
+		history = append(history, ChatMessageBlock{Role: role, Content: content})
+	}
+	return history
+}
+
+func totalTokens(messages []ChatMessageBlock) int {
+	var tokens int
+	for _, message := range messages {
+		tokens += EstimateMessageTokens(message)
+	}
+	return tokens
+}
+
+func TestFitSummaryRequestHasNoToolFields(t *testing.T) {
+	provider := NewFakeProvider(FakeResponse{Content: "earlier summary"})
+	manager := NewContextManager(provider, 400, nil, 0)
+
+	system := []ChatMessageBlock{{Role: dto.SystemRole, Content: "system"}}
+	fitted := manager.Fit(context.Background(), "test", system, turns(9, 100), nil)
+
+	requests := provider.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d summary requests, want 1", len(requests))
+	}
+	body, err := json.Marshal(requests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"tools"`, `"tool_choice"`, `"stop"`} {
+		if strings.Contains(string(body), field) {
+			t.Errorf("summary request carries %s: %s", field, body)
+		}
+	}
+	if fitted[1].Role != dto.SystemRole || !strings.Contains(fitted[1].Content, "earlier summary") {
+		t.Errorf("second message = %+v, want the summary", fitted[1])
+	}
+	// @NOTE: The summary itself is bounded by its completion limit, not the budget
+	if tokens := totalTokens(fitted) - EstimateMessageTokens(fitted[1]); tokens > 400 {
+		t.Errorf("fitted messages take %d tokens, budget is 400", tokens)
+	}
+}
+
+func TestFitBudgetsToolResults(t *testing.T) {
+	tests := []struct {
+		name        string
+		results     []int
+		wantTrimmed []bool
+	}{
+		{name: "small results are kept whole", results: []int{50, 60}, wantTrimmed: []bool{false, false}},
+		{name: "one large result is trimmed", results: []int{50, 5000}, wantTrimmed: []bool{false, true}},
+		{name: "every large result is trimmed", results: []int{3000, 5000}, wantTrimmed: []bool{true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewContextManager(NewFakeProvider(FakeResponse{Content: "summary"}), 2000, nil, 0)
+			system := []ChatMessageBlock{{Role: dto.SystemRole, Content: "system"}}
+
+			turn := []ChatMessageBlock{{Role: dto.AssistantRole, ToolCalls: []ToolCallsBlock{toolCall("c1", "Lookup", `{}`), toolCall("c2", "Lookup", `{}`)}}}
+			for i, words := range tt.results {
+				turn = append(turn, ChatMessageBlock{Role: dto.ToolRole, ToolCallID: []string{"c1", "c2"}[i], Content: strings.Repeat("data ", words)})
+			}
+			fitted := manager.Fit(context.Background(), "test", system, turns(5, 100), turn)
+
+			if tokens := totalTokens(fitted); tokens > 2000 {
+				t.Errorf("fitted messages take %d tokens, budget is 2000", tokens)
+			}
+			tail := fitted[len(fitted)-len(turn):]
+			if tail[0].Role != dto.AssistantRole || len(tail[0].ToolCalls) != 2 {
+				t.Fatalf("the tool call message was not kept before its results: %+v", tail[0])
+			}
+			for i, want := range tt.wantTrimmed {
+				if trimmed := strings.HasSuffix(tail[i+1].Content, "[truncated]"); trimmed != want {
+					t.Errorf("result %d trimmed = %v, want %v", i, trimmed, want)
+				}
+			}
+			if last := fitted[len(fitted)-len(turn)-1]; last.Role != dto.AssistantRole && last.Role != dto.UserRole {
+				t.Errorf("history before the turn ends with %s", last.Role)
+			}
+		})
+	}
+}
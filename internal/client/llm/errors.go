@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ErrorReason string
+
+const (
+	ReasonUnknown               ErrorReason = "unknown"
+	ReasonRateLimited           ErrorReason = "rate_limited"
+	ReasonUnavailable           ErrorReason = "unavailable"
+	ReasonTimeout               ErrorReason = "timeout"
+	ReasonContextLengthExceeded ErrorReason = "context_length_exceeded"
+)
+
+// ProviderError is a classified failure from an LLM provider.
+type ProviderError struct {
+	Reason     ErrorReason
+	StatusCode int
+	RetryAfter time.Duration
+	Message    string
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s (status %d): %s", e.Reason, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProviderError) Retryable() bool {
+	switch e.Reason {
+	case ReasonRateLimited, ReasonUnavailable, ReasonTimeout:
+		return true
+	}
+	return false
+}
+
+// ReasonOf returns the reason of the first ProviderError in err's chain.
+func ReasonOf(err error) ErrorReason {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Reason
+	}
+	return ReasonUnknown
+}
+
+// RetryAfterOf returns how long the provider asked us to wait, if it did.
+func RetryAfterOf(err error) time.Duration {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.RetryAfter
+	}
+	return 0
+}
+
+func classifyResponse(resp *http.Response, body []byte) *ProviderError {
+	providerErr := &ProviderError{
+		Reason:     ReasonUnknown,
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+	}
+
+	lower := strings.ToLower(string(body))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		providerErr.Reason = ReasonRateLimited
+		providerErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	case resp.StatusCode == http.StatusGatewayTimeout || resp.StatusCode == http.StatusRequestTimeout:
+		providerErr.Reason = ReasonTimeout
+	case resp.StatusCode >= 500:
+		providerErr.Reason = ReasonUnavailable
+	case strings.Contains(lower, "context_length_exceeded"),
+		strings.Contains(lower, "context length"),
+		strings.Contains(lower, "reduce the length"),
+		resp.StatusCode == http.StatusRequestEntityTooLarge:
+		providerErr.Reason = ReasonContextLengthExceeded
+	}
+	return providerErr
+}
+
+func classifyTransportError(err error) *ProviderError {
+	providerErr := &ProviderError{Reason: ReasonUnavailable, Message: err.Error(), Err: err}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		providerErr.Reason = ReasonTimeout
+	}
+	return providerErr
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -5,207 +5,180 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"patient-chatbot/internal/client/stock"
 	"patient-chatbot/internal/config"
 	"patient-chatbot/internal/dto"
-	"sort"
+	"patient-chatbot/internal/tool"
 	"strings"
-	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
-const (
-	CHAT_SYSTEM_PROMPT_EN = `
-	You Are Mudawul, a Saudi stock market expert.
-	Answer like a Saudi stock market expert.
-	Do NOT use any Markdown or styling characters (no asterisks, underscores, backticks, etc.).
-	Keep punctuation, spacing, and line breaks, but everything must be plain text.
-	`
-)
+const chatMaxCompletionTokens = 1024
 
 type LLMClient struct {
-	cfg         *config.Config
-	stockClient *stock.StockClient
+	cfg            *config.Config
+	provider       Provider
+	prompts        *Prompts
+	contextManager *ContextManager
+}
+
+func NewLLMClient(cfg *config.Config, provider Provider, prompts *Prompts) *LLMClient {
+	return &LLMClient{
+		cfg:            cfg,
+		provider:       provider,
+		prompts:        prompts,
+		contextManager: NewContextManager(provider, cfg.LLMContextBudget, cfg.LLMContextBudgets, chatMaxCompletionTokens),
+	}
 }
 
-func NewLLMClient(cfg *config.Config, stockClient *stock.StockClient) *LLMClient {
-	return &LLMClient{cfg: cfg, stockClient: stockClient}
+func (l *LLMClient) Chat(ctx context.Context, lang string, messages []dto.Message, answerContext *dto.Context, tools *tool.Registry) (string, []tool.Result, error) {
+	return l.chat(ctx, lang, messages, answerContext, tools, nil)
 }
 
-func (l *LLMClient) Chat(ctx context.Context, messages []dto.Message, answerContext *dto.Context) (string, []ToolCallsBlock, error) {
+// ChatStream runs the same tool loop as Chat but streams the completion,
+// reporting tokens and tool calls through callbacks as they arrive.
+func (l *LLMClient) ChatStream(ctx context.Context, lang string, messages []dto.Message, answerContext *dto.Context, tools *tool.Registry, callbacks *StreamCallbacks) (string, []tool.Result, error) {
+	if callbacks == nil {
+		callbacks = &StreamCallbacks{}
+	}
+	return l.chat(ctx, lang, messages, answerContext, tools, callbacks)
+}
+
+func (l *LLMClient) chat(ctx context.Context, lang string, messages []dto.Message, answerContext *dto.Context, tools *tool.Registry, callbacks *StreamCallbacks) (string, []tool.Result, error) {
+	systemPrompt, err := l.prompts.ChatSystem(lang)
+	if err != nil {
+		return "", nil, err
+	}
+
 	var sysBuf bytes.Buffer
-	sysBuf.WriteString(CHAT_SYSTEM_PROMPT_EN)
+	sysBuf.WriteString(systemPrompt)
 
-	if answerContext != nil && answerContext.Chart != "" {
-		sysBuf.WriteString("Context:\n")
-		sysBuf.WriteString("- " + fmt.Sprintf("%+v", answerContext.Stocks) + "\n")
+	chartContext, err := tools.RenderContext(answerContext)
+	if err != nil {
+		log.Error().Msg("llm client :: Chat :: " + err.Error())
+	} else if chartContext != "" {
+		sysBuf.WriteString("\nContext:\n")
+		sysBuf.WriteString(chartContext)
 	}
 
-	msgs := []ChatMessageBlock{
-		{Role: "system", Content: sysBuf.String()},
+	system := []ChatMessageBlock{
+		{Role: dto.SystemRole, Content: sysBuf.String()},
 	}
 
+	history := make([]ChatMessageBlock, 0, len(messages))
 	for _, message := range messages {
-		if strings.TrimSpace(message.Content) != "" {
-			msgs = append(msgs, ChatMessageBlock{
-				Role:    message.Role,
-				Content: message.Content,
-			})
+		if strings.TrimSpace(message.Content) == "" {
 			continue
 		}
-		if len(msgs) > 0 {
-			msgs = msgs[:len(msgs)-1]
-		}
+		history = append(history, ChatMessageBlock{
+			Role:    message.Role,
+			Content: message.Content,
+		})
 	}
 
-	reqBody := ChatRequest{
-		Messages:            msgs,
-		Temperature:         0,
-		MaxCompletionTokens: 1024,
-		TopP:                1.0,
-		Stream:              false,
-		Stop:                []string{"ERROR"},
-		Model:               l.cfg.LLMModel,
-		Tools: []ToolCallRequest{
-			{
-				Type: "function",
-				Function: ToolCallFunctionRequest{
-					Name:        string(stock.FunctionSearchCompanyStocks),
-					Description: "Search for a company's stocks by giving the company name",
-					Parameters: ParametersRequest{
-						Type: "object",
-						Properties: map[string]interface{}{
-							"companyName": map[string]interface{}{
-								"type":        "string",
-								"description": "The name of the company to search for",
-							},
-						},
-						Required: []string{"companyName"},
-					},
-				},
-			},
-			{
-				Type: "function",
-				Function: ToolCallFunctionRequest{
-					Name:        string(stock.FunctionGetDetailedCompanyStockPrices),
-					Description: "Get detailed company stock prices since last month by giving the company tadawul id",
-					Parameters: ParametersRequest{
-						Type: "object",
-						Properties: map[string]interface{}{
-							"tadawulID": map[string]interface{}{
-								"type":        "string",
-								"description": "The tadawul id of the company to search for",
-							},
-						},
-						Required: []string{"tadawulID"},
-					},
-				},
-			},
-		},
-		ToolChoice: "auto",
-	}
+	// @NOTE: Refitted every step, the tool results of the answer count against the budget too
+	var turn []ChatMessageBlock
+	var results []tool.Result
+	for step := 0; ; step++ {
+		msgs := l.contextManager.Fit(ctx, l.cfg.LLMModel, system, history, turn)
+		toolChoice := "auto"
+		if step >= l.cfg.LLMMaxToolSteps {
+			// @NOTE: Step limit reached, force the model to answer with what it has
+			toolChoice = "none"
+		}
 
-	payload, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", nil, fmt.Errorf("llm client :: Chat :: error marshalling chat request: %w", err)
-	}
+		request := l.newChatRequest(msgs, tools, toolChoice)
 
-	answer, toolCalls, err := CallGroqAPI(ctx, l.cfg, payload)
-	if err != nil {
-		return "", nil, fmt.Errorf("llm client :: Chat :: error calling groq API: %w", err)
+		var answer string
+		var toolCalls []ToolCallsBlock
+		if callbacks != nil {
+			request.Stream = true
+			answer, toolCalls, err = l.provider.Stream(ctx, request, callbacks.token)
+		} else {
+			answer, toolCalls, err = l.provider.Complete(ctx, request)
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("llm client :: Chat :: error calling %s provider: %w", l.provider.Name(), err)
+		}
+		if len(toolCalls) == 0 || toolChoice == "none" {
+			return answer, results, nil
+		}
+
+		turn = append(turn, ChatMessageBlock{
+			Role:      dto.AssistantRole,
+			Content:   answer,
+			ToolCalls: toolCalls,
+		})
+		for _, toolCall := range toolCalls {
+			if callbacks != nil {
+				callbacks.toolCall(toolCall.Function.Name)
+			}
+			result, content := l.executeToolCall(ctx, toolCall, tools)
+			if result != nil {
+				results = append(results, *result)
+			}
+			turn = append(turn, ChatMessageBlock{
+				Role:       dto.ToolRole,
+				Content:    content,
+				ToolCallID: toolCall.ID,
+				Name:       toolCall.Function.Name,
+			})
+		}
 	}
-	return answer, toolCalls, nil
 }
 
-func CallGroqAPI(ctx context.Context, cfg *config.Config, payload []byte) (string, []ToolCallsBlock, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.groq.com/openai/v1/chat/completions", bytes.NewReader(payload))
-	if err != nil {
-		return "", nil, fmt.Errorf("llm client :: CallGroqAPI :: error creating request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+cfg.GroqAPIKey)
+func (l *LLMClient) executeToolCall(ctx context.Context, toolCall ToolCallsBlock, tools *tool.Registry) (*tool.Result, string) {
+	log.Info().Msgf("llm client :: executeToolCall :: %s %s", toolCall.Function.Name, string(toolCall.Function.Arguments))
 
-	resp, err := http.DefaultClient.Do(req)
+	result, err := tools.Dispatch(ctx, toolCall.Function.Name, toolCall.Function.Arguments)
 	if err != nil {
-		return "", nil, fmt.Errorf("llm client :: CallGroqAPI :: error calling groq API: %w", err)
+		log.Error().Msg("llm client :: executeToolCall :: " + err.Error())
+		return nil, "error: " + err.Error()
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", nil, fmt.Errorf("llm client :: CallGroqAPI :: error calling groq API: %s", string(body))
+	if result == nil || result.Data == nil {
+		return result, "no results found"
 	}
 
-	var cr ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
-		return "", nil, fmt.Errorf("llm client :: CallGroqAPI :: error decoding chat response: %w", err)
-	}
-	if len(cr.Choices) == 0 {
-		return "", nil, fmt.Errorf("llm client :: CallGroqAPI :: no choices in chat response")
+	content, err := json.Marshal(result.Data)
+	if err != nil {
+		return nil, "error: could not encode tool result"
 	}
-	log.Info().Msg("CallGroqAPI :: " + fmt.Sprintf("%+v", cr.Choices[0].Message)) //@TODO: remove this
-	return cr.Choices[0].Message.Content, cr.Choices[0].Message.ToolCalls, nil
+	return result, string(content)
 }
 
-func DailySummary(ticks []stock.GetDetailedCompanyStockPricesResponse) []stock.GetDetailedCompanyStockPricesResponse {
-	agg := make(map[string]stock.GetDetailedCompanyStockPricesResponse)
-	for _, tk := range ticks {
-		t, err := time.Parse("2006-01-02 15:04:05", tk.Date)
-		if err != nil {
-			continue
-		}
-		dayKey := t.Format("2006-01-02")
-		if prev, ok := agg[dayKey]; ok {
-			if tk.High > prev.High {
-				prev.High = tk.High
-			}
-			if tk.Low < prev.Low {
-				prev.Low = tk.Low
-			}
-			prev.Close = tk.Close
-			prev.Volume += tk.Volume
-			agg[dayKey] = prev
-		} else {
-			agg[dayKey] = stock.GetDetailedCompanyStockPricesResponse{
-				Date:   dayKey + "T00:00:00",
-				Open:   tk.Open,
-				High:   tk.High,
-				Low:    tk.Low,
-				Close:  tk.Close,
-				Volume: tk.Volume,
-				X:      tk.X,
-				Y:      tk.Y,
-			}
-		}
+func (l *LLMClient) newChatRequest(msgs []ChatMessageBlock, tools *tool.Registry, toolChoice string) ChatRequest {
+	return ChatRequest{
+		Messages:            msgs,
+		Temperature:         0,
+		MaxCompletionTokens: chatMaxCompletionTokens,
+		TopP:                1.0,
+		Stream:              false,
+		Stop:                []string{"ERROR"},
+		Model:               l.cfg.LLMModel,
+		Tools:               toolCallRequests(tools),
+		ToolChoice:          toolChoice,
 	}
+}
 
-	var dates []time.Time
-	for d := range agg {
-		t, _ := time.Parse("2006-01-02", d)
-		dates = append(dates, t)
-	}
-	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
-
-	start, end := dates[0], dates[len(dates)-1]
-	result := []stock.GetDetailedCompanyStockPricesResponse{}
-	var lastThu stock.GetDetailedCompanyStockPricesResponse
-
-	for curr := start; !curr.After(end); curr = curr.AddDate(0, 0, 1) {
-		key := curr.Format("2006-01-02")
-		if entry, ok := agg[key]; ok {
-			result = append(result, entry)
-			if curr.Weekday() == time.Thursday {
-				lastThu = entry
-			}
-		} else if curr.Weekday() == time.Friday || curr.Weekday() == time.Saturday {
-			filled := lastThu
-			filled.Date = key + "T00:00:00"
-			result = append(result, filled)
+func toolCallRequests(tools *tool.Registry) []ToolCallRequest {
+	var requests []ToolCallRequest
+	for _, t := range tools.Tools() {
+		properties := make(map[string]interface{}, len(t.Parameters.Properties))
+		for name, property := range t.Parameters.Properties {
+			properties[name] = property
 		}
+		requests = append(requests, ToolCallRequest{
+			Type: "function",
+			Function: ToolCallFunctionRequest{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters: ParametersRequest{
+					Type:       "object",
+					Properties: properties,
+					Required:   t.Parameters.Required,
+				},
+			},
+		})
 	}
-
-	return result
+	return requests
 }
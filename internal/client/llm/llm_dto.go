@@ -2,14 +2,15 @@ package llm
 
 import (
 	"encoding/json"
-	"patient-chatbot/internal/client/stock"
 	"patient-chatbot/internal/dto"
 )
 
 type ChatMessageBlock struct {
-	Role      dto.Role         `json:"role"`
-	Content   string           `json:"content"`
-	ToolCalls []ToolCallsBlock `json:"tool_calls,omitempty"`
+	Role       dto.Role         `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []ToolCallsBlock `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
 }
 
 type JsonSchemaProperty struct {
@@ -43,9 +44,9 @@ type ChatRequest struct {
 	MaxCompletionTokens int                `json:"max_completion_tokens"`
 	TopP                float32            `json:"top_p"`
 	Stream              bool               `json:"stream"`
-	Stop                interface{}        `json:"stop"`
-	Tools               []ToolCallRequest  `json:"tools"`
-	ToolChoice          string             `json:"tool_choice"`
+	Stop                interface{}        `json:"stop,omitempty"`
+	Tools               []ToolCallRequest  `json:"tools,omitempty"`
+	ToolChoice          string             `json:"tool_choice,omitempty"`
 }
 
 type ImageBlock struct {
@@ -80,7 +81,7 @@ type ExtractTextResponse struct {
 }
 
 type ToolCallFunction struct {
-	Name      stock.Function  `json:"name"`
+	Name      string          `json:"name"`
 	Arguments json.RawMessage `json:"arguments"`
 }
 
@@ -90,6 +91,47 @@ type ToolCallsBlock struct {
 	Function ToolCallFunction `json:"function"`
 }
 
+type StreamCallbacks struct {
+	OnToken    func(token string)
+	OnToolCall func(name string)
+}
+
+func (c *StreamCallbacks) token(token string) {
+	if c.OnToken != nil {
+		c.OnToken(token)
+	}
+}
+
+func (c *StreamCallbacks) toolCall(name string) {
+	if c.OnToolCall != nil {
+		c.OnToolCall(name)
+	}
+}
+
+type ToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type ChatDelta struct {
+	Content   string          `json:"content"`
+	ToolCalls []ToolCallDelta `json:"tool_calls"`
+}
+
+type ChatStreamChoice struct {
+	Delta        ChatDelta `json:"delta"`
+	FinishReason *string   `json:"finish_reason"`
+}
+
+type ChatStreamChunk struct {
+	Choices []ChatStreamChoice `json:"choices"`
+}
+
 type ChatChoice struct {
 	Message ChatMessageBlock `json:"message"`
 }
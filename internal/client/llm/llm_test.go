@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"patient-chatbot/internal/config"
+	"patient-chatbot/internal/dto"
+	"patient-chatbot/internal/tool"
+	"strings"
+	"testing"
+)
+
+type lookupArgs struct {
+	TadawulID string `json:"tadawulId"`
+}
+
+func newTestClient(t *testing.T, provider Provider, maxToolSteps int) *LLMClient {
+	t.Helper()
+	prompts, err := LoadPrompts("../../locales/prompts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{LLMModel: "test", LLMMaxToolSteps: maxToolSteps, LLMContextBudget: 8000}
+	return NewLLMClient(cfg, provider, prompts)
+}
+
+func newTestTools() *tool.Registry {
+	tools := tool.NewRegistry()
+	tools.Register(tool.New("Lookup", "Look a company up", func(ctx context.Context, args lookupArgs) (*tool.Result, error) {
+		return &tool.Result{Chart: dto.ChartsSearchCompanyStocks, Data: map[string]string{"tadawulId": args.TadawulID, "price": "24.03"}}, nil
+	}))
+	return tools
+}
+
+func toolCall(id string, name string, args string) ToolCallsBlock {
+	return ToolCallsBlock{ID: id, Type: "function", Function: ToolCallFunction{Name: name, Arguments: json.RawMessage(args)}}
+}
+
+func TestChatRunsScriptedToolCalls(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       []FakeResponse
+		maxToolSteps int
+		wantAnswer   string
+		wantResults  int
+		wantRequests int
+		wantToolText string
+		wantChoice   string
+	}{
+		{
+			name: "tool result is fed back before the answer",
+			script: []FakeResponse{
+				{ToolCalls: []ToolCallsBlock{toolCall("c1", "Lookup", `{"tadawulId":"2222"}`)}},
+				{Content: "Aramco trades at 24.03"},
+			},
+			maxToolSteps: 5,
+			wantAnswer:   "Aramco trades at 24.03",
+			wantResults:  1,
+			wantRequests: 2,
+			wantToolText: `{"price":"24.03","tadawulId":"2222"}`,
+			wantChoice:   "auto",
+		},
+		{
+			name: "unknown tool is reported to the model",
+			script: []FakeResponse{
+				{ToolCalls: []ToolCallsBlock{toolCall("c1", "Missing", `{}`)}},
+				{Content: "sorry"},
+			},
+			maxToolSteps: 5,
+			wantAnswer:   "sorry",
+			wantResults:  0,
+			wantRequests: 2,
+			wantToolText: `error: tool :: Dispatch :: unknown tool "Missing"`,
+			wantChoice:   "auto",
+		},
+		{
+			name: "step limit forces an answer",
+			script: []FakeResponse{
+				{Content: "still looking", ToolCalls: []ToolCallsBlock{toolCall("c1", "Lookup", `{"tadawulId":"2222"}`)}},
+			},
+			maxToolSteps: 1,
+			wantAnswer:   "still looking",
+			wantResults:  1,
+			wantRequests: 2,
+			wantToolText: `{"price":"24.03","tadawulId":"2222"}`,
+			wantChoice:   "none",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFakeProvider(tt.script...)
+			client := newTestClient(t, provider, tt.maxToolSteps)
+
+			messages := []dto.Message{{Role: dto.UserRole, Content: "How is Aramco doing?"}}
+			answer, results, err := client.Chat(context.Background(), LangEnglish, messages, nil, newTestTools())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if answer != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", answer, tt.wantAnswer)
+			}
+			if len(results) != tt.wantResults {
+				t.Errorf("got %d tool results, want %d", len(results), tt.wantResults)
+			}
+
+			requests := provider.Requests()
+			if len(requests) != tt.wantRequests {
+				t.Fatalf("got %d provider requests, want %d", len(requests), tt.wantRequests)
+			}
+			last := requests[len(requests)-1]
+			if last.ToolChoice != tt.wantChoice {
+				t.Errorf("last tool_choice = %q, want %q", last.ToolChoice, tt.wantChoice)
+			}
+			toolMessage := last.Messages[len(last.Messages)-1]
+			if toolMessage.Role != dto.ToolRole || toolMessage.ToolCallID != "c1" || toolMessage.Content != tt.wantToolText {
+				t.Errorf("last message = %+v, want the c1 tool result %s", toolMessage, tt.wantToolText)
+			}
+		})
+	}
+}
+
+func TestChatRendersChartContext(t *testing.T) {
+	provider := NewFakeProvider(FakeResponse{Content: "ok"})
+	client := newTestClient(t, provider, 5)
+
+	tools := newTestTools()
+	tools.RegisterCharts(tool.NewChart(dto.ChartsSearchCompanyStocks, "tadawulId", func(data map[string]string) string {
+		return "The user is looking at " + data["tadawulId"]
+	}))
+	answerContext := &dto.Context{Stocks: map[string]string{"tadawulId": "1120"}}
+
+	messages := []dto.Message{{Role: dto.UserRole, Content: "And this one?"}}
+	if _, _, err := client.Chat(context.Background(), LangEnglish, messages, answerContext, tools); err != nil {
+		t.Fatal(err)
+	}
+	system := provider.Requests()[0].Messages[0]
+	if system.Role != dto.SystemRole || !strings.Contains(system.Content, "Context:\nThe user is looking at 1120") {
+		t.Errorf("system prompt does not carry the chart context:\n%s", system.Content)
+	}
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	LangArabic  = "ar"
+	LangEnglish = "en"
+)
+
+var riyadh = time.FixedZone("Asia/Riyadh", 3*60*60)
+
+// Prompts holds the chat system prompt template for each supported language.
+type Prompts struct {
+	chatSystem map[string]*template.Template
+}
+
+type promptData struct {
+	Today string
+}
+
+// LoadPrompts parses chat_system.<lang>.txt for every supported language in dir.
+func LoadPrompts(dir string) (*Prompts, error) {
+	prompts := &Prompts{chatSystem: map[string]*template.Template{}}
+	for _, lang := range []string{LangArabic, LangEnglish} {
+		path := filepath.Join(dir, fmt.Sprintf("chat_system.%s.txt", lang))
+		tmpl, err := template.ParseFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("llm client :: LoadPrompts :: error parsing %s: %w", path, err)
+		}
+		prompts.chatSystem[lang] = tmpl
+	}
+	return prompts, nil
+}
+
+// ChatSystem renders the system prompt for lang, a locale such as "ar" or "en-US".
+func (p *Prompts) ChatSystem(lang string) (string, error) {
+	tmpl := p.chatSystem[PromptLang(lang)]
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, promptData{
+		Today: time.Now().In(riyadh).Format("Monday 2006-01-02"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("llm client :: ChatSystem :: error rendering prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func PromptLang(lang string) string {
+	if strings.HasPrefix(strings.ToLower(lang), LangEnglish) {
+		return LangEnglish
+	}
+	return LangArabic
+}
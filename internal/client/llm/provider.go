@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"patient-chatbot/internal/config"
+)
+
+const (
+	ProviderGroq   = "groq"
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+
+	groqBaseURL = "https://api.groq.com/openai/v1"
+)
+
+// Provider is a chat completion backend speaking the OpenAI message format.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error)
+	Stream(ctx context.Context, request ChatRequest, onToken func(string)) (string, []ToolCallsBlock, error)
+}
+
+// NewProvider builds the provider selected in cfg, wrapped in the configured
+// retry and model fallback policy.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	var provider Provider
+	switch cfg.LLMProvider {
+	case ProviderGroq, "":
+		provider = NewGroqProvider(cfg.GroqAPIKey)
+	case ProviderOpenAI:
+		provider = NewOpenAIProvider(ProviderOpenAI, cfg.LLMBaseURL, cfg.LLMAPIKey)
+	case ProviderFake:
+		if cfg.LLMFakeScript == "" {
+			return NewFakeProvider(), nil
+		}
+		return LoadFakeProvider(cfg.LLMFakeScript)
+	default:
+		return nil, fmt.Errorf("llm client :: NewProvider :: unknown provider %q", cfg.LLMProvider)
+	}
+
+	return NewRetryingProvider(provider, RetryPolicy{
+		MaxRetries:     cfg.LLMMaxRetries,
+		BaseDelay:      cfg.LLMRetryBaseDelay,
+		MaxDelay:       cfg.LLMRetryMaxDelay,
+		AttemptTimeout: cfg.LLMTimeout,
+	}, cfg.LLMFallbackModels), nil
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type FakeResponse struct {
+	Content   string           `json:"content"`
+	ToolCalls []ToolCallsBlock `json:"tool_calls"`
+}
+
+// FakeProvider replays scripted responses in order, repeating the last one
+// once the script runs out. Without a script it echoes the last user message.
+type FakeProvider struct {
+	mu        sync.Mutex
+	responses []FakeResponse
+	requests  []ChatRequest
+}
+
+func NewFakeProvider(responses ...FakeResponse) *FakeProvider {
+	return &FakeProvider{responses: responses}
+}
+
+// LoadFakeProvider reads a JSON array of FakeResponse from path.
+func LoadFakeProvider(path string) (*FakeProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("llm client :: LoadFakeProvider :: error reading script: %w", err)
+	}
+	var responses []FakeResponse
+	if err := json.Unmarshal(raw, &responses); err != nil {
+		return nil, fmt.Errorf("llm client :: LoadFakeProvider :: error unmarshalling script: %w", err)
+	}
+	return NewFakeProvider(responses...), nil
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+// Requests returns every request the provider has received so far.
+func (p *FakeProvider) Requests() []ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ChatRequest(nil), p.requests...)
+}
+
+func (p *FakeProvider) Complete(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error) {
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	step := len(p.requests)
+	p.requests = append(p.requests, request)
+
+	if len(p.responses) == 0 {
+		return echo(request), nil, nil
+	}
+	response := p.responses[min(step, len(p.responses)-1)]
+	if request.ToolChoice == "none" {
+		return response.Content, nil, nil
+	}
+	return response.Content, response.ToolCalls, nil
+}
+
+func (p *FakeProvider) Stream(ctx context.Context, request ChatRequest, onToken func(string)) (string, []ToolCallsBlock, error) {
+	answer, toolCalls, err := p.Complete(ctx, request)
+	if err != nil {
+		return "", nil, err
+	}
+	if onToken != nil {
+		for _, word := range strings.SplitAfter(answer, " ") {
+			onToken(word)
+		}
+	}
+	return answer, toolCalls, nil
+}
+
+func echo(request ChatRequest) string {
+	for i := len(request.Messages) - 1; i >= 0; i-- {
+		if request.Messages[i].Role == "user" {
+			return "You said: " + request.Messages[i].Content
+		}
+	}
+	return "Hello from the fake provider."
+}
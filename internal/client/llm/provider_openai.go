@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAIProvider talks to any server exposing the OpenAI chat completions API,
+// e.g. Groq, OpenAI itself or a local llama.cpp/Ollama server.
+type OpenAIProvider struct {
+	name    string
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewOpenAIProvider(name string, baseURL string, apiKey string) *OpenAIProvider {
+	return &OpenAIProvider{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  http.DefaultClient,
+	}
+}
+
+func NewGroqProvider(apiKey string) *OpenAIProvider {
+	return NewOpenAIProvider(ProviderGroq, groqBaseURL, apiKey)
+}
+
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error) {
+	request.Stream = false
+	resp, err := p.post(ctx, request)
+	if err != nil {
+		return "", nil, fmt.Errorf("llm client :: OpenAIProvider.Complete :: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var cr ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
+		return "", nil, fmt.Errorf("llm client :: OpenAIProvider.Complete :: error decoding chat response: %w", err)
+	}
+	if len(cr.Choices) == 0 {
+		return "", nil, fmt.Errorf("llm client :: OpenAIProvider.Complete :: no choices in chat response")
+	}
+	return cr.Choices[0].Message.Content, cr.Choices[0].Message.ToolCalls, nil
+}
+
+func (p *OpenAIProvider) Stream(ctx context.Context, request ChatRequest, onToken func(string)) (string, []ToolCallsBlock, error) {
+	request.Stream = true
+	resp, err := p.post(ctx, request)
+	if err != nil {
+		return "", nil, fmt.Errorf("llm client :: OpenAIProvider.Stream :: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return readChatStream(resp.Body, onToken)
+}
+
+func (p *OpenAIProvider) post(ctx context.Context, request ChatRequest) (*http.Response, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling chat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if request.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s API: %w", p.name, classifyTransportError(err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("error calling %s API: %w", p.name, classifyResponse(resp, body))
+	}
+	return resp, nil
+}
@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type RetryPolicy struct {
+	MaxRetries     int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	AttemptTimeout time.Duration
+}
+
+// RetryingProvider retries retryable failures with jittered exponential
+// backoff, then moves on to the fallback models before giving up.
+type RetryingProvider struct {
+	provider       Provider
+	policy         RetryPolicy
+	fallbackModels []string
+}
+
+func NewRetryingProvider(provider Provider, policy RetryPolicy, fallbackModels []string) *RetryingProvider {
+	return &RetryingProvider{
+		provider:       provider,
+		policy:         policy,
+		fallbackModels: fallbackModels,
+	}
+}
+
+func (p *RetryingProvider) Name() string {
+	return p.provider.Name()
+}
+
+func (p *RetryingProvider) Complete(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error) {
+	return p.do(ctx, request, func(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error) {
+		return p.provider.Complete(ctx, request)
+	})
+}
+
+func (p *RetryingProvider) Stream(ctx context.Context, request ChatRequest, onToken func(string)) (string, []ToolCallsBlock, error) {
+	streamed := false
+	return p.do(ctx, request, func(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error) {
+		answer, toolCalls, err := p.provider.Stream(ctx, request, func(token string) {
+			streamed = true
+			if onToken != nil {
+				onToken(token)
+			}
+		})
+		if err != nil && streamed {
+			// @NOTE: Tokens already reached the client, a retry would duplicate them
+			return "", nil, &ProviderError{Reason: ReasonUnknown, Message: "stream interrupted", Err: err}
+		}
+		return answer, toolCalls, err
+	})
+}
+
+func (p *RetryingProvider) do(
+	ctx context.Context,
+	request ChatRequest,
+	call func(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error),
+) (string, []ToolCallsBlock, error) {
+	models := append([]string{request.Model}, p.fallbackModels...)
+
+	var lastErr error
+	for i, model := range models {
+		if i > 0 && model == request.Model {
+			continue
+		}
+		attemptRequest := request
+		attemptRequest.Model = model
+
+		for attempt := 0; attempt <= p.policy.MaxRetries; attempt++ {
+			answer, toolCalls, err := p.attempt(ctx, attemptRequest, call)
+			if err == nil {
+				return answer, toolCalls, nil
+			}
+			if ctx.Err() != nil {
+				return "", nil, err
+			}
+			lastErr = err
+
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) {
+				return "", nil, err
+			}
+			if !providerErr.Retryable() {
+				break
+			}
+			if attempt == p.policy.MaxRetries {
+				break
+			}
+
+			delay := p.backoff(attempt, providerErr.RetryAfter)
+			log.Warn().Msgf("llm client :: RetryingProvider :: %s on model %s, retrying in %s", providerErr.Reason, model, delay)
+			select {
+			case <-ctx.Done():
+				return "", nil, err
+			case <-time.After(delay):
+			}
+		}
+
+		reason := ReasonOf(lastErr)
+		if reason != ReasonRateLimited && reason != ReasonUnavailable && reason != ReasonTimeout && reason != ReasonContextLengthExceeded {
+			return "", nil, lastErr
+		}
+		if i < len(models)-1 {
+			log.Warn().Msgf("llm client :: RetryingProvider :: giving up on model %s (%s), falling back", model, reason)
+		}
+	}
+	return "", nil, lastErr
+}
+
+func (p *RetryingProvider) attempt(
+	ctx context.Context,
+	request ChatRequest,
+	call func(ctx context.Context, request ChatRequest) (string, []ToolCallsBlock, error),
+) (string, []ToolCallsBlock, error) {
+	if p.policy.AttemptTimeout <= 0 {
+		return call(ctx, request)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, p.policy.AttemptTimeout)
+	defer cancel()
+
+	answer, toolCalls, err := call(attemptCtx, request)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return "", nil, &ProviderError{Reason: ReasonTimeout, Message: "attempt timed out", Err: err}
+	}
+	return answer, toolCalls, err
+}
+
+// backoff returns a full-jitter exponential delay, never shorter than what
+// the provider asked for via Retry-After.
+func (p *RetryingProvider) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	ceiling := p.policy.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > p.policy.MaxDelay {
+		ceiling = p.policy.MaxDelay
+	}
+	delay := ceiling/2 + time.Duration(rand.Int64N(int64(ceiling/2)+1))
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// readChatStream parses OpenAI-style SSE delta chunks, forwarding content
+// tokens to onToken and stitching the incremental tool_call fragments back
+// into complete tool calls.
+func readChatStream(body io.Reader, onToken func(string)) (string, []ToolCallsBlock, error) {
+	type partialToolCall struct {
+		id        string
+		name      string
+		arguments strings.Builder
+	}
+
+	var answer strings.Builder
+	partials := map[int]*partialToolCall{}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk ChatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", nil, fmt.Errorf("llm client :: readChatStream :: error decoding chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			answer.WriteString(delta.Content)
+			if onToken != nil {
+				onToken(delta.Content)
+			}
+		}
+		for _, fragment := range delta.ToolCalls {
+			partial, ok := partials[fragment.Index]
+			if !ok {
+				partial = &partialToolCall{}
+				partials[fragment.Index] = partial
+			}
+			if fragment.ID != "" {
+				partial.id = fragment.ID
+			}
+			partial.name += fragment.Function.Name
+			partial.arguments.WriteString(fragment.Function.Arguments)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, fmt.Errorf("llm client :: readChatStream :: error reading stream: %w", err)
+	}
+
+	indexes := make([]int, 0, len(partials))
+	for index := range partials {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	toolCalls := make([]ToolCallsBlock, 0, len(indexes))
+	for _, index := range indexes {
+		partial := partials[index]
+		// @NOTE: Keep the string-wrapped shape non-streaming responses use
+		arguments, err := json.Marshal(partial.arguments.String())
+		if err != nil {
+			return "", nil, fmt.Errorf("llm client :: readChatStream :: error encoding tool arguments: %w", err)
+		}
+		toolCalls = append(toolCalls, ToolCallsBlock{
+			ID:   partial.id,
+			Type: "function",
+			Function: ToolCallFunction{
+				Name:      partial.name,
+				Arguments: arguments,
+			},
+		})
+	}
+
+	return answer.String(), toolCalls, nil
+}
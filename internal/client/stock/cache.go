@@ -0,0 +1,171 @@
+package stock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	cacheStoreTimeout = 2 * time.Second
+	// failureBackoff is how long we keep serving stale entries without
+	// waiting on upstream after it failed.
+	failureBackoff = 30 * time.Second
+)
+
+type CacheEntry struct {
+	Value      []byte
+	ExpiresAt  time.Time
+	StaleUntil time.Time
+}
+
+type CacheStore interface {
+	Get(ctx context.Context, key string) (CacheEntry, bool, error)
+	Set(ctx context.Context, key string, entry CacheEntry) error
+}
+
+type CacheTTLs struct {
+	Prices      time.Duration
+	Movers      time.Duration
+	Search      time.Duration
+	MarketWatch time.Duration
+	Stale       time.Duration
+}
+
+// CachedClient decorates a MarketDataProvider with per-method TTLs, coalescing of
+// concurrent identical calls and stale-while-revalidate when upstream fails.
+type CachedClient struct {
+	client MarketDataProvider
+	store  CacheStore
+	ttls   CacheTTLs
+	group  singleflight.Group
+
+	mu          sync.Mutex
+	lastFailure time.Time
+}
+
+func NewCachedClient(client MarketDataProvider, store CacheStore, ttls CacheTTLs) *CachedClient {
+	return &CachedClient{client: client, store: store, ttls: ttls}
+}
+
+func (c *CachedClient) GetDetailedCompanyStockPrices(companyID string, query PriceQuery) ([]GetDetailedCompanyStockPricesResponse, error) {
+	return cached(c, "GetDetailedCompanyStockPrices", companyID+"|"+query.cacheKey(), c.ttls.Prices, func() ([]GetDetailedCompanyStockPricesResponse, error) {
+		return c.client.GetDetailedCompanyStockPrices(companyID, query)
+	})
+}
+
+func (c *CachedClient) GetTodayTopFiveGainersOrLosers(topGainersOrLosers TopGainersOrLosers) ([]TopFiveGainersOrLosersResponse, error) {
+	return cached(c, "GetTodayTopFiveGainersOrLosers", string(topGainersOrLosers), c.ttls.Movers, func() ([]TopFiveGainersOrLosersResponse, error) {
+		return c.client.GetTodayTopFiveGainersOrLosers(topGainersOrLosers)
+	})
+}
+
+func (c *CachedClient) SearchCompanyStocks(companyName string) (*SearchCompanyStocksResponse, error) {
+	key := strings.ToLower(strings.TrimSpace(companyName))
+	return cached(c, "SearchCompanyStocks", key, c.ttls.Search, func() (*SearchCompanyStocksResponse, error) {
+		return c.client.SearchCompanyStocks(companyName)
+	})
+}
+
+func (c *CachedClient) GetMarketWatch() ([]MarketWatchResponse, error) {
+	return cached(c, "GetMarketWatch", "all", c.ttls.MarketWatch, func() ([]MarketWatchResponse, error) {
+		return c.client.GetMarketWatch()
+	})
+}
+
+func cached[T any](c *CachedClient, method string, arg string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	key := method + ":" + arg
+	logger := log.With().Str("method", method).Str("key", arg).Logger()
+
+	entry, found := c.get(key)
+	var cachedValue T
+	if found && json.Unmarshal(entry.Value, &cachedValue) != nil {
+		found = false
+	}
+
+	if found && time.Now().Before(entry.ExpiresAt) {
+		logger.Info().Msg("stock cache :: hit")
+		return cachedValue, nil
+	}
+	if found && c.failingRecently() {
+		logger.Warn().Msg("stock cache :: stale hit while upstream is failing, revalidating in background")
+		go func() {
+			_, _, _ = c.group.Do(key, func() (interface{}, error) {
+				return fetchAndStore(c, key, ttl, fetch)
+			})
+		}()
+		return cachedValue, nil
+	}
+
+	logger.Info().Msg("stock cache :: miss")
+	value, err, shared := c.group.Do(key, func() (interface{}, error) {
+		return fetchAndStore(c, key, ttl, fetch)
+	})
+	if shared {
+		logger.Info().Msg("stock cache :: coalesced with in-flight request")
+	}
+	if err != nil {
+		if found {
+			logger.Warn().Msg("stock cache :: serving stale entry after upstream error: " + err.Error())
+			return cachedValue, nil
+		}
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
+
+func fetchAndStore[T any](c *CachedClient, key string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	value, err := fetch()
+	if err != nil {
+		c.mu.Lock()
+		c.lastFailure = time.Now()
+		c.mu.Unlock()
+		return value, err
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return value, fmt.Errorf("stock client :: CachedClient :: error marshalling %s: %w", key, err)
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), cacheStoreTimeout)
+	defer cancel()
+	err = c.store.Set(ctx, key, CacheEntry{
+		Value:      raw,
+		ExpiresAt:  now.Add(ttl),
+		StaleUntil: now.Add(ttl + c.ttls.Stale),
+	})
+	if err != nil {
+		log.Error().Msg("stock client :: CachedClient :: error storing " + key + ": " + err.Error())
+	}
+	return value, nil
+}
+
+func (c *CachedClient) get(key string) (CacheEntry, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheStoreTimeout)
+	defer cancel()
+
+	entry, found, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Error().Msg("stock client :: CachedClient :: error reading " + key + ": " + err.Error())
+		return CacheEntry{}, false
+	}
+	if !found || time.Now().After(entry.StaleUntil) {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *CachedClient) failingRecently() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.lastFailure) < failureBackoff
+}
@@ -0,0 +1,59 @@
+package stock
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// MemoryCacheStore is a fixed size LRU cache.
+type MemoryCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+func NewMemoryCacheStore(capacity int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		capacity: capacity,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+func (s *MemoryCacheStore) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+	s.order.MoveToFront(element)
+	return element.Value.(*memoryCacheItem).entry, true, nil
+}
+
+func (s *MemoryCacheStore) Set(ctx context.Context, key string, entry CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		element.Value.(*memoryCacheItem).entry = entry
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
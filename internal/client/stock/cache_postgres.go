@@ -0,0 +1,47 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresCacheStore shares cached responses between instances and keeps
+// them across restarts.
+type PostgresCacheStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresCacheStore(pool *pgxpool.Pool) *PostgresCacheStore {
+	return &PostgresCacheStore{pool: pool}
+}
+
+func (s *PostgresCacheStore) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	var entry CacheEntry
+	err := s.pool.QueryRow(ctx, `
+		SELECT value, expires_at, stale_until FROM stock_cache WHERE key = $1`,
+		key,
+	).Scan(&entry.Value, &entry.ExpiresAt, &entry.StaleUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return CacheEntry{}, false, fmt.Errorf("stock client :: PostgresCacheStore.Get :: %w", err)
+	}
+	return entry, true, nil
+}
+
+func (s *PostgresCacheStore) Set(ctx context.Context, key string, entry CacheEntry) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO stock_cache (key, value, expires_at, stale_until) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at, stale_until = EXCLUDED.stale_until`,
+		key, entry.Value, entry.ExpiresAt, entry.StaleUntil,
+	)
+	if err != nil {
+		return fmt.Errorf("stock client :: PostgresCacheStore.Set :: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package stock
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Period string
+
+const (
+	Period1D  Period = "1D"
+	Period1W  Period = "1W"
+	Period1M  Period = "1M"
+	Period3M  Period = "3M"
+	Period6M  Period = "6M"
+	PeriodYTD Period = "YTD"
+	Period1Y  Period = "1Y"
+	Period5Y  Period = "5Y"
+
+	DefaultPeriod = Period1M
+	dateLayout    = "2006-01-02"
+)
+
+// Periods lists the supported periods from shortest to longest.
+var Periods = []Period{Period1D, Period1W, Period1M, Period3M, Period6M, PeriodYTD, Period1Y, Period5Y}
+
+var ErrInvalidPriceQuery = errors.New("invalid price query")
+
+var riyadh = time.FixedZone("Asia/Riyadh", 3*60*60)
+
+func ParsePeriod(value string) (Period, error) {
+	if value == "" {
+		return DefaultPeriod, nil
+	}
+	period := Period(strings.ToUpper(strings.TrimSpace(value)))
+	for _, p := range Periods {
+		if p == period {
+			return period, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown period %q", ErrInvalidPriceQuery, value)
+}
+
+// Start returns the first instant covered by the period when asked at now.
+func (p Period) Start(now time.Time) time.Time {
+	now = now.In(riyadh)
+	switch p {
+	case Period1D:
+		return now.AddDate(0, 0, -1)
+	case Period1W:
+		return now.AddDate(0, 0, -7)
+	case Period3M:
+		return now.AddDate(0, -3, 0)
+	case Period6M:
+		return now.AddDate(0, -6, 0)
+	case PeriodYTD:
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, riyadh)
+	case Period1Y:
+		return now.AddDate(-1, 0, 0)
+	case Period5Y:
+		return now.AddDate(-5, 0, 0)
+	}
+	return now.AddDate(0, -1, 0)
+}
+
+// PriceQuery selects a price history window. From and To are optional
+// YYYY-MM-DD dates that narrow the period down.
+type PriceQuery struct {
+	Period Period
+	From   string
+	To     string
+}
+
+func NewPriceQuery(period string, from string, to string) (PriceQuery, error) {
+	p, err := ParsePeriod(period)
+	if err != nil {
+		return PriceQuery{}, err
+	}
+	query := PriceQuery{Period: p, From: strings.TrimSpace(from), To: strings.TrimSpace(to)}
+
+	fromDate, toDate, err := query.dates()
+	if err != nil {
+		return PriceQuery{}, err
+	}
+	if !fromDate.IsZero() && !toDate.IsZero() && toDate.Before(fromDate) {
+		return PriceQuery{}, fmt.Errorf("%w: to %s is before from %s", ErrInvalidPriceQuery, query.To, query.From)
+	}
+	return query, nil
+}
+
+// UpstreamPeriod is the shortest period that still reaches back to From.
+func (q PriceQuery) UpstreamPeriod(now time.Time) Period {
+	period := q.Period
+	if period == "" {
+		period = DefaultPeriod
+	}
+
+	fromDate, _, err := q.dates()
+	if err != nil || fromDate.IsZero() || !fromDate.Before(period.Start(now)) {
+		return period
+	}
+	for _, p := range Periods {
+		if !fromDate.Before(p.Start(now)) {
+			return p
+		}
+	}
+	return Period5Y
+}
+
+// Filter keeps the bars falling between From and To, both inclusive.
+func (q PriceQuery) Filter(prices []GetDetailedCompanyStockPricesResponse) []GetDetailedCompanyStockPricesResponse {
+	fromDate, toDate, err := q.dates()
+	if err != nil || (fromDate.IsZero() && toDate.IsZero()) {
+		return prices
+	}
+
+	filtered := make([]GetDetailedCompanyStockPricesResponse, 0, len(prices))
+	for _, price := range prices {
+		date, err := ParseBarDate(price.Date)
+		if err != nil {
+			continue
+		}
+		if !fromDate.IsZero() && date.Before(fromDate) {
+			continue
+		}
+		if !toDate.IsZero() && !date.Before(toDate.AddDate(0, 0, 1)) {
+			continue
+		}
+		filtered = append(filtered, price)
+	}
+	return filtered
+}
+
+func (q PriceQuery) dates() (time.Time, time.Time, error) {
+	var fromDate, toDate time.Time
+	var err error
+	if q.From != "" {
+		if fromDate, err = time.ParseInLocation(dateLayout, q.From, riyadh); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidPriceQuery)
+		}
+	}
+	if q.To != "" {
+		if toDate, err = time.ParseInLocation(dateLayout, q.To, riyadh); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidPriceQuery)
+		}
+	}
+	return fromDate, toDate, nil
+}
+
+func (q PriceQuery) cacheKey() string {
+	return string(q.Period) + "|" + q.From + "|" + q.To
+}
+
+var barDateLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	dateLayout,
+}
+
+// ParseBarDate parses the date formats the upstream price feeds use, in
+// Riyadh time unless the value carries its own offset.
+func ParseBarDate(value string) (time.Time, error) {
+	for _, layout := range barDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, riyadh); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("stock client :: ParseBarDate :: unrecognized date %q", value)
+}
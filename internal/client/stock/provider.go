@@ -0,0 +1,57 @@
+package stock
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"patient-chatbot/internal/config"
+)
+
+const (
+	ProviderRapidAPI = "rapidapi"
+	ProviderFixture  = "fixture"
+	ProviderRandom   = "random"
+)
+
+// MarketDataProvider is the market data surface the service layer depends on.
+type MarketDataProvider interface {
+	SearchCompanyStocks(companyName string) (*SearchCompanyStocksResponse, error)
+	GetDetailedCompanyStockPrices(companyID string, query PriceQuery) ([]GetDetailedCompanyStockPricesResponse, error)
+	GetTodayTopFiveGainersOrLosers(topGainersOrLosers TopGainersOrLosers) ([]TopFiveGainersOrLosersResponse, error)
+	GetMarketWatch() ([]MarketWatchResponse, error)
+}
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// DefaultFixtures returns the fixture files shipped with the binary.
+func DefaultFixtures() fs.FS {
+	sub, err := fs.Sub(fixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// NewMarketDataProvider builds the provider selected in cfg.
+func NewMarketDataProvider(cfg *config.Config) (MarketDataProvider, error) {
+	switch cfg.MarketDataProvider {
+	case ProviderRapidAPI, "":
+		return NewStockClient(cfg), nil
+	case ProviderFixture:
+		fsys := DefaultFixtures()
+		if cfg.MarketDataFixtures != "" {
+			fsys = os.DirFS(cfg.MarketDataFixtures)
+		}
+		return NewFixtureProvider(fsys)
+	case ProviderRandom:
+		fixture, err := NewFixtureProvider(DefaultFixtures())
+		if err != nil {
+			return nil, err
+		}
+		companies, _ := fixture.GetMarketWatch()
+		return NewRandomProvider(companies), nil
+	}
+	return nil, fmt.Errorf("stock client :: NewMarketDataProvider :: unknown provider %q", cfg.MarketDataProvider)
+}
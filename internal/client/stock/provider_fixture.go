@@ -0,0 +1,118 @@
+package stock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"sort"
+	"strings"
+)
+
+// FixtureProvider serves deterministic market data from files:
+//
+//	market_watch.json      market watch entries, also used for search and movers
+//	prices/<tadawulId>.json price history for one company
+//	prices/default.json     price history for everyone else, rescaled to the
+//	                        company's market watch price
+type FixtureProvider struct {
+	fsys        fs.FS
+	marketWatch []MarketWatchResponse
+}
+
+func NewFixtureProvider(fsys fs.FS) (*FixtureProvider, error) {
+	var marketWatch []MarketWatchResponse
+	if err := readFixture(fsys, "market_watch.json", &marketWatch); err != nil {
+		return nil, fmt.Errorf("stock client :: NewFixtureProvider :: %w", err)
+	}
+	return &FixtureProvider{fsys: fsys, marketWatch: marketWatch}, nil
+}
+
+func (p *FixtureProvider) SearchCompanyStocks(companyName string) (*SearchCompanyStocksResponse, error) {
+	query := strings.ToLower(strings.TrimSpace(companyName))
+	if query == "" {
+		return nil, nil
+	}
+	for _, m := range p.marketWatch {
+		for _, name := range []string{m.TadawulID, m.AcronymName, m.AcronymNameAr, m.CompanyName, m.CompanyNameAr} {
+			if strings.Contains(strings.ToLower(name), query) {
+				response := m.SearchResponse()
+				return &response, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (p *FixtureProvider) GetDetailedCompanyStockPrices(companyID string, query PriceQuery) ([]GetDetailedCompanyStockPricesResponse, error) {
+	var prices []GetDetailedCompanyStockPricesResponse
+	err := readFixture(p.fsys, "prices/"+companyID+".json", &prices)
+	if err == nil {
+		return query.Filter(prices), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("stock client :: FixtureProvider.GetDetailedCompanyStockPrices :: %w", err)
+	}
+
+	if err := readFixture(p.fsys, "prices/default.json", &prices); err != nil {
+		return nil, fmt.Errorf("stock client :: FixtureProvider.GetDetailedCompanyStockPrices :: %w", err)
+	}
+	if len(prices) == 0 {
+		return prices, nil
+	}
+	for _, m := range p.marketWatch {
+		if m.TadawulID == companyID && m.Price > 0 {
+			rescale(prices, m.Price/prices[len(prices)-1].Close)
+			break
+		}
+	}
+	return query.Filter(prices), nil
+}
+
+func (p *FixtureProvider) GetTodayTopFiveGainersOrLosers(topGainersOrLosers TopGainersOrLosers) ([]TopFiveGainersOrLosersResponse, error) {
+	return topMovers(p.marketWatch, topGainersOrLosers), nil
+}
+
+func (p *FixtureProvider) GetMarketWatch() ([]MarketWatchResponse, error) {
+	return append([]MarketWatchResponse(nil), p.marketWatch...), nil
+}
+
+func readFixture(fsys fs.FS, name string, v interface{}) error {
+	raw, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("error unmarshalling %s: %w", name, err)
+	}
+	return nil
+}
+
+func rescale(prices []GetDetailedCompanyStockPricesResponse, factor float64) {
+	round := func(v float64) float64 { return math.Round(v*factor*100) / 100 }
+	for i := range prices {
+		prices[i].Open = round(prices[i].Open)
+		prices[i].High = round(prices[i].High)
+		prices[i].Low = round(prices[i].Low)
+		prices[i].Close = round(prices[i].Close)
+	}
+}
+
+// topMovers ranks market watch entries by change percentage like the
+// upstream top-gainers and top-losers endpoints.
+func topMovers(marketWatch []MarketWatchResponse, topGainersOrLosers TopGainersOrLosers) []TopFiveGainersOrLosersResponse {
+	sorted := append([]MarketWatchResponse(nil), marketWatch...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if topGainersOrLosers == TopLosers {
+			return sorted[i].ChangePercentage < sorted[j].ChangePercentage
+		}
+		return sorted[i].ChangePercentage > sorted[j].ChangePercentage
+	})
+
+	movers := make([]TopFiveGainersOrLosersResponse, 0, 5)
+	for _, m := range sorted[:min(5, len(sorted))] {
+		movers = append(movers, m.MoverResponse())
+	}
+	return movers
+}
@@ -0,0 +1,95 @@
+package stock
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// RandomProvider generates fresh random market data on every call, for demos
+// and load testing without a RapidAPI quota.
+type RandomProvider struct {
+	companies []MarketWatchResponse
+}
+
+func NewRandomProvider(companies []MarketWatchResponse) *RandomProvider {
+	return &RandomProvider{companies: companies}
+}
+
+func (p *RandomProvider) SearchCompanyStocks(companyName string) (*SearchCompanyStocksResponse, error) {
+	return &SearchCompanyStocksResponse{
+		TadawulID:     fmt.Sprintf("%04d", 100+rand.IntN(9900)),
+		CompanyID:     1,
+		CompanyName:   strings.ToUpper(companyName),
+		Sector:        "Technology",
+		AcrynomNameAr: companyName,
+		ArgaamID:      fmt.Sprintf("%04d", 100+rand.IntN(10100)),
+		CompanyNameAr: "",
+		SectorAr:      "التكنولوجيا",
+		AcrynomName:   companyName,
+		Price:         math.Round(rand.Float64()*1000) / 100,
+		Change:        math.Round(rand.Float64()*100) / 100,
+		ChangePercent: math.Round(rand.Float64()*100) / 100,
+	}, nil
+}
+
+func (p *RandomProvider) GetDetailedCompanyStockPrices(companyID string, query PriceQuery) ([]GetDetailedCompanyStockPricesResponse, error) {
+	base := 100.0
+
+	now := time.Now()
+	days := int(now.Sub(query.UpstreamPeriod(now).Start(now)).Hours()/24) + 1
+	mockData := make([]GetDetailedCompanyStockPricesResponse, days)
+
+	for i := 0; i < days; i++ {
+		prevClose := base
+		if i > 0 {
+			prevClose = mockData[i-1].Close
+		}
+
+		delta := rand.NormFloat64() * 2.0
+		open := prevClose + rand.NormFloat64()*0.5
+		close := prevClose + delta
+		high := math.Max(open, close) + rand.Float64()*1.0
+		low := math.Min(open, close) - rand.Float64()*1.0
+
+		mockData[i] = GetDetailedCompanyStockPricesResponse{
+			Date:   time.Now().AddDate(0, 0, -i).Format("2006-01-02"),
+			Open:   open,
+			Close:  close,
+			High:   high,
+			Low:    low,
+			Volume: int(1000 + rand.Float64()*5000),
+		}
+	}
+	return query.Filter(mockData), nil
+}
+
+func (p *RandomProvider) GetTodayTopFiveGainersOrLosers(topGainersOrLosers TopGainersOrLosers) ([]TopFiveGainersOrLosersResponse, error) {
+	marketWatch, err := p.GetMarketWatch()
+	if err != nil {
+		return nil, err
+	}
+	return topMovers(marketWatch, topGainersOrLosers), nil
+}
+
+func (p *RandomProvider) GetMarketWatch() ([]MarketWatchResponse, error) {
+	marketWatch := make([]MarketWatchResponse, len(p.companies))
+	for i, m := range p.companies {
+		previous := m.Price - m.Change
+		if previous <= 0 {
+			previous = 10 + rand.Float64()*90
+		}
+		m.ChangePercentage = math.Round(rand.NormFloat64()*200) / 100
+		m.Price = math.Round(previous*(1+m.ChangePercentage/100)*100) / 100
+		m.Change = math.Round((m.Price-previous)*100) / 100
+		m.OpenPrice = previous
+		m.HighPrice = math.Max(m.Price, previous) * (1 + rand.Float64()/100)
+		m.LowPrice = math.Min(m.Price, previous) * (1 - rand.Float64()/100)
+		m.Volume = 10000 + rand.IntN(2000000)
+		m.NumberOfTrades = 100 + rand.IntN(5000)
+		marketWatch[i] = m
+	}
+	return marketWatch, nil
+}
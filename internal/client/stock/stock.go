@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"patient-chatbot/internal/config"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -18,6 +19,8 @@ const (
 	TopLosers  TopGainersOrLosers = "top-losers"
 
 	rapidAPIURL = "https://saudi-exchange-stocks-tadawul.p.rapidapi.com/v1"
+
+	marketWatchLimit = 500
 )
 
 type StockClient struct {
@@ -28,21 +31,33 @@ func NewStockClient(cfg *config.Config) *StockClient {
 	return &StockClient{cfg: cfg}
 }
 
-// func (c *StockClient) GetDailyInformationForAllCompanies() (string, error) {
-// 	url := fmt.Sprintf("%s/stock/market-watch?limit=100", rapidAPIURL)
+func (c *StockClient) GetMarketWatch() ([]MarketWatchResponse, error) {
+	url := fmt.Sprintf("%s/stock/market-watch?limit=%d", rapidAPIURL, marketWatchLimit)
 
-// 	req, err := http.NewRequest("GET", url, nil)s
-// 	if err != nil {
-// 		return "", err
-// 	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("stock client :: GetMarketWatch :: error creating request: %w", err)
+	}
 
-// 	return c.callRapidAPI(req)
-// }
+	req.Header.Add("x-rapidapi-key", c.cfg.RapidAPIV2Key)
+	res, err := c.callRapidAPI(req)
+	if err != nil {
+		return nil, fmt.Errorf("stock client :: GetMarketWatch :: error calling rapidAPI: %w", err)
+	}
+
+	var details []MarketWatchResponse
+	err = json.Unmarshal(res.Data, &details)
+	if err != nil {
+		return nil, fmt.Errorf("stock client :: GetMarketWatch :: error unmarshalling response: %w", err)
+	}
+	return details, nil
+}
 
 func (c *StockClient) GetDetailedCompanyStockPrices(
 	companyID string,
+	query PriceQuery,
 ) ([]GetDetailedCompanyStockPricesResponse, error) {
-	url := fmt.Sprintf("%s/stock/getPrice?companyId=%s&period=1M", rapidAPIURL, companyID)
+	url := fmt.Sprintf("%s/stock/getPrice?companyId=%s&period=%s", rapidAPIURL, companyID, query.UpstreamPeriod(time.Now()))
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -60,7 +75,7 @@ func (c *StockClient) GetDetailedCompanyStockPrices(
 	if err != nil {
 		return nil, fmt.Errorf("stock client :: GetDetailedCompanyStockPrices :: error unmarshalling response: %w", err)
 	}
-	return details, nil
+	return query.Filter(details), nil
 }
 
 // func (c *StockClient) GetDetailedCompanyStockPrices(
@@ -142,6 +157,9 @@ func (c *StockClient) SearchCompanyStocks(companyName string) (*SearchCompanySto
 	if err != nil {
 		return nil, fmt.Errorf("stock client :: SearchCompanyStocks :: 	error unmarshalling response: %w", err)
 	}
+	if len(details) == 0 {
+		return nil, nil
+	}
 	return &details[0], nil
 }
 
@@ -19,7 +19,7 @@ type QueryPayload struct {
 }
 
 type SearchCompanyStocksArguments struct {
-	CompanyName string `json:"companyName"`
+	CompanyName string `json:"companyName" description:"The company name, acronym or tadawul id to search for, as the user wrote it"`
 }
 
 type SearchCompanyStocksResponse struct {
@@ -38,7 +38,11 @@ type SearchCompanyStocksResponse struct {
 }
 
 type GetDetailedCompanyStockPricesResponseArguments struct {
-	TadawulID string `json:"tadawulID"`
+	TadawulID string `json:"tadawulID" description:"The tadawul id of the company to search for"`
+	Period    string `json:"period,omitempty" enum:"1D,1W,1M,3M,6M,YTD,1Y,5Y" description:"How far back to fetch prices, 3M for a quarter, YTD for year to date. Defaults to 1M"`
+	From      string `json:"from,omitempty" description:"Optional first date to include, YYYY-MM-DD"`
+	To        string `json:"to,omitempty" description:"Optional last date to include, YYYY-MM-DD"`
+	Interval  string `json:"interval,omitempty" enum:"1D,1W,1M" description:"Optional bar size, use 1W or 1M to summarise long periods such as 1Y or 5Y"`
 }
 
 type GetDetailedCompanyStockPricesResponse struct {
@@ -50,6 +54,7 @@ type GetDetailedCompanyStockPricesResponse struct {
 	Volume int     `json:"volume"`
 	X      float64 `json:"x"`
 	Y      float64 `json:"y"`
+	Gap    bool    `json:"gap,omitempty"`
 }
 
 type TopFiveGainersOrLosersResponse struct {
@@ -64,6 +69,61 @@ type TopFiveGainersOrLosersResponse struct {
 	Price            float64 `json:"price"`
 }
 
+type MarketWatchResponse struct {
+	MarketWatchID    int     `json:"marketWatchId"`
+	CompanyID        int     `json:"companyId"`
+	TadawulID        string  `json:"tadawulId"`
+	CompanyName      string  `json:"companyName"`
+	CompanyNameAr    string  `json:"companyNameAr"`
+	AcronymName      string  `json:"acronymName"`
+	AcronymNameAr    string  `json:"acronymNameAr"`
+	Sector           string  `json:"sector"`
+	SectorAr         string  `json:"sectorAr"`
+	Price            float64 `json:"price"`
+	Change           float64 `json:"change"`
+	ChangePercentage float64 `json:"changePercentage"`
+	OpenPrice        float64 `json:"openPrice"`
+	HighPrice        float64 `json:"highPrice"`
+	LowPrice         float64 `json:"lowPrice"`
+	Highest52Price   float64 `json:"highest52Price"`
+	Lowest52Price    float64 `json:"lowest52Price"`
+	Volume           int     `json:"volume"`
+	NumberOfTrades   int     `json:"numberOfTrades"`
+	BestBidPrice     float64 `json:"bestBidPrice"`
+	BestBidAmount    int     `json:"bestBidAmount"`
+	BestAskPrice     float64 `json:"bestAskPrice"`
+	BestAskAmount    int     `json:"bestAskAmount"`
+}
+
+func (m MarketWatchResponse) SearchResponse() SearchCompanyStocksResponse {
+	return SearchCompanyStocksResponse{
+		TadawulID:     m.TadawulID,
+		CompanyID:     m.CompanyID,
+		CompanyName:   m.CompanyName,
+		Sector:        m.Sector,
+		AcrynomNameAr: m.AcronymNameAr,
+		CompanyNameAr: m.CompanyNameAr,
+		SectorAr:      m.SectorAr,
+		AcrynomName:   m.AcronymName,
+		Price:         m.Price,
+		Change:        m.Change,
+		ChangePercent: m.ChangePercentage,
+	}
+}
+
+func (m MarketWatchResponse) MoverResponse() TopFiveGainersOrLosersResponse {
+	return TopFiveGainersOrLosersResponse{
+		CompanyID:        m.CompanyID,
+		CompanyName:      m.CompanyName,
+		CompanyNameAr:    m.CompanyNameAr,
+		AcrynomNameAr:    m.AcronymNameAr,
+		Sector:           m.Sector,
+		SectorAr:         m.SectorAr,
+		PercentageGained: m.ChangePercentage,
+		Price:            m.Price,
+	}
+}
+
 type RapidAPIResponse struct {
 	Success bool            `json:"success"`
 	Data    json.RawMessage `json:"data"`
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,9 +17,135 @@ type Config struct {
 	RapidAPIV2Key string
 	RapidAPIHost  string
 	FrontendURL   string
+	DatabaseURL   string
+
+	MarketDataProvider string
+	MarketDataFixtures string
+
+	StockCache     string
+	StockCacheSize int
+
+	StockCachePricesTTL      time.Duration
+	StockCacheMoversTTL      time.Duration
+	StockCacheSearchTTL      time.Duration
+	StockCacheMarketWatchTTL time.Duration
+	StockCacheStaleTTL       time.Duration
+
+	DirectoryRefreshInterval time.Duration
+	DirectorySnapshot        string
+	DirectorySnapshotPath    string
+	AdminToken               string
+	GatewaySecret            string
+
+	AlertPollInterval       time.Duration
+	AlertWebhookURL         string
+	AlertWebhookSecret      string
+	AlertWebhookMaxAttempts int
+	AlertWebhookBackoff     time.Duration
+	AlertWebhookTimeout     time.Duration
+
+	RealtimePollInterval time.Duration
+
+	PriceHistory       string
+	PriceHistoryMaxAge time.Duration
+
+	LLMProvider     string
+	LLMBaseURL      string
+	LLMAPIKey       string
+	LLMFakeScript   string
+	LLMMaxToolSteps int
+
+	LLMFallbackModels []string
+	LLMMaxRetries     int
+	LLMRetryBaseDelay time.Duration
+	LLMRetryMaxDelay  time.Duration
+	LLMTimeout        time.Duration
+
+	LLMContextBudget  int
+	LLMContextBudgets map[string]int
 }
 
 func Load() (*Config, error) {
+	cfg := read()
+
+	missing := []string{}
+	if cfg.LLMProvider == "groq" && cfg.GroqAPIKey == "" {
+		missing = append(missing, "GROQ_API_KEY")
+	}
+	if cfg.LLMProvider == "openai" && cfg.LLMBaseURL == "" {
+		missing = append(missing, "LLM_BASE_URL")
+	}
+	if cfg.LLMModel == "" && cfg.LLMProvider != "fake" {
+		missing = append(missing, "LLM_MODEL")
+	}
+	missing = append(missing, cfg.missingMarketData()...)
+	if cfg.FrontendURL == "" {
+		missing = append(missing, "FRONTEND_URL")
+	}
+	// @NOTE: Webhooks are only ever sent signed
+	if cfg.AlertWebhookURL != "" && cfg.AlertWebhookSecret == "" {
+		missing = append(missing, "ALERT_WEBHOOK_SECRET")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required environment variables: %v", missing)
+	}
+	if invalid := cfg.outOfRange(); len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid environment variables: %v", invalid)
+	}
+	return cfg, nil
+}
+
+// LoadIngest loads the configuration of the price ingestion command, which
+// only needs the market data provider and the database.
+func LoadIngest() (*Config, error) {
+	cfg := read()
+
+	missing := cfg.missingMarketData()
+	if cfg.DatabaseURL == "" {
+		missing = append(missing, "DATABASE_URL")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required environment variables: %v", missing)
+	}
+	if invalid := cfg.outOfRange(); len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid environment variables: %v", invalid)
+	}
+	return cfg, nil
+}
+
+// outOfRange lists the numeric settings below their minimum. Intervals and
+// sizes must be positive, retries, tool steps and delays may be 0 to turn
+// them off.
+func (c *Config) outOfRange() []string {
+	var invalid []string
+	atLeast := func(key string, value int64, min int64) {
+		if value < min {
+			invalid = append(invalid, fmt.Sprintf("%s must be at least %d", key, min))
+		}
+	}
+	atLeast("STOCK_CACHE_SIZE", int64(c.StockCacheSize), 1)
+	atLeast("STOCK_CACHE_PRICES_TTL_SECONDS", int64(c.StockCachePricesTTL/time.Second), 1)
+	atLeast("STOCK_CACHE_MOVERS_TTL_SECONDS", int64(c.StockCacheMoversTTL/time.Second), 1)
+	atLeast("STOCK_CACHE_SEARCH_TTL_SECONDS", int64(c.StockCacheSearchTTL/time.Second), 1)
+	atLeast("STOCK_CACHE_MARKET_WATCH_TTL_SECONDS", int64(c.StockCacheMarketWatchTTL/time.Second), 1)
+	atLeast("STOCK_CACHE_STALE_TTL_SECONDS", int64(c.StockCacheStaleTTL/time.Second), 1)
+	atLeast("DIRECTORY_REFRESH_MINUTES", int64(c.DirectoryRefreshInterval/time.Minute), 1)
+	atLeast("ALERT_POLL_SECONDS", int64(c.AlertPollInterval/time.Second), 1)
+	atLeast("ALERT_WEBHOOK_MAX_ATTEMPTS", int64(c.AlertWebhookMaxAttempts), 1)
+	atLeast("ALERT_WEBHOOK_BACKOFF_SECONDS", int64(c.AlertWebhookBackoff/time.Second), 1)
+	atLeast("ALERT_WEBHOOK_TIMEOUT_SECONDS", int64(c.AlertWebhookTimeout/time.Second), 1)
+	atLeast("REALTIME_POLL_SECONDS", int64(c.RealtimePollInterval/time.Second), 1)
+	atLeast("PRICE_HISTORY_MAX_AGE_MINUTES", int64(c.PriceHistoryMaxAge/time.Minute), 0)
+	atLeast("LLM_MAX_TOOL_STEPS", int64(c.LLMMaxToolSteps), 0)
+	atLeast("LLM_MAX_RETRIES", int64(c.LLMMaxRetries), 0)
+	atLeast("LLM_RETRY_BASE_DELAY_MS", int64(c.LLMRetryBaseDelay/time.Millisecond), 0)
+	atLeast("LLM_RETRY_MAX_DELAY_MS", int64(c.LLMRetryMaxDelay/time.Millisecond), int64(c.LLMRetryBaseDelay/time.Millisecond))
+	atLeast("LLM_TIMEOUT_SECONDS", int64(c.LLMTimeout/time.Second), 1)
+	atLeast("LLM_CONTEXT_BUDGET", int64(c.LLMContextBudget), 1)
+	return invalid
+}
+
+func read() *Config {
 	_ = godotenv.Load()
 
 	cfg := &Config{
@@ -26,29 +155,118 @@ func Load() (*Config, error) {
 		RapidAPIV2Key: os.Getenv("RAPID_API_V2_KEY"),
 		RapidAPIHost:  os.Getenv("RAPID_API_HOST"),
 		FrontendURL:   os.Getenv("FRONTEND_URL"),
-	}
+		DatabaseURL:   os.Getenv("DATABASE_URL"),
 
-	missing := []string{}
-	if cfg.GroqAPIKey == "" {
-		missing = append(missing, "GROQ_API_KEY")
+		MarketDataProvider: getEnv("MARKET_DATA_PROVIDER", "rapidapi"),
+		MarketDataFixtures: os.Getenv("MARKET_DATA_FIXTURES"),
+
+		StockCache:     getEnv("STOCK_CACHE", "memory"),
+		StockCacheSize: getEnvInt("STOCK_CACHE_SIZE", 1000),
+
+		StockCachePricesTTL:      time.Duration(getEnvInt("STOCK_CACHE_PRICES_TTL_SECONDS", 900)) * time.Second,
+		StockCacheMoversTTL:      time.Duration(getEnvInt("STOCK_CACHE_MOVERS_TTL_SECONDS", 60)) * time.Second,
+		StockCacheSearchTTL:      time.Duration(getEnvInt("STOCK_CACHE_SEARCH_TTL_SECONDS", 86400)) * time.Second,
+		StockCacheMarketWatchTTL: time.Duration(getEnvInt("STOCK_CACHE_MARKET_WATCH_TTL_SECONDS", 60)) * time.Second,
+		StockCacheStaleTTL:       time.Duration(getEnvInt("STOCK_CACHE_STALE_TTL_SECONDS", 86400)) * time.Second,
+
+		DirectoryRefreshInterval: time.Duration(getEnvInt("DIRECTORY_REFRESH_MINUTES", 60)) * time.Minute,
+		DirectorySnapshot:        getEnv("DIRECTORY_SNAPSHOT", "file"),
+		DirectorySnapshotPath:    getEnv("DIRECTORY_SNAPSHOT_PATH", "data/company_directory.json"),
+		AdminToken:               os.Getenv("ADMIN_TOKEN"),
+		GatewaySecret:            os.Getenv("GATEWAY_SECRET"),
+
+		AlertPollInterval:       time.Duration(getEnvInt("ALERT_POLL_SECONDS", 60)) * time.Second,
+		AlertWebhookURL:         os.Getenv("ALERT_WEBHOOK_URL"),
+		AlertWebhookSecret:      os.Getenv("ALERT_WEBHOOK_SECRET"),
+		AlertWebhookMaxAttempts: getEnvInt("ALERT_WEBHOOK_MAX_ATTEMPTS", 5),
+		AlertWebhookBackoff:     time.Duration(getEnvInt("ALERT_WEBHOOK_BACKOFF_SECONDS", 10)) * time.Second,
+		AlertWebhookTimeout:     time.Duration(getEnvInt("ALERT_WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+
+		RealtimePollInterval: time.Duration(getEnvInt("REALTIME_POLL_SECONDS", 15)) * time.Second,
+
+		PriceHistory:       getEnv("PRICE_HISTORY", "postgres"),
+		PriceHistoryMaxAge: time.Duration(getEnvInt("PRICE_HISTORY_MAX_AGE_MINUTES", 15)) * time.Minute,
+
+		LLMProvider:     getEnv("LLM_PROVIDER", "groq"),
+		LLMBaseURL:      os.Getenv("LLM_BASE_URL"),
+		LLMAPIKey:       os.Getenv("LLM_API_KEY"),
+		LLMFakeScript:   os.Getenv("LLM_FAKE_SCRIPT"),
+		LLMMaxToolSteps: getEnvInt("LLM_MAX_TOOL_STEPS", 5),
+
+		LLMFallbackModels: getEnvList("LLM_FALLBACK_MODELS"),
+		LLMMaxRetries:     getEnvInt("LLM_MAX_RETRIES", 2),
+		LLMRetryBaseDelay: time.Duration(getEnvInt("LLM_RETRY_BASE_DELAY_MS", 500)) * time.Millisecond,
+		LLMRetryMaxDelay:  time.Duration(getEnvInt("LLM_RETRY_MAX_DELAY_MS", 8000)) * time.Millisecond,
+		LLMTimeout:        time.Duration(getEnvInt("LLM_TIMEOUT_SECONDS", 60)) * time.Second,
+
+		LLMContextBudget:  getEnvInt("LLM_CONTEXT_BUDGET", 8000),
+		LLMContextBudgets: getEnvIntMap("LLM_CONTEXT_BUDGETS"),
 	}
-	if cfg.LLMModel == "" {
-		missing = append(missing, "LLM_MODEL")
+
+	// @NOTE: MOCK_DATA predates MARKET_DATA_PROVIDER
+	if os.Getenv("MOCK_DATA") == "true" && os.Getenv("MARKET_DATA_PROVIDER") == "" {
+		cfg.MarketDataProvider = "random"
 	}
-	if cfg.RapidAPIV1Key == "" {
-		missing = append(missing, "RAPID_API_V1_KEY")
+
+	return cfg
+}
+
+func (cfg *Config) missingMarketData() []string {
+	missing := []string{}
+	if cfg.MarketDataProvider == "rapidapi" {
+		if cfg.RapidAPIV1Key == "" {
+			missing = append(missing, "RAPID_API_V1_KEY")
+		}
+		if cfg.RapidAPIV2Key == "" {
+			missing = append(missing, "RAPID_API_V2_KEY")
+		}
+		if cfg.RapidAPIHost == "" {
+			missing = append(missing, "RAPID_API_HOST")
+		}
 	}
-	if cfg.RapidAPIV2Key == "" {
-		missing = append(missing, "RAPID_API_V2_KEY")
+	return missing
+}
+
+func getEnv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
-	if cfg.RapidAPIHost == "" {
-		missing = append(missing, "RAPID_API_HOST")
+	return fallback
+}
+
+// getEnvInt falls back when the variable is unset or not a number, ranges
+// are checked by outOfRange so 0 stays a valid setting where it means off.
+func getEnvInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
 	}
-	if cfg.FrontendURL == "" {
-		missing = append(missing, "FRONTEND_URL")
+	return v
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
 	}
-	if len(missing) > 0 {
-		return nil, fmt.Errorf("missing required environment variables: %v", missing)
+	return values
+}
+
+// getEnvIntMap parses "key=value,key=value" pairs, skipping malformed ones.
+func getEnvIntMap(key string) map[string]int {
+	values := map[string]int{}
+	for _, pair := range getEnvList(key) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n <= 0 {
+			continue
+		}
+		values[strings.TrimSpace(k)] = n
 	}
-	return cfg, nil
+	return values
 }
@@ -0,0 +1,56 @@
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"patient-chatbot/internal/dto"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrNotFound = errors.New("conversation not found")
+
+const maxTitleLength = 60
+
+type Conversation struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Message is a stored chat turn. Tool messages keep the raw tool result so
+// the chart of any past answer can be restored.
+type Message struct {
+	ID        int64           `json:"id"`
+	Role      dto.Role        `json:"role"`
+	Content   string          `json:"content"`
+	ToolName  string          `json:"toolName,omitempty"`
+	Chart     dto.Chart       `json:"chart,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// Repository stores conversations per user, every method is scoped by userID
+// so a conversation of another user is reported as ErrNotFound.
+type Repository interface {
+	Create(ctx context.Context, userID string, title string) (*Conversation, error)
+	List(ctx context.Context, userID string, limit int, offset int) ([]Conversation, int, error)
+	Get(ctx context.Context, userID string, id string) (*Conversation, error)
+	Rename(ctx context.Context, userID string, id string, title string) (*Conversation, error)
+	Delete(ctx context.Context, userID string, id string) error
+	AppendMessages(ctx context.Context, userID string, id string, messages ...Message) error
+	Messages(ctx context.Context, userID string, id string) ([]Message, error)
+}
+
+// TitleFrom derives a conversation title from its first message.
+func TitleFrom(content string) string {
+	title := strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(title) <= maxTitleLength {
+		return title
+	}
+	runes := []rune(title)
+	return strings.TrimSpace(string(runes[:maxTitleLength])) + "…"
+}
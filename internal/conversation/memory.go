@@ -0,0 +1,124 @@
+package conversation
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps conversations in process memory. It is used when no
+// database is configured and in tests.
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	nextMessageID int64
+	conversations map[string]map[string]*Conversation
+	messages      map[string][]Message
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		conversations: map[string]map[string]*Conversation{},
+		messages:      map[string][]Message{},
+	}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, userID string, title string) (*Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	c := &Conversation{ID: uuid.NewString(), Title: title, CreatedAt: now, UpdatedAt: now}
+	if r.conversations[userID] == nil {
+		r.conversations[userID] = map[string]*Conversation{}
+	}
+	r.conversations[userID][c.ID] = c
+	copied := *c
+	return &copied, nil
+}
+
+func (r *MemoryRepository) List(ctx context.Context, userID string, limit int, offset int) ([]Conversation, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]Conversation, 0, len(r.conversations[userID]))
+	for _, c := range r.conversations[userID] {
+		all = append(all, *c)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].UpdatedAt.After(all[j].UpdatedAt) })
+
+	if offset >= len(all) {
+		return []Conversation{}, len(all), nil
+	}
+	end := min(offset+limit, len(all))
+	return all[offset:end], len(all), nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, userID string, id string) (*Conversation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.conversations[userID][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (r *MemoryRepository) Rename(ctx context.Context, userID string, id string, title string) (*Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.conversations[userID][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c.Title = title
+	c.UpdatedAt = time.Now().UTC()
+	copied := *c
+	return &copied, nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.conversations[userID][id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.conversations[userID], id)
+	delete(r.messages, id)
+	return nil
+}
+
+func (r *MemoryRepository) AppendMessages(ctx context.Context, userID string, id string, messages ...Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.conversations[userID][id]
+	if !ok {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	for _, m := range messages {
+		r.nextMessageID++
+		m.ID = r.nextMessageID
+		m.CreatedAt = now
+		r.messages[id] = append(r.messages[id], m)
+	}
+	c.UpdatedAt = now
+	return nil
+}
+
+func (r *MemoryRepository) Messages(ctx context.Context, userID string, id string) ([]Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.conversations[userID][id]; !ok {
+		return nil, ErrNotFound
+	}
+	return append([]Message{}, r.messages[id]...), nil
+}
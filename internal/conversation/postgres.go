@@ -0,0 +1,179 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/dto"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, userID string, title string) (*Conversation, error) {
+	var c Conversation
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO conversations (id, user_id, title) VALUES ($1, $2, $3)
+		RETURNING id, title, created_at, updated_at`,
+		uuid.NewString(), userID, title,
+	).Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("conversation :: Create :: %w", err)
+	}
+	return &c, nil
+}
+
+func (r *PostgresRepository) List(ctx context.Context, userID string, limit int, offset int) ([]Conversation, int, error) {
+	var total int
+	if err := r.pool.QueryRow(ctx, "SELECT count(*) FROM conversations WHERE user_id = $1", userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("conversation :: List :: error counting: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, title, created_at, updated_at FROM conversations
+		WHERE user_id = $1
+		ORDER BY updated_at DESC LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("conversation :: List :: %w", err)
+	}
+	defer rows.Close()
+
+	conversations := []Conversation{}
+	for rows.Next() {
+		var c Conversation
+		if err := rows.Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, 0, fmt.Errorf("conversation :: List :: error scanning: %w", err)
+		}
+		conversations = append(conversations, c)
+	}
+	return conversations, total, rows.Err()
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, userID string, id string) (*Conversation, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	var c Conversation
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, title, created_at, updated_at FROM conversations WHERE id = $1 AND user_id = $2`,
+		id, userID,
+	).Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("conversation :: Get :: %w", err)
+	}
+	return &c, nil
+}
+
+func (r *PostgresRepository) Rename(ctx context.Context, userID string, id string, title string) (*Conversation, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	var c Conversation
+	err := r.pool.QueryRow(ctx, `
+		UPDATE conversations SET title = $3, updated_at = now() WHERE id = $1 AND user_id = $2
+		RETURNING id, title, created_at, updated_at`,
+		id, userID, title,
+	).Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("conversation :: Rename :: %w", err)
+	}
+	return &c, nil
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, userID string, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+
+	tag, err := r.pool.Exec(ctx, "DELETE FROM conversations WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("conversation :: Delete :: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) AppendMessages(ctx context.Context, userID string, id string, messages ...Message) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("conversation :: AppendMessages :: error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "UPDATE conversations SET updated_at = now() WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("conversation :: AppendMessages :: error touching conversation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	for _, m := range messages {
+		var data []byte
+		if len(m.Data) > 0 {
+			data = m.Data
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO conversation_messages (conversation_id, role, content, tool_name, chart, data)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			id, string(m.Role), m.Content, m.ToolName, string(m.Chart), data,
+		)
+		if err != nil {
+			return fmt.Errorf("conversation :: AppendMessages :: error inserting message: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PostgresRepository) Messages(ctx context.Context, userID string, id string) ([]Message, error) {
+	if _, err := r.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, role, content, tool_name, chart, data, created_at FROM conversation_messages
+		WHERE conversation_id = $1 ORDER BY id`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("conversation :: Messages :: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []Message{}
+	for rows.Next() {
+		var m Message
+		var role, chart string
+		if err := rows.Scan(&m.ID, &role, &m.Content, &m.ToolName, &chart, &m.Data, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("conversation :: Messages :: error scanning: %w", err)
+		}
+		m.Role, m.Chart = dto.Role(role), dto.Chart(chart)
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
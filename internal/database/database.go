@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationLockID is an arbitrary advisory lock key so concurrent instances
+// don't apply migrations twice.
+const migrationLockID = 7_423_201
+
+func Open(ctx context.Context, url string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("database :: Open :: error creating pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("database :: Open :: error connecting: %w", err)
+	}
+	return pool, nil
+}
+
+// Migrate applies every embedded migration that hasn't been applied yet, in
+// file name order, each in its own transaction.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("database :: Migrate :: error acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("database :: Migrate :: error taking lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("database :: Migrate :: error creating schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("database :: Migrate :: error listing migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(strings.TrimPrefix(file, "migrations/"), ".sql")
+
+		var applied bool
+		err := conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("database :: Migrate :: error checking %s: %w", version, err)
+		}
+		if applied {
+			continue
+		}
+
+		sql, err := migrations.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("database :: Migrate :: error reading %s: %w", file, err)
+		}
+
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("database :: Migrate :: error starting transaction: %w", err)
+		}
+		if _, err := tx.Exec(ctx, string(sql)); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("database :: Migrate :: error applying %s: %w", version, err)
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("database :: Migrate :: error recording %s: %w", version, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("database :: Migrate :: error committing %s: %w", version, err)
+		}
+		log.Info().Msgf("database :: Migrate :: applied %s", version)
+	}
+	return nil
+}
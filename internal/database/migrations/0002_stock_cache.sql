@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS stock_cache (
+    key         TEXT PRIMARY KEY,
+    value       JSONB NOT NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    stale_until TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS stock_cache_stale_until_idx ON stock_cache (stale_until);
@@ -0,0 +1,8 @@
+-- missing counts the full feeds in a row a company was left out of, removed lists the delisted ones
+CREATE TABLE IF NOT EXISTS company_directory (
+    id         SMALLINT PRIMARY KEY CHECK (id = 1),
+    companies  JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    missing    JSONB NOT NULL,
+    removed    JSONB NOT NULL
+);
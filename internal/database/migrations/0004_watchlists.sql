@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS watchlists (
+    id         UUID PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS watchlists_user_id_name_idx ON watchlists (user_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS watchlist_items (
+    watchlist_id UUID NOT NULL REFERENCES watchlists (id) ON DELETE CASCADE,
+    tadawul_id   TEXT NOT NULL,
+    position     BIGSERIAL,
+    added_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (watchlist_id, tadawul_id)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS portfolio_transactions (
+    id         UUID PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    tadawul_id TEXT NOT NULL,
+    side       TEXT NOT NULL CHECK (side IN ('buy', 'sell')),
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    price      DOUBLE PRECISION NOT NULL CHECK (price > 0),
+    fees       DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (fees >= 0),
+    traded_on  DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS portfolio_transactions_user_id_idx ON portfolio_transactions (user_id, traded_on, created_at);
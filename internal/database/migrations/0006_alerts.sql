@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id                UUID PRIMARY KEY,
+    user_id           TEXT NOT NULL,
+    tadawul_id        TEXT NOT NULL,
+    condition         TEXT NOT NULL,
+    threshold         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    repeat            BOOLEAN NOT NULL DEFAULT false,
+    webhook_url       TEXT NOT NULL DEFAULT '',
+    triggered         BOOLEAN NOT NULL DEFAULT false,
+    last_triggered_at TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS alert_rules_user_id_idx ON alert_rules (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS alert_events (
+    id              UUID PRIMARY KEY,
+    rule_id         UUID NOT NULL,
+    user_id         TEXT NOT NULL,
+    tadawul_id      TEXT NOT NULL,
+    condition       TEXT NOT NULL,
+    threshold       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    value           DOUBLE PRECISION NOT NULL,
+    price           DOUBLE PRECISION NOT NULL,
+    webhook_url     TEXT NOT NULL DEFAULT '',
+    status          TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ,
+    triggered_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS alert_events_user_id_idx ON alert_events (user_id, triggered_at DESC);
+CREATE INDEX IF NOT EXISTS alert_events_due_idx ON alert_events (next_attempt_at) WHERE status = 'pending';
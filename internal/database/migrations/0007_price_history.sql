@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS price_bars (
+    tadawul_id TEXT NOT NULL,
+    interval   TEXT NOT NULL,
+    bar_date   DATE NOT NULL,
+    open       DOUBLE PRECISION NOT NULL,
+    high       DOUBLE PRECISION NOT NULL,
+    low        DOUBLE PRECISION NOT NULL,
+    close      DOUBLE PRECISION NOT NULL,
+    volume     BIGINT NOT NULL,
+    x          DOUBLE PRECISION NOT NULL,
+    y          DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tadawul_id, interval, bar_date)
+);
+
+-- first_date..last_date is the gapless range price_bars holds every upstream bar for
+CREATE TABLE IF NOT EXISTS price_coverage (
+    tadawul_id  TEXT NOT NULL,
+    interval    TEXT NOT NULL,
+    first_date  DATE NOT NULL,
+    last_date   DATE NOT NULL,
+    ingested_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tadawul_id, interval)
+);
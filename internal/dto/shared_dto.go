@@ -6,6 +6,7 @@ const (
 	UserRole      Role = "user"
 	AssistantRole Role = "assistant"
 	SystemRole    Role = "system"
+	ToolRole      Role = "tool"
 )
 
 type Message struct {
@@ -18,12 +19,19 @@ type Chart string
 const (
 	ChartsDetailedCompanyStockPrices Chart = "detailed_company_stock_prices"
 	ChartsSearchCompanyStocks        Chart = "search_company_stocks"
+	ChartsTechnicalIndicators        Chart = "technical_indicators"
+	ChartsSectorPerformance          Chart = "sector_performance"
+	ChartsWatchlist                  Chart = "watchlist"
+	ChartsPortfolio                  Chart = "portfolio"
+	ChartsAlerts                     Chart = "alerts"
+	ChartsScreener                   Chart = "screener"
 )
 
 type LLMResponse struct {
-	Answer string      `json:"answer"`
-	Stocks interface{} `json:"stocks"`
-	Chart  Chart       `json:"chart"`
+	Answer         string      `json:"answer"`
+	Stocks         interface{} `json:"stocks"`
+	Chart          Chart       `json:"chart"`
+	ConversationID string      `json:"conversationId,omitempty"`
 }
 
 type Context struct {
@@ -31,7 +39,19 @@ type Context struct {
 	Stocks interface{} `json:"stocks"`
 }
 
+// ChatRequestDTO either carries the whole message history (stateless) or a
+// single new message for a stored conversation. Leaving ConversationID empty
+// with Message set starts a new conversation.
 type ChatRequestDTO struct {
-	Messages []Message `json:"messages" binding:"required"`
-	Context  *Context  `json:"context"`
+	Messages       []Message `json:"messages"`
+	ConversationID string    `json:"conversationId"`
+	Message        *Message  `json:"message"`
+	Context        *Context  `json:"context"`
+}
+
+func (r ChatRequestDTO) IsValid() bool {
+	if r.Message != nil {
+		return r.Message.Content != ""
+	}
+	return r.ConversationID == "" && len(r.Messages) > 0
 }
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"patient-chatbot/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// RequireAdmin only lets requests carrying token in the X-Admin-Token header
+// through, and rejects everything when no token is configured.
+func RequireAdmin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader("X-Admin-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(403, NewResponse(nil, utils.Localize(c, "forbidden")))
+			return
+		}
+		c.Next()
+	}
+}
+
+func (h *Handler) HandleRefreshDirectory(c *gin.Context) {
+	diff, err := h.service.RefreshDirectory(c.Request.Context())
+	if err != nil {
+		log.Error().Msg("HandleRefreshDirectory :: " + err.Error())
+		c.JSON(502, NewResponse(nil, utils.Localize(c, "directory_refresh_failed")))
+		return
+	}
+	c.JSON(200, NewResponse(diff, utils.Localize(c, "directory_refreshed_successfully")))
+}
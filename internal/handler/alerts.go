@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"patient-chatbot/internal/alert"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/user"
+	"patient-chatbot/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) HandleListAlerts(c *gin.Context) {
+	rules, err := h.service.ListAlerts(c.Request.Context())
+	if err != nil {
+		h.alertError(c, "HandleListAlerts", err)
+		return
+	}
+	c.JSON(200, NewResponse(rules, utils.Localize(c, "alerts_fetched_successfully")))
+}
+
+func (h *Handler) HandleCreateAlert(c *gin.Context) {
+	var request CreateAlertRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	rule, err := h.service.CreateAlert(c.Request.Context(), alert.Rule{
+		TadawulID:  request.TadawulID,
+		Condition:  alert.Condition(request.Condition),
+		Threshold:  request.Threshold,
+		Repeat:     request.Repeat,
+		WebhookURL: request.WebhookURL,
+	})
+	if err != nil {
+		h.alertError(c, "HandleCreateAlert", err)
+		return
+	}
+	c.JSON(201, NewResponse(rule, utils.Localize(c, "alert_created_successfully")))
+}
+
+func (h *Handler) HandleDeleteAlert(c *gin.Context) {
+	if err := h.service.DeleteAlert(c.Request.Context(), c.Param("id")); err != nil {
+		h.alertError(c, "HandleDeleteAlert", err)
+		return
+	}
+	c.JSON(200, NewResponse(nil, utils.Localize(c, "alert_deleted_successfully")))
+}
+
+func (h *Handler) HandleGetAlertHistory(c *gin.Context) {
+	var request AlertHistoryRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+	h.alertHistory(c, "HandleGetAlertHistory", alert.Status(request.Status), request.Limit)
+}
+
+func (h *Handler) HandleGetDeadLetters(c *gin.Context) {
+	var request AlertHistoryRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+	h.alertHistory(c, "HandleGetDeadLetters", alert.StatusDead, request.Limit)
+}
+
+func (h *Handler) HandleRetryAlertEvent(c *gin.Context) {
+	event, err := h.service.RetryAlertEvent(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.alertError(c, "HandleRetryAlertEvent", err)
+		return
+	}
+	c.JSON(200, NewResponse(event, utils.Localize(c, "alert_event_requeued_successfully")))
+}
+
+func (h *Handler) alertHistory(c *gin.Context, handler string, status alert.Status, limit int) {
+	events, err := h.service.ListAlertHistory(c.Request.Context(), status, limit)
+	if err != nil {
+		h.alertError(c, handler, err)
+		return
+	}
+	c.JSON(200, NewResponse(events, utils.Localize(c, "alert_history_fetched_successfully")))
+}
+
+func (h *Handler) alertError(c *gin.Context, handler string, err error) {
+	switch {
+	case errors.Is(err, user.ErrMissingID):
+		c.JSON(401, NewResponse(nil, utils.Localize(c, "user_id_required")))
+	case errors.Is(err, alert.ErrNotFound):
+		c.JSON(404, NewResponse(nil, utils.Localize(c, "alert_not_found")))
+	case errors.Is(err, alert.ErrNotDeadLettered):
+		c.JSON(409, NewResponse(nil, utils.Localize(c, "alert_event_not_dead_lettered")))
+	case errors.Is(err, alert.ErrLimitReached):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "alert_limit_reached")))
+	case errors.Is(err, alert.ErrWebhooksDisabled):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "alert_webhooks_disabled")))
+	case errors.Is(err, alert.ErrInvalidRule):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "alert_rule_invalid")))
+	case errors.Is(err, mapping.ErrCompanyNotFound):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "company_not_found")))
+	default:
+		log.Error().Msg(handler + " :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+	}
+}
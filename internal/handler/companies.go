@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/indicators"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/middleware"
+	"patient-chatbot/internal/timeseries"
+	"patient-chatbot/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) HandleListCompanies(c *gin.Context) {
+	var request CompaniesRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	lang := middleware.GetLang(c)
+	companies, total := h.service.ListCompanies(mapping.ListOptions{
+		Sector:     request.Sector,
+		Sort:       mapping.SortField(request.Sort),
+		Descending: request.Order == "desc",
+		Lang:       lang,
+		Offset:     (request.Page - 1) * request.PageSize,
+		Limit:      request.PageSize,
+	})
+
+	response := ListCompaniesResponseDTO{
+		Companies: make([]CompanyResponseDTO, 0, len(companies)),
+		PageSize:  request.PageSize,
+		Page:      request.Page,
+		Total:     total,
+	}
+	for _, company := range companies {
+		response.Companies = append(response.Companies, NewCompanyResponseDTO(company, lang))
+	}
+	c.JSON(200, NewResponse(response, utils.Localize(c, "companies_fetched_successfully")))
+}
+
+func (h *Handler) HandleGetCompany(c *gin.Context) {
+	company, err := h.service.GetCompany(c.Param("tadawulId"))
+	if errors.Is(err, mapping.ErrCompanyNotFound) {
+		c.JSON(404, NewResponse(nil, utils.Localize(c, "company_not_found")))
+		return
+	}
+	if err != nil {
+		log.Error().Msg("HandleGetCompany :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+		return
+	}
+	c.JSON(200, NewResponse(NewCompanyResponseDTO(*company, middleware.GetLang(c)), utils.Localize(c, "company_fetched_successfully")))
+}
+
+func (h *Handler) HandleGetCompanyIndicators(c *gin.Context) {
+	var request IndicatorsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	names, err := indicators.ParseNames(request.Indicators)
+	if err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_indicators")))
+		return
+	}
+	if request.Period == "" {
+		request.Period = string(indicators.DefaultPeriod)
+	}
+	query, err := stock.NewPriceQuery(request.Period, request.From, request.To)
+	if err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_price_query")))
+		return
+	}
+	var interval timeseries.Interval
+	if request.Interval != "" {
+		if interval, err = timeseries.ParseInterval(request.Interval); err != nil {
+			c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_chart_interval")))
+			return
+		}
+	}
+
+	report, err := h.service.GetCompanyIndicators(c.Param("tadawulId"), query, interval, names, indicators.Params{
+		SMAPeriod:       request.SMAPeriod,
+		EMAPeriod:       request.EMAPeriod,
+		RSIPeriod:       request.RSIPeriod,
+		MACDFast:        request.MACDFast,
+		MACDSlow:        request.MACDSlow,
+		MACDSignal:      request.MACDSignal,
+		BollingerPeriod: request.BollingerPeriod,
+		BollingerWidth:  request.BollingerWidth,
+		ATRPeriod:       request.ATRPeriod,
+	})
+	if err != nil {
+		log.Error().Msg("HandleGetCompanyIndicators :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+		return
+	}
+	c.JSON(200, NewResponse(report, utils.Localize(c, "indicators_fetched_successfully")))
+}
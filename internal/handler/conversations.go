@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"patient-chatbot/internal/conversation"
+	"patient-chatbot/internal/user"
+	"patient-chatbot/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) HandleListConversations(c *gin.Context) {
+	var pagination PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	conversations, total, err := h.service.ListConversations(c.Request.Context(), pagination.Page, pagination.PageSize)
+	if err != nil {
+		log.Error().Msg("HandleListConversations :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+		return
+	}
+
+	c.JSON(200, NewResponse(ListConversationsResponseDTO{
+		Conversations: conversations,
+		PageSize:      pagination.PageSize,
+		Page:          pagination.Page,
+		Total:         total,
+	}, utils.Localize(c, "conversations_fetched_successfully")))
+}
+
+func (h *Handler) HandleGetConversation(c *gin.Context) {
+	conv, messages, err := h.service.GetConversation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.conversationError(c, "HandleGetConversation", err)
+		return
+	}
+
+	c.JSON(200, NewResponse(ConversationResponseDTO{
+		Conversation: conv,
+		Messages:     messages,
+	}, utils.Localize(c, "conversation_fetched_successfully")))
+}
+
+func (h *Handler) HandleRenameConversation(c *gin.Context) {
+	var request RenameConversationRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	conv, err := h.service.RenameConversation(c.Request.Context(), c.Param("id"), request.Title)
+	if err != nil {
+		h.conversationError(c, "HandleRenameConversation", err)
+		return
+	}
+
+	c.JSON(200, NewResponse(conv, utils.Localize(c, "conversation_renamed_successfully")))
+}
+
+func (h *Handler) HandleDeleteConversation(c *gin.Context) {
+	if err := h.service.DeleteConversation(c.Request.Context(), c.Param("id")); err != nil {
+		h.conversationError(c, "HandleDeleteConversation", err)
+		return
+	}
+
+	c.JSON(200, NewResponse(nil, utils.Localize(c, "conversation_deleted_successfully")))
+}
+
+func (h *Handler) conversationError(c *gin.Context, handler string, err error) {
+	if errors.Is(err, conversation.ErrNotFound) {
+		c.JSON(404, NewResponse(nil, utils.Localize(c, "conversation_not_found")))
+		return
+	}
+	if errors.Is(err, user.ErrMissingID) {
+		c.JSON(401, NewResponse(nil, utils.Localize(c, "user_id_required")))
+		return
+	}
+	log.Error().Msg(handler + " :: " + err.Error())
+	c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"patient-chatbot/internal/client/llm"
+	"patient-chatbot/internal/conversation"
+	"patient-chatbot/internal/user"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chatErrorStatus maps a chat failure to an HTTP status and a locale message
+// key, setting Retry-After when the LLM provider asked us to back off.
+func chatErrorStatus(c *gin.Context, err error) (int, string) {
+	if errors.Is(err, conversation.ErrNotFound) {
+		return 404, "conversation_not_found"
+	}
+	if errors.Is(err, user.ErrMissingID) {
+		return 401, "user_id_required"
+	}
+
+	switch llm.ReasonOf(err) {
+	case llm.ReasonRateLimited:
+		if retryAfter := llm.RetryAfterOf(err); retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		return 429, "assistant_is_busy"
+	case llm.ReasonUnavailable:
+		return 503, "assistant_is_unavailable"
+	case llm.ReasonTimeout:
+		return 504, "assistant_timed_out"
+	case llm.ReasonContextLengthExceeded:
+		return 413, "conversation_is_too_long"
+	}
+	return 500, "an_error_occurred_while_processing_your_request"
+}
@@ -1,9 +1,15 @@
 package handler
 
 import (
+	"patient-chatbot/internal/client/llm"
+	"patient-chatbot/internal/client/stock"
 	"patient-chatbot/internal/dto"
 	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/market"
+	"patient-chatbot/internal/middleware"
+	"patient-chatbot/internal/realtime"
 	"patient-chatbot/internal/service"
+	"patient-chatbot/internal/timeseries"
 	"patient-chatbot/internal/utils"
 	"strconv"
 
@@ -13,10 +19,11 @@ import (
 
 type Handler struct {
 	service *service.Service
+	hub     *realtime.Hub
 }
 
-func NewHandler(service *service.Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service *service.Service, hub *realtime.Hub) *Handler {
+	return &Handler{service: service, hub: hub}
 }
 
 func (h *Handler) HandleGetHealth(c *gin.Context) {
@@ -25,22 +32,69 @@ func (h *Handler) HandleGetHealth(c *gin.Context) {
 
 func (h *Handler) HandleChat(c *gin.Context) {
 	var request dto.ChatRequestDTO
-	if err := c.ShouldBindJSON(&request); err != nil {
+	if err := c.ShouldBindJSON(&request); err != nil || !request.IsValid() {
 		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
 		return
 	}
+	// @NOTE: Stored conversations belong to a user, only stateless chats are anonymous
+	if request.Message != nil && !hasUser(c) {
+		c.JSON(401, NewResponse(nil, utils.Localize(c, "user_id_required")))
+		return
+	}
 
-	// lang := middleware.GetLang(c) // @TODO: Use when we have multiple languages
-	data, err := h.service.Chat(c.Request.Context(), request)
+	lang := middleware.GetLang(c)
+	data, err := h.service.Chat(c.Request.Context(), lang, request)
 	if err != nil {
 		log.Error().Msg("error: " + err.Error())
-		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+		status, messageKey := chatErrorStatus(c, err)
+		c.JSON(status, NewResponse(nil, utils.Localize(c, messageKey)))
 		return
 	}
 
 	c.JSON(200, NewResponse(data, utils.Localize(c, "chat_message_sent")))
 }
 
+func (h *Handler) HandleChatStream(c *gin.Context) {
+	var request dto.ChatRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil || !request.IsValid() {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+	// @NOTE: Stored conversations belong to a user, only stateless chats are anonymous
+	if request.Message != nil && !hasUser(c) {
+		c.JSON(401, NewResponse(nil, utils.Localize(c, "user_id_required")))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	send := func(event string, data interface{}) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+
+	lang := middleware.GetLang(c)
+	data, err := h.service.ChatStream(c.Request.Context(), lang, request, &llm.StreamCallbacks{
+		OnToken: func(token string) {
+			send("token", StreamTokenEvent{Content: token})
+		},
+		OnToolCall: func(name string) {
+			send("tool", StreamToolEvent{Name: name})
+		},
+	})
+	if err != nil {
+		log.Error().Msg("HandleChatStream :: " + err.Error())
+		_, messageKey := chatErrorStatus(c, err)
+		send("error", NewResponse(nil, utils.Localize(c, messageKey)))
+		return
+	}
+
+	send("done", NewResponse(data, utils.Localize(c, "chat_message_sent")))
+}
+
 func (h *Handler) HandleGetDashboard(c *gin.Context) {
 	data, err := h.service.GetDashboard()
 	if err != nil {
@@ -49,33 +103,59 @@ func (h *Handler) HandleGetDashboard(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, NewResponse(data, utils.Localize(c, "chat_message_sent")))
+	c.JSON(200, NewResponse(data, utils.Localize(c, "dashboard_data_fetched_successfully")))
+}
+
+func (h *Handler) HandleGetSectors(c *gin.Context) {
+	var request SectorsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	data, err := h.service.GetSectors(request.Top, market.SectorSort(request.Sort))
+	if err != nil {
+		log.Error().Msg("HandleGetSectors :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+		return
+	}
+	c.JSON(200, NewResponse(data, utils.Localize(c, "sectors_fetched_successfully")))
 }
 
 func (h *Handler) HandleGetCompanyChart(c *gin.Context) {
-	if tid := c.Query("tadawulId"); tid != "" {
-		data, err := h.service.GetCompanyChart(tid)
-		if err != nil {
-			log.Error().Msg("HandleGetCompanyChart :: " + err.Error())
-			c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred")))
+	query, err := stock.NewPriceQuery(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_price_query")))
+		return
+	}
+	var interval timeseries.Interval
+	if value := c.Query("interval"); value != "" {
+		if interval, err = timeseries.ParseInterval(value); err != nil {
+			c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_chart_interval")))
 			return
 		}
-		c.JSON(200, NewResponse(data, utils.Localize(c, "chat_message_sent")))
-		return
 	}
-
-	cid, err := strconv.Atoi(c.Query("companyId"))
+	gaps, err := timeseries.ParseGapPolicy(c.Query("gaps"))
 	if err != nil {
-		c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_company_id")))
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_chart_gaps")))
 		return
 	}
-	tadawulID := mapping.CompanyToTadawul[cid]
+
+	tadawulID := c.Query("tadawulId")
 	if tadawulID == "" {
-		c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_company_id")))
-		return
+		cid, err := strconv.Atoi(c.Query("companyId"))
+		if err != nil {
+			c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_company_id")))
+			return
+		}
+		var ok bool
+		if tadawulID, ok = mapping.TadawulID(cid); !ok {
+			c.JSON(400, NewResponse(nil, utils.Localize(c, "invalid_company_id")))
+			return
+		}
 	}
 
-	data, err := h.service.GetCompanyChart(tadawulID)
+	data, err := h.service.GetCompanyChart(tadawulID, query, interval, gaps)
 	if err != nil {
 		log.Error().Msg("HandleGetCompanyChart :: " + err.Error())
 		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred")))
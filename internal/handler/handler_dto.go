@@ -2,6 +2,8 @@ package handler
 
 import (
 	"mime/multipart"
+	"patient-chatbot/internal/conversation"
+	"patient-chatbot/internal/mapping"
 )
 
 type HandlerResponse struct {
@@ -20,6 +22,14 @@ type ChatResponseDTO struct {
 	Answer string `json:"answer"`
 }
 
+type StreamTokenEvent struct {
+	Content string `json:"content"`
+}
+
+type StreamToolEvent struct {
+	Name string `json:"name"`
+}
+
 type UploadRequestDTO struct {
 	File *multipart.FileHeader `form:"file"  binding:"required"`
 }
@@ -69,3 +79,118 @@ type ExtractedContent struct {
 	ContentID string `json:"content_id"`
 	Content   string `json:"content"`
 }
+
+type ListConversationsResponseDTO struct {
+	Conversations []conversation.Conversation `json:"conversations"`
+	PageSize      int                         `json:"page_size"`
+	Page          int                         `json:"page"`
+	Total         int                         `json:"total"`
+}
+
+type ConversationResponseDTO struct {
+	Conversation *conversation.Conversation `json:"conversation"`
+	Messages     []conversation.Message     `json:"messages"`
+}
+
+type RenameConversationRequestDTO struct {
+	Title string `json:"title" binding:"required,max=200"`
+}
+
+type IndicatorsRequest struct {
+	Indicators      []string `form:"indicators"`
+	Period          string   `form:"period"`
+	From            string   `form:"from"`
+	To              string   `form:"to"`
+	Interval        string   `form:"interval"`
+	SMAPeriod       int      `form:"sma_period"       binding:"min=0,max=500"`
+	EMAPeriod       int      `form:"ema_period"       binding:"min=0,max=500"`
+	RSIPeriod       int      `form:"rsi_period"       binding:"min=0,max=500"`
+	MACDFast        int      `form:"macd_fast"        binding:"min=0,max=500"`
+	MACDSlow        int      `form:"macd_slow"        binding:"min=0,max=500"`
+	MACDSignal      int      `form:"macd_signal"      binding:"min=0,max=500"`
+	BollingerPeriod int      `form:"bollinger_period" binding:"min=0,max=500"`
+	BollingerWidth  float64  `form:"bollinger_width"  binding:"min=0,max=10"`
+	ATRPeriod       int      `form:"atr_period"       binding:"min=0,max=500"`
+}
+
+type CompaniesRequest struct {
+	Sector   string `form:"sector"`
+	Sort     string `form:"sort"                  binding:"omitempty,oneof=name tadawulId sector price change changePercentage volume"`
+	Order    string `form:"order,default=asc"     binding:"oneof=asc desc"`
+	Page     int    `form:"page,default=1"        binding:"min=1"`
+	PageSize int    `form:"page_size,default=50"  binding:"min=1,max=300"`
+}
+
+// CompanyResponseDTO carries both languages plus the name, acronym and sector
+// in the request language.
+type CompanyResponseDTO struct {
+	mapping.Company
+	Name       string `json:"name"`
+	Acronym    string `json:"acronym"`
+	SectorName string `json:"sectorName"`
+}
+
+func NewCompanyResponseDTO(company mapping.Company, lang string) CompanyResponseDTO {
+	return CompanyResponseDTO{
+		Company:    company,
+		Name:       company.Name(lang),
+		Acronym:    company.Acronym(lang),
+		SectorName: company.SectorName(lang),
+	}
+}
+
+type ListCompaniesResponseDTO struct {
+	Companies []CompanyResponseDTO `json:"companies"`
+	PageSize  int                  `json:"page_size"`
+	Page      int                  `json:"page"`
+	Total     int                  `json:"total"`
+}
+
+type SectorsRequest struct {
+	Sort string `form:"sort,default=weightedChange" binding:"oneof=weightedChange averageChange tradedValue name"`
+	Top  int    `form:"top,default=3"               binding:"min=0,max=10"`
+}
+
+type CreateWatchlistRequestDTO struct {
+	Name       string   `json:"name"       binding:"required,max=60"`
+	TadawulIDs []string `json:"tadawulIds" binding:"max=50"`
+}
+
+type RenameWatchlistRequestDTO struct {
+	Name string `json:"name" binding:"required,max=60"`
+}
+
+type WatchlistItemsRequestDTO struct {
+	TadawulIDs []string `json:"tadawulIds" binding:"required,min=1,max=50"`
+}
+
+type CreateTransactionRequestDTO struct {
+	TadawulID string  `json:"tadawulId" binding:"required"`
+	Side      string  `json:"side"      binding:"required,oneof=buy sell"`
+	Quantity  int     `json:"quantity"  binding:"required,min=1"`
+	Price     float64 `json:"price"     binding:"required,gt=0"`
+	Fees      float64 `json:"fees"      binding:"min=0"`
+	Date      string  `json:"date"      binding:"omitempty,datetime=2006-01-02"`
+}
+
+type CreateAlertRequestDTO struct {
+	TadawulID  string  `json:"tadawulId"  binding:"required"`
+	Condition  string  `json:"condition"  binding:"required,oneof=price_above price_below change_up change_down volume_spike high_52w low_52w"`
+	Threshold  float64 `json:"threshold"  binding:"min=0"`
+	Repeat     bool    `json:"repeat"`
+	WebhookURL string  `json:"webhookUrl" binding:"omitempty,url,max=2048"`
+}
+
+// ScreenerRequest binds from the query string on GET and from JSON on POST.
+type ScreenerRequest struct {
+	Filter   string `form:"filter"    json:"filter"   binding:"max=1000"`
+	Sort     string `form:"sort"      json:"sort"`
+	Order    string `form:"order"     json:"order"    binding:"omitempty,oneof=asc desc"`
+	Page     int    `form:"page"      json:"page"     binding:"min=0,max=10000"`
+	PageSize int    `form:"page_size" json:"pageSize" binding:"min=0,max=100"`
+}
+
+type AlertHistoryRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending delivered dead skipped"`
+	Limit  int    `form:"limit"  binding:"min=0,max=200"`
+}
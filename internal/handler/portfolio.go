@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/portfolio"
+	"patient-chatbot/internal/user"
+	"patient-chatbot/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) HandleGetPortfolio(c *gin.Context) {
+	report, err := h.service.GetPortfolio(c.Request.Context())
+	if err != nil {
+		h.portfolioError(c, "HandleGetPortfolio", err)
+		return
+	}
+	c.JSON(200, NewResponse(report, utils.Localize(c, "portfolio_fetched_successfully")))
+}
+
+func (h *Handler) HandleListTransactions(c *gin.Context) {
+	transactions, err := h.service.ListTransactions(c.Request.Context())
+	if err != nil {
+		h.portfolioError(c, "HandleListTransactions", err)
+		return
+	}
+	c.JSON(200, NewResponse(transactions, utils.Localize(c, "transactions_fetched_successfully")))
+}
+
+func (h *Handler) HandleAddTransaction(c *gin.Context) {
+	var request CreateTransactionRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	transaction, err := h.service.AddTransaction(c.Request.Context(), portfolio.Transaction{
+		TadawulID: request.TadawulID,
+		Side:      portfolio.Side(request.Side),
+		Quantity:  request.Quantity,
+		Price:     request.Price,
+		Fees:      request.Fees,
+		Date:      request.Date,
+	})
+	if err != nil {
+		h.portfolioError(c, "HandleAddTransaction", err)
+		return
+	}
+	c.JSON(201, NewResponse(transaction, utils.Localize(c, "transaction_created_successfully")))
+}
+
+func (h *Handler) HandleDeleteTransaction(c *gin.Context) {
+	if err := h.service.DeleteTransaction(c.Request.Context(), c.Param("id")); err != nil {
+		h.portfolioError(c, "HandleDeleteTransaction", err)
+		return
+	}
+	c.JSON(200, NewResponse(nil, utils.Localize(c, "transaction_deleted_successfully")))
+}
+
+func (h *Handler) portfolioError(c *gin.Context, handler string, err error) {
+	switch {
+	case errors.Is(err, user.ErrMissingID):
+		c.JSON(401, NewResponse(nil, utils.Localize(c, "user_id_required")))
+	case errors.Is(err, portfolio.ErrNotFound):
+		c.JSON(404, NewResponse(nil, utils.Localize(c, "transaction_not_found")))
+	case errors.Is(err, portfolio.ErrInsufficientQuantity):
+		c.JSON(409, NewResponse(nil, utils.Localize(c, "insufficient_quantity")))
+	case errors.Is(err, portfolio.ErrFutureDate):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "transaction_date_in_future")))
+	case errors.Is(err, mapping.ErrCompanyNotFound):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "company_not_found")))
+	default:
+		log.Error().Msg(handler + " :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+	}
+}
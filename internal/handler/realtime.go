@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// HandleWebSocket streams quotes and the dashboard, see realtime.Hub. The
+// upgrader answers requests that are not WebSocket handshakes itself.
+func (h *Handler) HandleWebSocket(c *gin.Context) {
+	if err := h.hub.Serve(c.Writer, c.Request); err != nil {
+		log.Warn().Msg("HandleWebSocket :: " + err.Error())
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"patient-chatbot/internal/screener"
+	"patient-chatbot/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// HandleScreen serves the screener, from query parameters on GET and from a
+// JSON body on POST.
+func (h *Handler) HandleScreen(c *gin.Context) {
+	var request ScreenerRequest
+	bind := c.ShouldBindQuery
+	if c.Request.Method == http.MethodPost {
+		bind = c.ShouldBindJSON
+	}
+	if err := bind(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	result, err := h.service.Screen(screener.Query{
+		Filter:   request.Filter,
+		Sort:     request.Sort,
+		Order:    request.Order,
+		Page:     request.Page,
+		PageSize: request.PageSize,
+	})
+	var syntaxErr *screener.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// @NOTE: The position lets clients point at the part of the filter to fix
+		c.JSON(400, NewResponse(syntaxErr, utils.Localize(c, "screener_filter_invalid")))
+		return
+	case errors.Is(err, screener.ErrInvalidQuery):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "screener_sort_invalid")))
+		return
+	case err != nil:
+		log.Error().Msg("HandleScreen :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+		return
+	}
+	c.JSON(200, NewResponse(result, utils.Localize(c, "screener_results_fetched_successfully")))
+}
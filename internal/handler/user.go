@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"patient-chatbot/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireUser rejects requests the UserID middleware left anonymous.
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasUser(c) {
+			c.AbortWithStatusJSON(401, NewResponse(nil, utils.Localize(c, "user_id_required")))
+			return
+		}
+		c.Next()
+	}
+}
+
+func hasUser(c *gin.Context) bool {
+	_, ok := c.Get("UserID")
+	return ok
+}
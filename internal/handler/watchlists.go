@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/user"
+	"patient-chatbot/internal/utils"
+	"patient-chatbot/internal/watchlist"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) HandleListWatchlists(c *gin.Context) {
+	watchlists, err := h.service.ListWatchlists(c.Request.Context())
+	if err != nil {
+		h.watchlistError(c, "HandleListWatchlists", err)
+		return
+	}
+	c.JSON(200, NewResponse(watchlists, utils.Localize(c, "watchlists_fetched_successfully")))
+}
+
+func (h *Handler) HandleCreateWatchlist(c *gin.Context) {
+	var request CreateWatchlistRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	w, err := h.service.CreateWatchlist(c.Request.Context(), request.Name, request.TadawulIDs)
+	if err != nil {
+		h.watchlistError(c, "HandleCreateWatchlist", err)
+		return
+	}
+	c.JSON(201, NewResponse(w, utils.Localize(c, "watchlist_created_successfully")))
+}
+
+func (h *Handler) HandleGetWatchlist(c *gin.Context) {
+	w, err := h.service.GetWatchlist(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.watchlistError(c, "HandleGetWatchlist", err)
+		return
+	}
+	c.JSON(200, NewResponse(w, utils.Localize(c, "watchlist_fetched_successfully")))
+}
+
+func (h *Handler) HandleRenameWatchlist(c *gin.Context) {
+	var request RenameWatchlistRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	w, err := h.service.RenameWatchlist(c.Request.Context(), c.Param("id"), request.Name)
+	if err != nil {
+		h.watchlistError(c, "HandleRenameWatchlist", err)
+		return
+	}
+	c.JSON(200, NewResponse(w, utils.Localize(c, "watchlist_updated_successfully")))
+}
+
+func (h *Handler) HandleDeleteWatchlist(c *gin.Context) {
+	if err := h.service.DeleteWatchlist(c.Request.Context(), c.Param("id")); err != nil {
+		h.watchlistError(c, "HandleDeleteWatchlist", err)
+		return
+	}
+	c.JSON(200, NewResponse(nil, utils.Localize(c, "watchlist_deleted_successfully")))
+}
+
+func (h *Handler) HandleAddToWatchlist(c *gin.Context) {
+	var request WatchlistItemsRequestDTO
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+		return
+	}
+
+	w, err := h.service.AddToWatchlist(c.Request.Context(), c.Param("id"), request.TadawulIDs)
+	if err != nil {
+		h.watchlistError(c, "HandleAddToWatchlist", err)
+		return
+	}
+	c.JSON(200, NewResponse(w, utils.Localize(c, "watchlist_updated_successfully")))
+}
+
+func (h *Handler) HandleRemoveFromWatchlist(c *gin.Context) {
+	w, err := h.service.RemoveFromWatchlist(c.Request.Context(), c.Param("id"), []string{c.Param("tadawulId")})
+	if err != nil {
+		h.watchlistError(c, "HandleRemoveFromWatchlist", err)
+		return
+	}
+	c.JSON(200, NewResponse(w, utils.Localize(c, "watchlist_updated_successfully")))
+}
+
+func (h *Handler) HandleGetWatchlistQuotes(c *gin.Context) {
+	quotes, err := h.service.GetWatchlistQuotes(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.watchlistError(c, "HandleGetWatchlistQuotes", err)
+		return
+	}
+	c.JSON(200, NewResponse(quotes, utils.Localize(c, "watchlist_quotes_fetched_successfully")))
+}
+
+func (h *Handler) watchlistError(c *gin.Context, handler string, err error) {
+	switch {
+	case errors.Is(err, user.ErrMissingID):
+		c.JSON(401, NewResponse(nil, utils.Localize(c, "user_id_required")))
+	case errors.Is(err, watchlist.ErrEmptyName):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "request_is_invalid")))
+	case errors.Is(err, watchlist.ErrNotFound):
+		c.JSON(404, NewResponse(nil, utils.Localize(c, "watchlist_not_found")))
+	case errors.Is(err, watchlist.ErrDuplicateName):
+		c.JSON(409, NewResponse(nil, utils.Localize(c, "watchlist_name_taken")))
+	case errors.Is(err, watchlist.ErrLimitReached):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "watchlist_limit_reached")))
+	case errors.Is(err, mapping.ErrCompanyNotFound):
+		c.JSON(400, NewResponse(nil, utils.Localize(c, "company_not_found")))
+	default:
+		log.Error().Msg(handler + " :: " + err.Error())
+		c.JSON(500, NewResponse(nil, utils.Localize(c, "an_error_occurred_while_processing_your_request")))
+	}
+}
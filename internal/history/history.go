@@ -0,0 +1,167 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/timeseries"
+	"sort"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// closingTime is when the day's bar stops changing, after the closing
+// auction.
+const closingTime = 15*time.Hour + 15*time.Minute
+
+var ErrNoCoverage = errors.New("no price history")
+
+// Coverage is the date range, both ends inclusive, over which the store holds
+// every bar upstream reported for a company.
+type Coverage struct {
+	TadawulID  string
+	Interval   timeseries.Interval
+	From       string
+	To         string
+	IngestedAt time.Time
+}
+
+// Store persists price bars per company and interval along with their
+// coverage.
+type Store interface {
+	// Coverage fails with ErrNoCoverage when nothing was ingested yet.
+	Coverage(ctx context.Context, tadawulID string, interval timeseries.Interval) (*Coverage, error)
+	Bars(ctx context.Context, tadawulID string, interval timeseries.Interval, from string, to string) ([]stock.GetDetailedCompanyStockPricesResponse, error)
+	// Save upserts bars, which must be deduped, and extends the coverage with
+	// from-to in one step. It returns how many bars were new or changed.
+	Save(ctx context.Context, tadawulID string, interval timeseries.Interval, bars []stock.GetDetailedCompanyStockPricesResponse, from string, to string, ingestedAt time.Time) (int, error)
+}
+
+// Serve returns the daily bars of query from the store when its coverage spans
+// the whole window, ok is false otherwise. Today's bar only counts as covered
+// once final or ingested less than maxAge ago.
+func Serve(
+	ctx context.Context,
+	store Store,
+	tadawulID string,
+	query stock.PriceQuery,
+	now time.Time,
+	maxAge time.Duration,
+) ([]stock.GetDetailedCompanyStockPricesResponse, bool, error) {
+	// @NOTE: The 1D period is intraday, the store only has daily bars
+	if query.Period == stock.Period1D {
+		return nil, false, nil
+	}
+	from, to := Window(query, now)
+
+	coverage, err := store.Coverage(ctx, tadawulID, timeseries.Interval1D)
+	if errors.Is(err, ErrNoCoverage) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("history :: Serve :: %w", err)
+	}
+	if !coverage.Covers(from, to, now, maxAge) {
+		return nil, false, nil
+	}
+
+	bars, err := store.Bars(ctx, tadawulID, timeseries.Interval1D, from, to)
+	if err != nil {
+		return nil, false, fmt.Errorf("history :: Serve :: %w", err)
+	}
+	return bars, true, nil
+}
+
+// Window is the date range query asks for, ending today at the latest.
+func Window(query stock.PriceQuery, now time.Time) (string, string) {
+	period := query.Period
+	if period == "" {
+		period = stock.DefaultPeriod
+	}
+	today := now.In(timeseries.Riyadh).Format(DateLayout)
+	from, to := query.From, query.To
+	if from == "" {
+		from = period.Start(now).Format(DateLayout)
+	}
+	if to == "" || to > today {
+		to = today
+	}
+	return from, to
+}
+
+// Covers reports whether from-to lies within the coverage with its last day
+// settled.
+func (c Coverage) Covers(from string, to string, now time.Time, maxAge time.Duration) bool {
+	if from < c.From || to > c.To {
+		return false
+	}
+	if to < c.To {
+		return true
+	}
+	last, err := time.ParseInLocation(DateLayout, c.To, timeseries.Riyadh)
+	if err != nil {
+		return false
+	}
+	return !c.IngestedAt.Before(last.Add(closingTime)) || now.Sub(c.IngestedAt) < maxAge
+}
+
+// extend merges the range from-to into the coverage. A range that neither
+// overlaps nor touches it replaces the coverage when newer and is ignored
+// otherwise, the coverage must stay gapless.
+func (c Coverage) extend(from string, to string, ingestedAt time.Time) Coverage {
+	if c.From == "" {
+		c.From, c.To, c.IngestedAt = from, to, ingestedAt
+		return c
+	}
+	if from > nextDay(c.To) || to < previousDay(c.From) {
+		if to > c.To {
+			c.From, c.To, c.IngestedAt = from, to, ingestedAt
+		}
+		return c
+	}
+	if to >= c.To {
+		c.IngestedAt = ingestedAt
+	}
+	c.From, c.To = min(c.From, from), max(c.To, to)
+	return c
+}
+
+// Dedupe normalizes the bar dates to YYYY-MM-DD and keeps the last bar of
+// every date, in date order. Bars with an unreadable date are dropped.
+func Dedupe(bars []stock.GetDetailedCompanyStockPricesResponse) []stock.GetDetailedCompanyStockPricesResponse {
+	byDate := make(map[string]stock.GetDetailedCompanyStockPricesResponse, len(bars))
+	for _, bar := range bars {
+		date, err := stock.ParseBarDate(bar.Date)
+		if err != nil {
+			continue
+		}
+		bar.Date = date.In(timeseries.Riyadh).Format(DateLayout)
+		bar.Gap = false
+		byDate[bar.Date] = bar
+	}
+
+	deduped := make([]stock.GetDetailedCompanyStockPricesResponse, 0, len(byDate))
+	for _, bar := range byDate {
+		deduped = append(deduped, bar)
+	}
+	sort.Slice(deduped, func(i, j int) bool { return deduped[i].Date < deduped[j].Date })
+	return deduped
+}
+
+func nextDay(date string) string {
+	return shiftDay(date, 1)
+}
+
+func previousDay(date string) string {
+	return shiftDay(date, -1)
+}
+
+func shiftDay(date string, days int) string {
+	t, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, days).Format(DateLayout)
+}
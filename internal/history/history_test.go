@@ -0,0 +1,132 @@
+package history
+
+import (
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/timeseries"
+	"testing"
+	"time"
+)
+
+func at(date string, clock time.Duration) time.Time {
+	day, err := time.ParseInLocation(DateLayout, date, timeseries.Riyadh)
+	if err != nil {
+		panic(err)
+	}
+	return day.Add(clock)
+}
+
+func TestCoverageExtend(t *testing.T) {
+	old := at("2025-01-05", 16*time.Hour)
+	recent := at("2025-01-20", 16*time.Hour)
+	coverage := Coverage{From: "2025-01-01", To: "2025-01-31", IngestedAt: old}
+
+	tests := []struct {
+		name     string
+		coverage Coverage
+		from     string
+		to       string
+		want     Coverage
+	}{
+		{name: "first range", coverage: Coverage{}, from: "2025-01-01", to: "2025-01-31", want: Coverage{From: "2025-01-01", To: "2025-01-31", IngestedAt: recent}},
+		{name: "touching after", coverage: coverage, from: "2025-02-01", to: "2025-02-10", want: Coverage{From: "2025-01-01", To: "2025-02-10", IngestedAt: recent}},
+		{name: "touching before keeps the ingestion time", coverage: coverage, from: "2024-12-01", to: "2024-12-31", want: Coverage{From: "2024-12-01", To: "2025-01-31", IngestedAt: old}},
+		{name: "overlapping the end", coverage: coverage, from: "2025-01-20", to: "2025-02-05", want: Coverage{From: "2025-01-01", To: "2025-02-05", IngestedAt: recent}},
+		{name: "refreshing the last day", coverage: coverage, from: "2025-01-31", to: "2025-01-31", want: Coverage{From: "2025-01-01", To: "2025-01-31", IngestedAt: recent}},
+		{name: "inside", coverage: coverage, from: "2025-01-10", to: "2025-01-12", want: coverage},
+		{name: "disjoint newer replaces", coverage: coverage, from: "2025-02-02", to: "2025-02-10", want: Coverage{From: "2025-02-02", To: "2025-02-10", IngestedAt: recent}},
+		{name: "disjoint older is ignored", coverage: coverage, from: "2024-11-01", to: "2024-12-30", want: coverage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coverage.extend(tt.from, tt.to, recent); got != tt.want {
+				t.Errorf("extend(%s, %s) = %+v, want %+v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoverageCovers(t *testing.T) {
+	const maxAge = 15 * time.Minute
+	during := at("2025-01-05", 13*time.Hour)
+	settled := at("2025-01-05", closingTime)
+
+	tests := []struct {
+		name       string
+		from       string
+		to         string
+		ingestedAt time.Time
+		now        time.Time
+		want       bool
+	}{
+		{name: "before the last day", from: "2025-01-02", to: "2025-01-04", ingestedAt: during, now: during.Add(time.Hour), want: true},
+		{name: "starts before the coverage", from: "2024-12-31", to: "2025-01-04", ingestedAt: settled, now: settled, want: false},
+		{name: "ends after the coverage", from: "2025-01-02", to: "2025-01-06", ingestedAt: settled, now: settled, want: false},
+		{name: "today ingested after the close", from: "2025-01-02", to: "2025-01-05", ingestedAt: settled, now: settled.Add(24 * time.Hour), want: true},
+		{name: "today ingested during the session within max age", from: "2025-01-02", to: "2025-01-05", ingestedAt: during, now: during.Add(maxAge - time.Minute), want: true},
+		{name: "today ingested during the session past max age", from: "2025-01-02", to: "2025-01-05", ingestedAt: during, now: during.Add(maxAge), want: false},
+		{name: "today ingested a minute before the close", from: "2025-01-02", to: "2025-01-05", ingestedAt: settled.Add(-time.Minute), now: settled.Add(time.Hour), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coverage := Coverage{From: "2025-01-01", To: "2025-01-05", IngestedAt: tt.ingestedAt}
+			if got := coverage.Covers(tt.from, tt.to, tt.now, maxAge); got != tt.want {
+				t.Errorf("Covers(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	now := at("2025-03-15", 12*time.Hour)
+
+	tests := []struct {
+		name     string
+		query    stock.PriceQuery
+		now      time.Time
+		wantFrom string
+		wantTo   string
+	}{
+		{name: "default period", query: stock.PriceQuery{}, now: now, wantFrom: "2025-02-15", wantTo: "2025-03-15"},
+		{name: "period", query: stock.PriceQuery{Period: stock.Period1W}, now: now, wantFrom: "2025-03-08", wantTo: "2025-03-15"},
+		{name: "explicit dates", query: stock.PriceQuery{Period: stock.Period1M, From: "2024-01-01", To: "2024-02-01"}, now: now, wantFrom: "2024-01-01", wantTo: "2024-02-01"},
+		{name: "to is capped at today", query: stock.PriceQuery{From: "2025-03-01", To: "2025-04-01"}, now: now, wantFrom: "2025-03-01", wantTo: "2025-03-15"},
+		{name: "today in riyadh time", query: stock.PriceQuery{Period: stock.Period1W}, now: time.Date(2025, 3, 14, 22, 30, 0, 0, time.UTC), wantFrom: "2025-03-08", wantTo: "2025-03-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := Window(tt.query, tt.now)
+			if from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("Window() = %s, %s, want %s, %s", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	bars := []stock.GetDetailedCompanyStockPricesResponse{
+		{Date: "2025-01-02T00:00:00", Close: 1, X: 1, Y: 1},
+		{Date: "2025-01-01", Close: 2},
+		{Date: "2025-01-02 00:00:00", Close: 3, X: 3, Y: 3, Gap: true},
+		// @NOTE: 21:30 UTC is already the next day in Riyadh
+		{Date: "2025-01-02T21:30:00Z", Close: 4},
+		{Date: "yesterday", Close: 5},
+	}
+	want := []stock.GetDetailedCompanyStockPricesResponse{
+		{Date: "2025-01-01", Close: 2},
+		{Date: "2025-01-02", Close: 3, X: 3, Y: 3},
+		{Date: "2025-01-03", Close: 4},
+	}
+
+	got := Dedupe(bars)
+	if len(got) != len(want) {
+		t.Fatalf("Dedupe() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bar %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/timeseries"
+	"time"
+)
+
+// PriceSource is where the ingester downloads prices from.
+type PriceSource interface {
+	GetDetailedCompanyStockPrices(companyID string, query stock.PriceQuery) ([]stock.GetDetailedCompanyStockPricesResponse, error)
+}
+
+// Result is the outcome of ingesting one company.
+type Result struct {
+	TadawulID string
+	Period    stock.Period
+	Fetched   int
+	Stored    int
+	Coverage  Coverage
+}
+
+// Ingester backfills the daily bars of companies without history over the
+// longest upstream period, then only fetches from the last stored day on.
+type Ingester struct {
+	source PriceSource
+	store  Store
+}
+
+func NewIngester(source PriceSource, store Store) *Ingester {
+	return &Ingester{source: source, store: store}
+}
+
+// Ingest downloads and stores the daily bars of a company. backfill forces a
+// download over the longest upstream period even when history exists.
+func (i *Ingester) Ingest(ctx context.Context, tadawulID string, backfill bool) (*Result, error) {
+	now := time.Now()
+	period := stock.Period5Y
+	if !backfill {
+		coverage, err := i.store.Coverage(ctx, tadawulID, timeseries.Interval1D)
+		switch {
+		case errors.Is(err, ErrNoCoverage):
+		case err != nil:
+			return nil, fmt.Errorf("history :: Ingest :: %w", err)
+		default:
+			// @NOTE: The last stored day is fetched again, it may have been ingested mid-session
+			query := stock.PriceQuery{Period: stock.Period1W, From: coverage.To}
+			period = query.UpstreamPeriod(now)
+		}
+	}
+
+	prices, err := i.source.GetDetailedCompanyStockPrices(tadawulID, stock.PriceQuery{Period: period})
+	if err != nil {
+		return nil, fmt.Errorf("history :: Ingest :: error fetching %s prices: %w", tadawulID, err)
+	}
+	bars := Dedupe(prices)
+
+	from, to := Window(stock.PriceQuery{Period: period}, now)
+	stored, err := i.store.Save(ctx, tadawulID, timeseries.Interval1D, bars, from, to, now)
+	if err != nil {
+		return nil, fmt.Errorf("history :: Ingest :: %w", err)
+	}
+	coverage, err := i.store.Coverage(ctx, tadawulID, timeseries.Interval1D)
+	if err != nil {
+		return nil, fmt.Errorf("history :: Ingest :: %w", err)
+	}
+	return &Result{TadawulID: tadawulID, Period: period, Fetched: len(prices), Stored: stored, Coverage: *coverage}, nil
+}
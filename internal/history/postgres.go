@@ -0,0 +1,174 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/timeseries"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+func (s *PostgresStore) Coverage(ctx context.Context, tadawulID string, interval timeseries.Interval) (*Coverage, error) {
+	coverage, err := s.coverage(ctx, s.pool, tadawulID, interval)
+	if err != nil {
+		return nil, fmt.Errorf("history :: Coverage :: %w", err)
+	}
+	return coverage, nil
+}
+
+func (s *PostgresStore) Bars(ctx context.Context, tadawulID string, interval timeseries.Interval, from string, to string) ([]stock.GetDetailedCompanyStockPricesResponse, error) {
+	fromDate, toDate, err := parseRange(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("history :: Bars :: %w", err)
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT to_char(bar_date, 'YYYY-MM-DD'), open, close, high, low, volume, x, y
+		FROM price_bars
+		WHERE tadawul_id = $1 AND interval = $2 AND bar_date BETWEEN $3 AND $4
+		ORDER BY bar_date`,
+		tadawulID, string(interval), fromDate, toDate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("history :: Bars :: %w", err)
+	}
+	defer rows.Close()
+
+	bars := []stock.GetDetailedCompanyStockPricesResponse{}
+	for rows.Next() {
+		var bar stock.GetDetailedCompanyStockPricesResponse
+		var volume int64
+		if err := rows.Scan(&bar.Date, &bar.Open, &bar.Close, &bar.High, &bar.Low, &volume, &bar.X, &bar.Y); err != nil {
+			return nil, fmt.Errorf("history :: Bars :: error scanning: %w", err)
+		}
+		bar.Volume = int(volume)
+		bars = append(bars, bar)
+	}
+	return bars, rows.Err()
+}
+
+func (s *PostgresStore) Save(
+	ctx context.Context,
+	tadawulID string,
+	interval timeseries.Interval,
+	bars []stock.GetDetailedCompanyStockPricesResponse,
+	from string,
+	to string,
+	ingestedAt time.Time,
+) (int, error) {
+	if _, _, err := parseRange(from, to); err != nil {
+		return 0, fmt.Errorf("history :: Save :: %w", err)
+	}
+	dates := make([]time.Time, len(bars))
+	opens := make([]float64, len(bars))
+	highs := make([]float64, len(bars))
+	lows := make([]float64, len(bars))
+	closes := make([]float64, len(bars))
+	volumes := make([]int64, len(bars))
+	xs := make([]float64, len(bars))
+	ys := make([]float64, len(bars))
+	for i, bar := range bars {
+		date, err := time.Parse(DateLayout, bar.Date)
+		if err != nil {
+			return 0, fmt.Errorf("history :: Save :: bar date %q is not YYYY-MM-DD", bar.Date)
+		}
+		dates[i], opens[i], highs[i], lows[i], closes[i], volumes[i] = date, bar.Open, bar.High, bar.Low, bar.Close, int64(bar.Volume)
+		xs[i], ys[i] = bar.X, bar.Y
+	}
+
+	var stored int
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// @NOTE: Serializes ingestions of a company so coverage updates don't overwrite each other
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "price_coverage:"+tadawulID+":"+string(interval)); err != nil {
+			return err
+		}
+		// @NOTE: Unchanged bars are left alone so the count only has new or corrected ones
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO price_bars (tadawul_id, interval, bar_date, open, high, low, close, volume, x, y)
+			SELECT $1, $2, t.bar_date, t.open, t.high, t.low, t.close, t.volume, t.x, t.y
+			FROM unnest($3::date[], $4::float8[], $5::float8[], $6::float8[], $7::float8[], $8::bigint[], $9::float8[], $10::float8[])
+				AS t (bar_date, open, high, low, close, volume, x, y)
+			ON CONFLICT (tadawul_id, interval, bar_date) DO UPDATE SET
+				open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low,
+				close = EXCLUDED.close, volume = EXCLUDED.volume, x = EXCLUDED.x, y = EXCLUDED.y, updated_at = now()
+			WHERE (price_bars.open, price_bars.high, price_bars.low, price_bars.close, price_bars.volume, price_bars.x, price_bars.y)
+				IS DISTINCT FROM (EXCLUDED.open, EXCLUDED.high, EXCLUDED.low, EXCLUDED.close, EXCLUDED.volume, EXCLUDED.x, EXCLUDED.y)`,
+			tadawulID, string(interval), dates, opens, highs, lows, closes, volumes, xs, ys,
+		)
+		if err != nil {
+			return err
+		}
+		stored = int(tag.RowsAffected())
+
+		current, err := s.coverage(ctx, tx, tadawulID, interval)
+		if errors.Is(err, ErrNoCoverage) {
+			current = &Coverage{TadawulID: tadawulID, Interval: interval}
+		} else if err != nil {
+			return err
+		}
+		next := current.extend(from, to, ingestedAt)
+		_, err = tx.Exec(ctx, `
+			INSERT INTO price_coverage (tadawul_id, interval, first_date, last_date, ingested_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (tadawul_id, interval) DO UPDATE SET
+				first_date = EXCLUDED.first_date, last_date = EXCLUDED.last_date, ingested_at = EXCLUDED.ingested_at`,
+			tadawulID, string(interval), mustParseDate(next.From), mustParseDate(next.To), next.IngestedAt,
+		)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("history :: Save :: %w", err)
+	}
+	return stored, nil
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (s *PostgresStore) coverage(ctx context.Context, q querier, tadawulID string, interval timeseries.Interval) (*Coverage, error) {
+	coverage := Coverage{TadawulID: tadawulID, Interval: interval}
+	err := q.QueryRow(ctx, `
+		SELECT to_char(first_date, 'YYYY-MM-DD'), to_char(last_date, 'YYYY-MM-DD'), ingested_at
+		FROM price_coverage
+		WHERE tadawul_id = $1 AND interval = $2`,
+		tadawulID, string(interval),
+	).Scan(&coverage.From, &coverage.To, &coverage.IngestedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoCoverage
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &coverage, nil
+}
+
+func parseRange(from string, to string) (time.Time, time.Time, error) {
+	fromDate, err := time.Parse(DateLayout, from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("from %q is not YYYY-MM-DD", from)
+	}
+	toDate, err := time.Parse(DateLayout, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("to %q is not YYYY-MM-DD", to)
+	}
+	return fromDate, toDate, nil
+}
+
+// mustParseDate parses dates already validated by parseRange or read back
+// from the coverage table.
+func mustParseDate(date string) time.Time {
+	t, _ := time.Parse(DateLayout, date)
+	return t
+}
@@ -0,0 +1,247 @@
+package indicators
+
+import (
+	"math"
+	"patient-chatbot/internal/client/stock"
+	"strconv"
+)
+
+// Value is a single indicator reading. It is NaN while there are not enough
+// bars to compute it yet and is encoded as null in JSON.
+type Value float64
+
+func (v Value) Valid() bool {
+	return !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	if !v.Valid() {
+		return []byte("null"), nil
+	}
+	return strconv.AppendFloat(nil, math.Round(float64(v)*10000)/10000, 'f', -1, 64), nil
+}
+
+func (v *Value) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*v = nan()
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return err
+	}
+	*v = Value(f)
+	return nil
+}
+
+func nan() Value {
+	return Value(math.NaN())
+}
+
+func newSeries(n int) []Value {
+	series := make([]Value, n)
+	for i := range series {
+		series[i] = nan()
+	}
+	return series
+}
+
+func closes(bars []stock.GetDetailedCompanyStockPricesResponse) []Value {
+	values := make([]Value, len(bars))
+	for i, bar := range bars {
+		values[i] = Value(bar.Close)
+	}
+	return values
+}
+
+// SMA is the simple moving average over period values.
+func SMA(values []Value, period int) []Value {
+	result := newSeries(len(values))
+	if period <= 0 {
+		return result
+	}
+	var sum float64
+	valid := 0
+	for i, v := range values {
+		if !v.Valid() {
+			sum, valid = 0, 0
+			continue
+		}
+		sum += float64(v)
+		valid++
+		if valid > period {
+			sum -= float64(values[i-period])
+			valid = period
+		}
+		if valid == period {
+			result[i] = Value(sum / float64(period))
+		}
+	}
+	return result
+}
+
+// EMA is the exponential moving average over period values, seeded with the
+// SMA of the first period valid values. Leading invalid values are skipped so
+// it can smooth another indicator, as the MACD signal line does.
+func EMA(values []Value, period int) []Value {
+	result := newSeries(len(values))
+	if period <= 0 {
+		return result
+	}
+	seed := SMA(values, period)
+	alpha := 2 / float64(period+1)
+	prev := nan()
+	for i, v := range values {
+		switch {
+		case !v.Valid():
+			prev = nan()
+		case prev.Valid():
+			prev = Value(alpha*float64(v) + (1-alpha)*float64(prev))
+		default:
+			prev = seed[i]
+		}
+		result[i] = prev
+	}
+	return result
+}
+
+// wilder smooths values with Wilder's moving average, seeded with the SMA of
+// the first period values.
+func wilder(values []Value, period int) []Value {
+	result := newSeries(len(values))
+	if period <= 0 || len(values) < period {
+		return result
+	}
+	var sum float64
+	for _, v := range values[:period] {
+		sum += float64(v)
+	}
+	prev := sum / float64(period)
+	result[period-1] = Value(prev)
+	for i := period; i < len(values); i++ {
+		prev = (prev*float64(period-1) + float64(values[i])) / float64(period)
+		result[i] = Value(prev)
+	}
+	return result
+}
+
+// RSI is Wilder's relative strength index, from 0 to 100 and 50 when prices
+// did not move over the period.
+func RSI(values []Value, period int) []Value {
+	result := newSeries(len(values))
+	if len(values) < 2 {
+		return result
+	}
+	gains := make([]Value, len(values)-1)
+	losses := make([]Value, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		change := float64(values[i] - values[i-1])
+		gains[i-1] = Value(math.Max(change, 0))
+		losses[i-1] = Value(math.Max(-change, 0))
+	}
+	avgGain, avgLoss := wilder(gains, period), wilder(losses, period)
+	for i := range gains {
+		if !avgGain[i].Valid() {
+			continue
+		}
+		// @NOTE: A flat series neither gained nor lost, it is neutral rather than overbought
+		if avgLoss[i] == 0 && avgGain[i] == 0 {
+			result[i+1] = 50
+			continue
+		}
+		if avgLoss[i] == 0 {
+			result[i+1] = 100
+			continue
+		}
+		rs := avgGain[i] / avgLoss[i]
+		result[i+1] = 100 - 100/(1+rs)
+	}
+	return result
+}
+
+// MACD returns the MACD line (fast EMA minus slow EMA), its signal line and
+// the histogram between them.
+func MACD(values []Value, fast, slow, signal int) ([]Value, []Value, []Value) {
+	fastEMA, slowEMA := EMA(values, fast), EMA(values, slow)
+	line := newSeries(len(values))
+	for i := range values {
+		if fastEMA[i].Valid() && slowEMA[i].Valid() {
+			line[i] = fastEMA[i] - slowEMA[i]
+		}
+	}
+	signalLine := EMA(line, signal)
+	histogram := newSeries(len(values))
+	for i := range values {
+		if line[i].Valid() && signalLine[i].Valid() {
+			histogram[i] = line[i] - signalLine[i]
+		}
+	}
+	return line, signalLine, histogram
+}
+
+// Bollinger returns the middle, upper and lower bands, width population
+// standard deviations around the SMA.
+func Bollinger(values []Value, period int, width float64) ([]Value, []Value, []Value) {
+	middle := SMA(values, period)
+	upper, lower := newSeries(len(values)), newSeries(len(values))
+	for i, mean := range middle {
+		if !mean.Valid() {
+			continue
+		}
+		var variance float64
+		for _, v := range values[i-period+1 : i+1] {
+			variance += math.Pow(float64(v-mean), 2)
+		}
+		deviation := Value(width * math.Sqrt(variance/float64(period)))
+		upper[i], lower[i] = mean+deviation, mean-deviation
+	}
+	return middle, upper, lower
+}
+
+// ATR is Wilder's average true range.
+func ATR(bars []stock.GetDetailedCompanyStockPricesResponse, period int) []Value {
+	trueRanges := make([]Value, len(bars))
+	for i, bar := range bars {
+		tr := bar.High - bar.Low
+		if i > 0 {
+			prevClose := bars[i-1].Close
+			tr = math.Max(tr, math.Max(math.Abs(bar.High-prevClose), math.Abs(bar.Low-prevClose)))
+		}
+		trueRanges[i] = Value(tr)
+	}
+	return wilder(trueRanges, period)
+}
+
+// VWAP is the volume weighted average of the typical price, cumulative from
+// the first bar.
+func VWAP(bars []stock.GetDetailedCompanyStockPricesResponse) []Value {
+	result := newSeries(len(bars))
+	var priceVolume, volume float64
+	for i, bar := range bars {
+		typical := (bar.High + bar.Low + bar.Close) / 3
+		priceVolume += typical * float64(bar.Volume)
+		volume += float64(bar.Volume)
+		if volume > 0 {
+			result[i] = Value(priceVolume / volume)
+		}
+	}
+	return result
+}
+
+// OBV is the on-balance volume, starting at zero on the first bar.
+func OBV(bars []stock.GetDetailedCompanyStockPricesResponse) []Value {
+	result := newSeries(len(bars))
+	var obv float64
+	for i, bar := range bars {
+		if i > 0 {
+			switch {
+			case bar.Close > bars[i-1].Close:
+				obv += float64(bar.Volume)
+			case bar.Close < bars[i-1].Close:
+				obv -= float64(bar.Volume)
+			}
+		}
+		result[i] = Value(obv)
+	}
+	return result
+}
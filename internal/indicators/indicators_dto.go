@@ -0,0 +1,10 @@
+package indicators
+
+const FunctionGetTechnicalIndicators = "GetTechnicalIndicators"
+
+type GetTechnicalIndicatorsArguments struct {
+	TadawulID  string   `json:"tadawulID" description:"The tadawul id of the company"`
+	Indicators []string `json:"indicators,omitempty" description:"Indicators to compute, any of sma, ema, rsi, macd, bollinger, atr, vwap, obv. Defaults to all of them"`
+	Period     string   `json:"period,omitempty" enum:"1M,3M,6M,YTD,1Y,5Y" description:"How much price history to compute over. Defaults to 6M"`
+	Interval   string   `json:"interval,omitempty" enum:"1D,1W,1M" description:"Bar size the indicators are computed on. Defaults to 1D"`
+}
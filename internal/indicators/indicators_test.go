@@ -0,0 +1,170 @@
+package indicators
+
+import (
+	"math"
+	"patient-chatbot/internal/client/stock"
+	"testing"
+)
+
+var none = math.NaN()
+
+func series(values ...float64) []Value {
+	result := make([]Value, len(values))
+	for i, v := range values {
+		result[i] = Value(v)
+	}
+	return result
+}
+
+func bar(high float64, low float64, close float64, volume int) stock.GetDetailedCompanyStockPricesResponse {
+	return stock.GetDetailedCompanyStockPricesResponse{High: high, Low: low, Close: close, Volume: volume}
+}
+
+func assertSeries(t *testing.T, name string, got []Value, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s has %d values, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			if got[i].Valid() {
+				t.Errorf("%s[%d] = %v, want no value", name, i, got[i])
+			}
+			continue
+		}
+		if !got[i].Valid() || math.Abs(float64(got[i])-want[i]) > 1e-9 {
+			t.Errorf("%s[%d] = %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestMovingAverages(t *testing.T) {
+	tests := []struct {
+		name    string
+		average func([]Value, int) []Value
+		values  []Value
+		period  int
+		want    []float64
+	}{
+		{name: "sma", average: SMA, values: series(1, 2, 3, 4, 5), period: 3, want: []float64{none, none, 2, 3, 4}},
+		{name: "sma restarts after a missing value", average: SMA, values: series(1, 2, none, 4, 5, 6), period: 2, want: []float64{none, 1.5, none, none, 4.5, 5.5}},
+		{name: "sma without a period", average: SMA, values: series(1, 2), period: 0, want: []float64{none, none}},
+		{name: "ema is seeded with the sma", average: EMA, values: series(2, 4, 6, 8, 20), period: 3, want: []float64{none, none, 4, 6, 13}},
+		{name: "ema skips leading missing values", average: EMA, values: series(none, 2, 4, 6, 8), period: 2, want: []float64{none, none, 3, 5, 7}},
+		{name: "ema shorter than its period", average: EMA, values: series(1, 2), period: 3, want: []float64{none, none}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, tt.name, tt.average(tt.values, tt.period), tt.want)
+		})
+	}
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []Value
+		period int
+		want   []float64
+	}{
+		{name: "flat series is neutral", values: series(10, 10, 10, 10), period: 2, want: []float64{none, none, 50, 50}},
+		{name: "only gains", values: series(1, 2, 3, 4), period: 2, want: []float64{none, none, 100, 100}},
+		{name: "only losses", values: series(4, 3, 2, 1), period: 2, want: []float64{none, none, 0, 0}},
+		{name: "wilder smoothing", values: series(1, 2, 1, 2), period: 2, want: []float64{none, none, 50, 75}},
+		{name: "shorter than its period", values: series(1, 2), period: 2, want: []float64{none, none}},
+		{name: "single value", values: series(1), period: 2, want: []float64{none}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, "rsi", RSI(tt.values, tt.period), tt.want)
+		})
+	}
+}
+
+func TestRSISignal(t *testing.T) {
+	flat := RSI(series(10, 10, 10, 10, 10), 3)
+	if signal := rsiSignal(last(flat)); signal != "neutral" {
+		t.Errorf("signal of a flat series = %q, want neutral", signal)
+	}
+}
+
+func TestMACD(t *testing.T) {
+	line, signal, histogram := MACD(series(5, 5, 5, 5, 5, 5), 2, 3, 2)
+	assertSeries(t, "line", line, []float64{none, none, 0, 0, 0, 0})
+	assertSeries(t, "signal", signal, []float64{none, none, none, 0, 0, 0})
+	assertSeries(t, "histogram", histogram, []float64{none, none, none, 0, 0, 0})
+
+	line, _, _ = MACD(series(1, 2, 3, 4), 2, 3, 2)
+	// @NOTE: On a steady rise the fast average leads the slow one by half a step
+	assertSeries(t, "rising line", line, []float64{none, none, 0.5, 0.5})
+}
+
+func TestBollinger(t *testing.T) {
+	tests := []struct {
+		name                       string
+		values                     []Value
+		period                     int
+		width                      float64
+		wantMiddle, wantUp, wantLo []float64
+	}{
+		{name: "flat series has no spread", values: series(5, 5, 5), period: 2, width: 2, wantMiddle: []float64{none, 5, 5}, wantUp: []float64{none, 5, 5}, wantLo: []float64{none, 5, 5}},
+		{name: "population deviation", values: series(1, 3, 5), period: 2, width: 1, wantMiddle: []float64{none, 2, 4}, wantUp: []float64{none, 3, 5}, wantLo: []float64{none, 1, 3}},
+		{name: "width scales the bands", values: series(1, 3), period: 2, width: 2, wantMiddle: []float64{none, 2}, wantUp: []float64{none, 4}, wantLo: []float64{none, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middle, upper, lower := Bollinger(tt.values, tt.period, tt.width)
+			assertSeries(t, "middle", middle, tt.wantMiddle)
+			assertSeries(t, "upper", upper, tt.wantUp)
+			assertSeries(t, "lower", lower, tt.wantLo)
+		})
+	}
+}
+
+func TestBarIndicators(t *testing.T) {
+	bars := []stock.GetDetailedCompanyStockPricesResponse{
+		bar(10, 8, 9, 100),
+		bar(12, 9, 11, 300),
+		bar(11, 10, 10.5, 0),
+		bar(11, 9, 9, 200),
+	}
+
+	// @NOTE: True ranges are 2, 3 (the gap from the previous close), 1 and 2
+	assertSeries(t, "atr", ATR(bars, 2), []float64{none, 2.5, 1.75, 1.875})
+	// @NOTE: Typical prices are 9, 32/3, 31.5/3 and 29/3
+	assertSeries(t, "vwap", VWAP(bars), []float64{9, (900 + 3200) / 400.0, (900 + 3200) / 400.0, (900 + 3200 + 29.0/3*200) / 600})
+	assertSeries(t, "obv", OBV(bars), []float64{0, 300, 300, 100})
+}
+
+func TestVWAPWithoutVolume(t *testing.T) {
+	bars := []stock.GetDetailedCompanyStockPricesResponse{bar(3, 1, 2, 0), bar(6, 3, 3, 100)}
+	assertSeries(t, "vwap", VWAP(bars), []float64{none, 4})
+}
+
+func TestValueJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Value
+		want  string
+	}{
+		{name: "missing value is null", value: nan(), want: "null"},
+		{name: "infinity is null", value: Value(math.Inf(1)), want: "null"},
+		{name: "rounded to four decimals", value: 71.234567, want: "71.2346"},
+		{name: "whole number", value: 50, want: "50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.value.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
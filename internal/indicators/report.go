@@ -0,0 +1,290 @@
+package indicators
+
+import (
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"strings"
+)
+
+type Name string
+
+const (
+	NameSMA       Name = "sma"
+	NameEMA       Name = "ema"
+	NameRSI       Name = "rsi"
+	NameMACD      Name = "macd"
+	NameBollinger Name = "bollinger"
+	NameATR       Name = "atr"
+	NameVWAP      Name = "vwap"
+	NameOBV       Name = "obv"
+)
+
+var Names = []Name{NameSMA, NameEMA, NameRSI, NameMACD, NameBollinger, NameATR, NameVWAP, NameOBV}
+
+var ErrInvalidIndicators = errors.New("invalid indicators request")
+
+// DefaultPeriod is the price history indicators are computed over, long
+// enough for the MACD signal line to warm up on daily bars.
+const DefaultPeriod = stock.Period6M
+
+// ParseNames parses indicator names, returning all of them when none are given.
+func ParseNames(values []string) ([]Name, error) {
+	var names []Name
+	seen := map[Name]bool{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			name := Name(strings.ToLower(strings.TrimSpace(part)))
+			if name == "" || seen[name] {
+				continue
+			}
+			if !name.valid() {
+				return nil, fmt.Errorf("%w: unknown indicator %q", ErrInvalidIndicators, part)
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return Names, nil
+	}
+	return names, nil
+}
+
+func (n Name) valid() bool {
+	for _, name := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Params are the indicator windows, zero values fall back to the defaults.
+type Params struct {
+	SMAPeriod       int     `json:"smaPeriod,omitempty"`
+	EMAPeriod       int     `json:"emaPeriod,omitempty"`
+	RSIPeriod       int     `json:"rsiPeriod,omitempty"`
+	MACDFast        int     `json:"macdFast,omitempty"`
+	MACDSlow        int     `json:"macdSlow,omitempty"`
+	MACDSignal      int     `json:"macdSignal,omitempty"`
+	BollingerPeriod int     `json:"bollingerPeriod,omitempty"`
+	BollingerWidth  float64 `json:"bollingerWidth,omitempty"`
+	ATRPeriod       int     `json:"atrPeriod,omitempty"`
+}
+
+var DefaultParams = Params{
+	SMAPeriod:       20,
+	EMAPeriod:       20,
+	RSIPeriod:       14,
+	MACDFast:        12,
+	MACDSlow:        26,
+	MACDSignal:      9,
+	BollingerPeriod: 20,
+	BollingerWidth:  2,
+	ATRPeriod:       14,
+}
+
+func (p Params) withDefaults() Params {
+	or := func(v, fallback int) int {
+		if v > 0 {
+			return v
+		}
+		return fallback
+	}
+	p.SMAPeriod = or(p.SMAPeriod, DefaultParams.SMAPeriod)
+	p.EMAPeriod = or(p.EMAPeriod, DefaultParams.EMAPeriod)
+	p.RSIPeriod = or(p.RSIPeriod, DefaultParams.RSIPeriod)
+	p.MACDFast = or(p.MACDFast, DefaultParams.MACDFast)
+	p.MACDSlow = or(p.MACDSlow, DefaultParams.MACDSlow)
+	p.MACDSignal = or(p.MACDSignal, DefaultParams.MACDSignal)
+	p.BollingerPeriod = or(p.BollingerPeriod, DefaultParams.BollingerPeriod)
+	p.ATRPeriod = or(p.ATRPeriod, DefaultParams.ATRPeriod)
+	if p.BollingerWidth <= 0 {
+		p.BollingerWidth = DefaultParams.BollingerWidth
+	}
+	return p
+}
+
+// Indicator holds one indicator's lines, aligned with Report.Dates, and
+// their latest readings. Signal is a plain reading of the latest values such
+// as "overbought" for an RSI above 70.
+type Indicator struct {
+	Name   Name               `json:"name"`
+	Params map[string]float64 `json:"params,omitempty"`
+	Lines  map[string][]Value `json:"lines,omitempty"`
+	Latest map[string]Value   `json:"latest"`
+	Signal string             `json:"signal,omitempty"`
+}
+
+type Report struct {
+	TadawulID  string      `json:"tadawulId"`
+	Bars       int         `json:"bars"`
+	From       string      `json:"from,omitempty"`
+	To         string      `json:"to,omitempty"`
+	Close      Value       `json:"close"`
+	Dates      []string    `json:"dates,omitempty"`
+	Indicators []Indicator `json:"indicators"`
+}
+
+// Compute runs the named indicators over bars, which must be sorted oldest
+// first.
+func Compute(bars []stock.GetDetailedCompanyStockPricesResponse, names []Name, params Params) Report {
+	params = params.withDefaults()
+	values := closes(bars)
+	report := Report{
+		Bars:       len(bars),
+		Close:      nan(),
+		Dates:      make([]string, len(bars)),
+		Indicators: make([]Indicator, 0, len(names)),
+	}
+	for i, bar := range bars {
+		report.Dates[i] = bar.Date
+	}
+	if len(bars) > 0 {
+		report.From, report.To = bars[0].Date, bars[len(bars)-1].Date
+		report.Close = values[len(values)-1]
+	}
+
+	for _, name := range names {
+		var indicator Indicator
+		switch name {
+		case NameSMA:
+			sma := SMA(values, params.SMAPeriod)
+			indicator = newIndicator(name, map[string]float64{"period": float64(params.SMAPeriod)}, line{"sma", sma})
+			indicator.Signal = priceRelativeTo(report.Close, last(sma))
+		case NameEMA:
+			ema := EMA(values, params.EMAPeriod)
+			indicator = newIndicator(name, map[string]float64{"period": float64(params.EMAPeriod)}, line{"ema", ema})
+			indicator.Signal = priceRelativeTo(report.Close, last(ema))
+		case NameRSI:
+			rsi := RSI(values, params.RSIPeriod)
+			indicator = newIndicator(name, map[string]float64{"period": float64(params.RSIPeriod)}, line{"rsi", rsi})
+			indicator.Signal = rsiSignal(last(rsi))
+		case NameMACD:
+			macd, signal, histogram := MACD(values, params.MACDFast, params.MACDSlow, params.MACDSignal)
+			indicator = newIndicator(name, map[string]float64{
+				"fast":   float64(params.MACDFast),
+				"slow":   float64(params.MACDSlow),
+				"signal": float64(params.MACDSignal),
+			}, line{"macd", macd}, line{"signal", signal}, line{"histogram", histogram})
+			indicator.Signal = macdSignal(histogram)
+		case NameBollinger:
+			middle, upper, lower := Bollinger(values, params.BollingerPeriod, params.BollingerWidth)
+			indicator = newIndicator(name, map[string]float64{
+				"period": float64(params.BollingerPeriod),
+				"width":  params.BollingerWidth,
+			}, line{"middle", middle}, line{"upper", upper}, line{"lower", lower})
+			indicator.Signal = bollingerSignal(report.Close, last(upper), last(lower))
+		case NameATR:
+			indicator = newIndicator(name, map[string]float64{"period": float64(params.ATRPeriod)}, line{"atr", ATR(bars, params.ATRPeriod)})
+		case NameVWAP:
+			vwap := VWAP(bars)
+			indicator = newIndicator(name, nil, line{"vwap", vwap})
+			indicator.Signal = priceRelativeTo(report.Close, last(vwap))
+		case NameOBV:
+			indicator = newIndicator(name, nil, line{"obv", OBV(bars)})
+		default:
+			continue
+		}
+		report.Indicators = append(report.Indicators, indicator)
+	}
+	return report
+}
+
+// Summary drops the series and keeps the latest readings, which is what the
+// LLM needs to answer questions without spending tokens on every bar.
+func (r Report) Summary() Report {
+	r.Dates = nil
+	indicators := make([]Indicator, len(r.Indicators))
+	for i, indicator := range r.Indicators {
+		indicator.Lines = nil
+		indicators[i] = indicator
+	}
+	r.Indicators = indicators
+	return r
+}
+
+type line struct {
+	name   string
+	values []Value
+}
+
+func newIndicator(name Name, params map[string]float64, lines ...line) Indicator {
+	indicator := Indicator{
+		Name:   name,
+		Params: params,
+		Lines:  map[string][]Value{},
+		Latest: map[string]Value{},
+	}
+	for _, l := range lines {
+		indicator.Lines[l.name] = l.values
+		indicator.Latest[l.name] = last(l.values)
+	}
+	return indicator
+}
+
+func last(series []Value) Value {
+	if len(series) == 0 {
+		return nan()
+	}
+	return series[len(series)-1]
+}
+
+func priceRelativeTo(price, level Value) string {
+	switch {
+	case !price.Valid() || !level.Valid():
+		return ""
+	case price > level:
+		return "price_above"
+	case price < level:
+		return "price_below"
+	}
+	return "price_at"
+}
+
+func rsiSignal(rsi Value) string {
+	switch {
+	case !rsi.Valid():
+		return ""
+	case rsi >= 70:
+		return "overbought"
+	case rsi <= 30:
+		return "oversold"
+	}
+	return "neutral"
+}
+
+func macdSignal(histogram []Value) string {
+	current := last(histogram)
+	if !current.Valid() {
+		return ""
+	}
+	previous := nan()
+	if len(histogram) > 1 {
+		previous = histogram[len(histogram)-2]
+	}
+	switch {
+	case current > 0 && previous.Valid() && previous <= 0:
+		return "bullish_crossover"
+	case current < 0 && previous.Valid() && previous >= 0:
+		return "bearish_crossover"
+	case current > 0:
+		return "bullish"
+	case current < 0:
+		return "bearish"
+	}
+	return "neutral"
+}
+
+func bollingerSignal(price, upper, lower Value) string {
+	switch {
+	case !price.Valid() || !upper.Valid() || !lower.Valid():
+		return ""
+	case price > upper:
+		return "above_upper_band"
+	case price < lower:
+		return "below_lower_band"
+	}
+	return "inside_bands"
+}
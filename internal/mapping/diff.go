@@ -0,0 +1,74 @@
+package mapping
+
+import (
+	"strconv"
+	"time"
+)
+
+// Diff lists the listings added, removed or changed between two directories.
+// Price moves are not changes, only identity fields such as names and sectors.
+type Diff struct {
+	Source    string          `json:"source"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	Total     int             `json:"total"`
+	Added     []Company       `json:"added"`
+	Removed   []Company       `json:"removed"`
+	Changed   []CompanyChange `json:"changed"`
+}
+
+type CompanyChange struct {
+	TadawulID string        `json:"tadawulId"`
+	Fields    []FieldChange `json:"fields"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+func Compare(previous *Directory, next *Directory) *Diff {
+	diff := &Diff{
+		Source:    next.Source(),
+		UpdatedAt: next.UpdatedAt(),
+		Total:     len(next.Companies()),
+		Added:     []Company{},
+		Removed:   []Company{},
+		Changed:   []CompanyChange{},
+	}
+
+	for _, c := range next.Companies() {
+		old, err := previous.Lookup(c.TadawulID)
+		if err != nil {
+			diff.Added = append(diff.Added, c)
+			continue
+		}
+		if fields := identityChanges(old, c); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, CompanyChange{TadawulID: c.TadawulID, Fields: fields})
+		}
+	}
+	for _, c := range previous.Companies() {
+		if _, err := next.Lookup(c.TadawulID); err != nil {
+			diff.Removed = append(diff.Removed, c)
+		}
+	}
+	return diff
+}
+
+func identityChanges(old Company, next Company) []FieldChange {
+	var fields []FieldChange
+	compare := func(field string, a string, b string) {
+		if a != b {
+			fields = append(fields, FieldChange{Field: field, Old: a, New: b})
+		}
+	}
+	compare("companyId", strconv.Itoa(old.CompanyID), strconv.Itoa(next.CompanyID))
+	compare("marketWatchId", strconv.Itoa(old.MarketWatchID), strconv.Itoa(next.MarketWatchID))
+	compare("companyName", old.CompanyName, next.CompanyName)
+	compare("companyNameAr", old.CompanyNameAr, next.CompanyNameAr)
+	compare("acronymName", old.AcronymName, next.AcronymName)
+	compare("acronymNameAr", old.AcronymNameAr, next.AcronymNameAr)
+	compare("sector", old.Sector, next.Sector)
+	compare("sectorAr", old.SectorAr, next.SectorAr)
+	return fields
+}
@@ -0,0 +1,81 @@
+package mapping
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	SourceEmbedded    = "embedded"
+	SourceSnapshot    = "snapshot"
+	SourceMarketWatch = "market_watch"
+)
+
+// Directory is an immutable view of the listed companies. The current one is
+// swapped atomically on refresh, so readers never see a half-built directory.
+type Directory struct {
+	companies   []Company
+	byTadawulID map[string]Company
+	byCompanyID map[int]string
+	index       *SearchIndex
+	source      string
+	updatedAt   time.Time
+}
+
+var current atomic.Pointer[Directory]
+
+func NewDirectory(companies []Company, source string, updatedAt time.Time) *Directory {
+	d := &Directory{
+		companies:   companies,
+		byTadawulID: make(map[string]Company, len(companies)),
+		byCompanyID: make(map[int]string, len(companies)),
+		index:       NewSearchIndex(companies),
+		source:      source,
+		updatedAt:   updatedAt,
+	}
+	for _, c := range companies {
+		d.byTadawulID[c.TadawulID] = c
+		d.byCompanyID[c.CompanyID] = c.TadawulID
+	}
+	return d
+}
+
+// Current returns the directory in use.
+func Current() *Directory {
+	return current.Load()
+}
+
+// Replace makes d the current directory and returns the previous one.
+func Replace(d *Directory) *Directory {
+	return current.Swap(d)
+}
+
+// Companies returns the directory's companies, which must not be modified.
+func (d *Directory) Companies() []Company {
+	return d.companies
+}
+
+func (d *Directory) Source() string {
+	return d.source
+}
+
+func (d *Directory) UpdatedAt() time.Time {
+	return d.updatedAt
+}
+
+func (d *Directory) Search(query string, limit int) []Match {
+	return d.index.Search(query, limit)
+}
+
+func (d *Directory) Lookup(tadawulID string) (Company, error) {
+	c, ok := d.byTadawulID[tadawulID]
+	if !ok {
+		return Company{}, ErrCompanyNotFound
+	}
+	return c, nil
+}
+
+func (d *Directory) TadawulID(companyID int) (string, bool) {
+	tadawulID, ok := d.byCompanyID[companyID]
+	return tadawulID, ok
+}
@@ -0,0 +1,81 @@
+package mapping
+
+import (
+	"sort"
+	"strings"
+)
+
+type SortField string
+
+const (
+	SortName             SortField = "name"
+	SortTadawulID        SortField = "tadawulId"
+	SortSector           SortField = "sector"
+	SortPrice            SortField = "price"
+	SortChange           SortField = "change"
+	SortChangePercentage SortField = "changePercentage"
+	SortVolume           SortField = "volume"
+)
+
+// ListOptions filters and orders the directory. Sector matches the English or
+// Arabic sector name, names sort in the Lang language.
+type ListOptions struct {
+	Sector     string
+	Sort       SortField
+	Descending bool
+	Lang       string
+	Offset     int
+	Limit      int
+}
+
+// List returns a page of the directory and the number of companies matching
+// the filter.
+func (d *Directory) List(options ListOptions) ([]Company, int) {
+	sector := Normalize(options.Sector)
+	companies := make([]Company, 0, len(d.companies))
+	for _, c := range d.companies {
+		if sector != "" && Normalize(c.Sector) != sector && Normalize(c.SectorAr) != sector {
+			continue
+		}
+		companies = append(companies, c)
+	}
+
+	less := companyLess(options.Sort, options.Lang)
+	sort.SliceStable(companies, func(i, j int) bool {
+		if options.Descending {
+			return less(companies[j], companies[i])
+		}
+		return less(companies[i], companies[j])
+	})
+
+	total := len(companies)
+	start := min(max(options.Offset, 0), total)
+	end := total
+	if options.Limit > 0 {
+		end = min(start+options.Limit, total)
+	}
+	return companies[start:end], total
+}
+
+func companyLess(field SortField, lang string) func(a, b Company) bool {
+	switch field {
+	case SortName:
+		return func(a, b Company) bool { return strings.ToLower(a.Name(lang)) < strings.ToLower(b.Name(lang)) }
+	case SortSector:
+		return func(a, b Company) bool { return a.SectorName(lang) < b.SectorName(lang) }
+	case SortPrice:
+		return func(a, b Company) bool { return a.Price < b.Price }
+	case SortChange:
+		return func(a, b Company) bool { return a.Change < b.Change }
+	case SortChangePercentage:
+		return func(a, b Company) bool { return a.ChangePercentage < b.ChangePercentage }
+	case SortVolume:
+		return func(a, b Company) bool { return a.Volume < b.Volume }
+	}
+	return func(a, b Company) bool {
+		if len(a.TadawulID) != len(b.TadawulID) {
+			return len(a.TadawulID) < len(b.TadawulID)
+		}
+		return a.TadawulID < b.TadawulID
+	}
+}
@@ -3,27 +3,92 @@ package mapping
 import (
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 )
 
 //go:embed company_map.json
 var raw []byte
 
-var CompanyToTadawul map[int]string
+var ErrCompanyNotFound = errors.New("company not found")
 
-func init() {
-	type entry struct {
-		CompanyID int    `json:"companyId"`
-		TadawulID string `json:"tadawulId"`
+// Company is a listed company with its market snapshot at the time the
+// directory was taken.
+type Company struct {
+	CompanyID        int     `json:"companyId"`
+	TadawulID        string  `json:"tadawulId"`
+	MarketWatchID    int     `json:"marketWatchId"`
+	CompanyName      string  `json:"companyName"`
+	CompanyNameAr    string  `json:"companyNameAr"`
+	AcronymName      string  `json:"acronymName"`
+	AcronymNameAr    string  `json:"acronymNameAr"`
+	Sector           string  `json:"sector"`
+	SectorAr         string  `json:"sectorAr"`
+	Price            float64 `json:"price"`
+	Change           float64 `json:"change"`
+	ChangePercentage float64 `json:"changePercentage"`
+	OpenPrice        float64 `json:"openPrice"`
+	HighPrice        float64 `json:"highPrice"`
+	LowPrice         float64 `json:"lowPrice"`
+	Highest52Price   float64 `json:"highest52Price"`
+	Lowest52Price    float64 `json:"lowest52Price"`
+	Volume           int     `json:"volume"`
+	NumberOfTrades   int     `json:"numberOfTrades"`
+	BestBidPrice     float64 `json:"bestBidPrice"`
+	BestBidAmount    int     `json:"bestBidAmount"`
+	BestAskPrice     float64 `json:"bestAskPrice"`
+	BestAskAmount    int     `json:"bestAskAmount"`
+}
+
+func (c Company) Name(lang string) string {
+	return localized(lang, c.CompanyName, c.CompanyNameAr)
+}
+
+func (c Company) Acronym(lang string) string {
+	return localized(lang, c.AcronymName, c.AcronymNameAr)
+}
+
+func (c Company) SectorName(lang string) string {
+	return localized(lang, c.Sector, c.SectorAr)
+}
+
+func localized(lang string, en string, ar string) string {
+	if lang == "ar" && ar != "" {
+		return ar
 	}
+	return en
+}
+
+var embedded *Directory
 
-	var all []entry
-	if err := json.Unmarshal(raw, &all); err != nil {
+func init() {
+	var companies []Company
+	if err := json.Unmarshal(raw, &companies); err != nil {
 		panic(fmt.Errorf("failed to unmarshal company_map.json: %w", err))
 	}
+	embedded = NewDirectory(companies, SourceEmbedded, time.Time{})
+	current.Store(embedded)
+}
 
-	CompanyToTadawul = make(map[int]string, len(all))
-	for _, e := range all {
-		CompanyToTadawul[e.CompanyID] = e.TadawulID
-	}
+// Embedded is the directory compiled into the binary.
+func Embedded() *Directory {
+	return embedded
+}
+
+// Search looks query up in the current company directory.
+func Search(query string, limit int) []Match {
+	return Current().Search(query, limit)
+}
+
+func Lookup(tadawulID string) (Company, error) {
+	return Current().Lookup(tadawulID)
+}
+
+func TadawulID(companyID int) (string, bool) {
+	return Current().TadawulID(companyID)
+}
+
+func List(options ListOptions) ([]Company, int) {
+	return Current().List(options)
 }
@@ -0,0 +1,120 @@
+package mapping
+
+import (
+	"strings"
+	"unicode"
+)
+
+var arabicLetters = strings.NewReplacer(
+	"أ", "ا",
+	"إ", "ا",
+	"آ", "ا",
+	"ٱ", "ا",
+	"ة", "ه",
+	"ى", "ي",
+	"ؤ", "و",
+	"ئ", "ي",
+	"ـ", "",
+)
+
+// stopWords carry no meaning when matching company names.
+var stopWords = map[string]bool{
+	"al":      true,
+	"co":      true,
+	"company": true,
+	"corp":    true,
+	"inc":     true,
+	"ltd":     true,
+	"the":     true,
+	"and":     true,
+	"for":     true,
+	"of":      true,
+	"شركه":    true,
+}
+
+// Normalize folds a company name for matching: lower case, Arabic alef,
+// hamza, taa marbuta and alef maqsura variants unified, diacritics and
+// tatweel removed and punctuation turned into spaces.
+func Normalize(value string) string {
+	value = arabicLetters.Replace(strings.ToLower(value))
+
+	var b strings.Builder
+	space := false
+	for _, r := range value {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Arabic harakat and other combining marks
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// tokens splits a normalized value into words, dropping stop words.
+func tokens(normalized string) []string {
+	words := strings.Fields(normalized)
+	result := make([]string, 0, len(words))
+	for _, w := range words {
+		if !stopWords[w] {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// searchWords are the tokens of a normalized value without their articles.
+func searchWords(normalized string) []string {
+	words := tokens(normalized)
+	for i, w := range words {
+		words[i] = withoutArticle(w)
+	}
+	return words
+}
+
+// withoutArticle strips a leading Arabic or Latin definite article, so
+// "الراجحي", "alrajhi" and "rajhi" all meet at "rajhi" or "راجحي".
+func withoutArticle(word string) string {
+	runes := []rune(word)
+	if len(runes) > 4 && (strings.HasPrefix(word, "al") || strings.HasPrefix(word, "ال")) {
+		return string(runes[2:])
+	}
+	return word
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// similarity is one minus the edit distance relative to the longer word.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
@@ -0,0 +1,274 @@
+package mapping
+
+import (
+	"context"
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// RemoveAfterMisses is how many full feeds in a row a company must be
+	// missing from before it is removed as delisted.
+	RemoveAfterMisses = 3
+	// fullFeedRatio is the share of the directory a feed must list to count
+	// as full, shorter ones may be truncated and never remove companies.
+	fullFeedRatio = 0.9
+)
+
+type MarketWatchSource interface {
+	GetMarketWatch() ([]stock.MarketWatchResponse, error)
+}
+
+// Refresher keeps the current directory in line with the market watch feed
+// and persists every refresh so restarts pick up new listings without a
+// redeploy.
+type Refresher struct {
+	source MarketWatchSource
+	store  SnapshotStore
+	mu     sync.Mutex
+	// missing counts the full feeds in a row each company was missing from,
+	// removed has the companies dropped so the embedded directory doesn't
+	// bring them back on restore.
+	missing map[string]int
+	removed map[string]bool
+}
+
+// NewRefresher builds a refresher, store may be nil to skip snapshots.
+func NewRefresher(source MarketWatchSource, store SnapshotStore) *Refresher {
+	return &Refresher{source: source, store: store, missing: map[string]int{}, removed: map[string]bool{}}
+}
+
+// Restore merges the last saved snapshot over the embedded directory, less
+// the companies it removed. The embedded directory stays in use when there is
+// no snapshot.
+func (r *Refresher) Restore(ctx context.Context) error {
+	if r.store == nil {
+		return nil
+	}
+	snapshot, err := r.store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("mapping :: Refresher.Restore :: %w", err)
+	}
+	if snapshot == nil || len(snapshot.Companies) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, misses := range snapshot.Missing {
+		r.missing[id] = misses
+	}
+	for _, id := range snapshot.Removed {
+		r.removed[id] = true
+	}
+	var base []Company
+	for _, c := range Embedded().Companies() {
+		if !r.removed[c.TadawulID] {
+			base = append(base, c)
+		}
+	}
+	Replace(NewDirectory(Merge(base, snapshot.Companies), SourceSnapshot, snapshot.UpdatedAt))
+	return nil
+}
+
+// Refresh pulls the market watch, merges it into the current directory and
+// swaps it in, returning what changed. Companies missing from
+// RemoveAfterMisses full feeds in a row are removed. A failure to save the
+// snapshot is logged and does not undo the refresh.
+func (r *Refresher) Refresh(ctx context.Context) (*Diff, error) {
+	marketWatch, err := r.source.GetMarketWatch()
+	if err != nil {
+		return nil, fmt.Errorf("mapping :: Refresher.Refresh :: error fetching market watch: %w", err)
+	}
+	updates := make([]Company, 0, len(marketWatch))
+	for _, m := range marketWatch {
+		if m.TadawulID != "" {
+			updates = append(updates, companyFromMarketWatch(m))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := Current()
+	companies := Merge(previous.Companies(), updates)
+	for _, update := range updates {
+		delete(r.missing, update.TadawulID)
+		delete(r.removed, update.TadawulID)
+	}
+	if float64(len(updates)) >= fullFeedRatio*float64(len(previous.Companies())) {
+		companies = r.dropMissing(companies, updates)
+	}
+	next := NewDirectory(companies, SourceMarketWatch, time.Now().UTC())
+	Replace(next)
+	diff := Compare(previous, next)
+
+	if r.store != nil {
+		snapshot := Snapshot{Companies: next.Companies(), UpdatedAt: next.UpdatedAt(), Missing: r.missing, Removed: r.removedIDs()}
+		if err := r.store.Save(ctx, snapshot); err != nil {
+			log.Error().Msg("mapping :: Refresher.Refresh :: error saving snapshot: " + err.Error())
+		}
+	}
+	return diff, nil
+}
+
+// Run refreshes right away and then every interval until ctx is done.
+func (r *Refresher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		diff, err := r.Refresh(ctx)
+		if err != nil {
+			log.Error().Msg(err.Error())
+		} else if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+			log.Info().Msgf("mapping :: Refresher.Run :: directory updated: %d added, %d removed, %d changed",
+				len(diff.Added), len(diff.Removed), len(diff.Changed))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dropMissing counts a miss for every company the full feed of updates left
+// out and drops those missing from RemoveAfterMisses feeds in a row.
+func (r *Refresher) dropMissing(companies []Company, updates []Company) []Company {
+	listed := make(map[string]bool, len(updates))
+	for _, update := range updates {
+		listed[update.TadawulID] = true
+	}
+
+	kept := companies[:0]
+	for _, c := range companies {
+		if listed[c.TadawulID] {
+			kept = append(kept, c)
+			continue
+		}
+		r.missing[c.TadawulID]++
+		if r.missing[c.TadawulID] < RemoveAfterMisses {
+			kept = append(kept, c)
+			continue
+		}
+		delete(r.missing, c.TadawulID)
+		r.removed[c.TadawulID] = true
+	}
+	return kept
+}
+
+func (r *Refresher) removedIDs() []string {
+	ids := make([]string, 0, len(r.removed))
+	for id := range r.removed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Merge overlays updates on base by tadawul id. Companies missing from the
+// updates are kept, since the feed may be truncated, and blank fields in an
+// update keep their base value.
+func Merge(base []Company, updates []Company) []Company {
+	merged := make([]Company, len(base), len(base)+len(updates))
+	copy(merged, base)
+	positions := make(map[string]int, len(base))
+	for i, c := range merged {
+		positions[c.TadawulID] = i
+	}
+
+	for _, update := range updates {
+		i, ok := positions[update.TadawulID]
+		if !ok {
+			positions[update.TadawulID] = len(merged)
+			merged = append(merged, update)
+			continue
+		}
+		merged[i] = mergeCompany(merged[i], update)
+	}
+	return merged
+}
+
+func mergeCompany(base Company, update Company) Company {
+	keep := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	keep(&update.CompanyName, base.CompanyName)
+	keep(&update.CompanyNameAr, base.CompanyNameAr)
+	keep(&update.AcronymName, base.AcronymName)
+	keep(&update.AcronymNameAr, base.AcronymNameAr)
+	keep(&update.Sector, base.Sector)
+	keep(&update.SectorAr, base.SectorAr)
+	if update.CompanyID == 0 {
+		update.CompanyID = base.CompanyID
+	}
+	if update.MarketWatchID == 0 {
+		update.MarketWatchID = base.MarketWatchID
+	}
+	return update
+}
+
+func companyFromMarketWatch(m stock.MarketWatchResponse) Company {
+	return Company{
+		CompanyID:        m.CompanyID,
+		TadawulID:        m.TadawulID,
+		MarketWatchID:    m.MarketWatchID,
+		CompanyName:      m.CompanyName,
+		CompanyNameAr:    m.CompanyNameAr,
+		AcronymName:      m.AcronymName,
+		AcronymNameAr:    m.AcronymNameAr,
+		Sector:           m.Sector,
+		SectorAr:         m.SectorAr,
+		Price:            m.Price,
+		Change:           m.Change,
+		ChangePercentage: m.ChangePercentage,
+		OpenPrice:        m.OpenPrice,
+		HighPrice:        m.HighPrice,
+		LowPrice:         m.LowPrice,
+		Highest52Price:   m.Highest52Price,
+		Lowest52Price:    m.Lowest52Price,
+		Volume:           m.Volume,
+		NumberOfTrades:   m.NumberOfTrades,
+		BestBidPrice:     m.BestBidPrice,
+		BestBidAmount:    m.BestBidAmount,
+		BestAskPrice:     m.BestAskPrice,
+		BestAskAmount:    m.BestAskAmount,
+	}
+}
+
+// MarketWatch returns the company's directory snapshot as a market watch row,
+// for pricing companies the live feed is missing.
+func (c Company) MarketWatch() stock.MarketWatchResponse {
+	return stock.MarketWatchResponse{
+		MarketWatchID:    c.MarketWatchID,
+		CompanyID:        c.CompanyID,
+		TadawulID:        c.TadawulID,
+		CompanyName:      c.CompanyName,
+		CompanyNameAr:    c.CompanyNameAr,
+		AcronymName:      c.AcronymName,
+		AcronymNameAr:    c.AcronymNameAr,
+		Sector:           c.Sector,
+		SectorAr:         c.SectorAr,
+		Price:            c.Price,
+		Change:           c.Change,
+		ChangePercentage: c.ChangePercentage,
+		OpenPrice:        c.OpenPrice,
+		HighPrice:        c.HighPrice,
+		LowPrice:         c.LowPrice,
+		Highest52Price:   c.Highest52Price,
+		Lowest52Price:    c.Lowest52Price,
+		Volume:           c.Volume,
+		NumberOfTrades:   c.NumberOfTrades,
+		BestBidPrice:     c.BestBidPrice,
+		BestBidAmount:    c.BestBidAmount,
+		BestAskPrice:     c.BestAskPrice,
+		BestAskAmount:    c.BestAskAmount,
+	}
+}
@@ -0,0 +1,128 @@
+package mapping
+
+import (
+	"context"
+	"path/filepath"
+	"patient-chatbot/internal/client/stock"
+	"testing"
+)
+
+type feed struct {
+	rows []stock.MarketWatchResponse
+}
+
+func (f *feed) GetMarketWatch() ([]stock.MarketWatchResponse, error) {
+	return f.rows, nil
+}
+
+// listing returns the market watch rows of the embedded directory less the
+// skipped companies, or of its first half only when partial.
+func listing(partial bool, skipped ...string) []stock.MarketWatchResponse {
+	skip := map[string]bool{}
+	for _, id := range skipped {
+		skip[id] = true
+	}
+	companies := Embedded().Companies()
+	if partial {
+		companies = companies[:len(companies)/2]
+	}
+	var rows []stock.MarketWatchResponse
+	for _, c := range companies {
+		if !skip[c.TadawulID] {
+			rows = append(rows, c.MarketWatch())
+		}
+	}
+	return rows
+}
+
+func withEmbedded(t *testing.T) {
+	t.Helper()
+	previous := Replace(Embedded())
+	t.Cleanup(func() { Replace(previous) })
+}
+
+func TestRefreshRemovesAfterMissedFullFeeds(t *testing.T) {
+	tests := []struct {
+		name  string
+		feeds [][]stock.MarketWatchResponse
+		// wantListed is whether 1010 is still listed after each feed
+		wantListed []bool
+	}{
+		{
+			name:       "three full feeds in a row",
+			feeds:      [][]stock.MarketWatchResponse{listing(false, "1010"), listing(false, "1010"), listing(false, "1010")},
+			wantListed: []bool{true, true, false},
+		},
+		{
+			name:       "partial feeds are not misses",
+			feeds:      [][]stock.MarketWatchResponse{listing(false, "1010"), listing(true, "1010"), listing(false, "1010"), listing(true, "1010"), listing(false, "1010")},
+			wantListed: []bool{true, true, true, true, false},
+		},
+		{
+			name:       "reappearing resets the count",
+			feeds:      [][]stock.MarketWatchResponse{listing(false, "1010"), listing(false, "1010"), listing(false), listing(false, "1010"), listing(false, "1010"), listing(false, "1010")},
+			wantListed: []bool{true, true, true, true, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEmbedded(t)
+			source := &feed{}
+			refresher := NewRefresher(source, nil)
+			for i, rows := range tt.feeds {
+				source.rows = rows
+				diff, err := refresher.Refresh(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = Lookup("1010")
+				if listed := err == nil; listed != tt.wantListed[i] {
+					t.Fatalf("after feed %d 1010 listed = %v, want %v", i+1, listed, tt.wantListed[i])
+				}
+				if removed := len(diff.Removed) == 1; removed != (i > 0 && tt.wantListed[i-1] && !tt.wantListed[i]) {
+					t.Errorf("feed %d removed %d companies", i+1, len(diff.Removed))
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreKeepsRemovalState(t *testing.T) {
+	withEmbedded(t)
+	store := NewFileSnapshotStore(filepath.Join(t.TempDir(), "directory.json"))
+	source := &feed{}
+	refresher := NewRefresher(source, store)
+	for _, rows := range [][]stock.MarketWatchResponse{listing(false, "1020", "1010"), listing(false, "1010")} {
+		source.rows = rows
+		if _, err := refresher.Refresh(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored := NewRefresher(source, store)
+	if err := restored.Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restored.missing["1020"]; ok || restored.missing["1010"] != 2 {
+		t.Errorf("restored misses = %v, want only 1010 at 2", restored.missing)
+	}
+
+	if _, err := restored.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lookup("1010"); err == nil {
+		t.Error("1010 is still listed after its third miss")
+	}
+	if !restored.removed["1010"] {
+		t.Errorf("removed = %v, want 1010", restored.removedIDs())
+	}
+
+	// @NOTE: Restoring again must not bring 1010 back from the embedded directory
+	if err := NewRefresher(source, store).Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lookup("1010"); err == nil {
+		t.Error("1010 is listed again after a restore")
+	}
+}
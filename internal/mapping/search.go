@@ -0,0 +1,169 @@
+package mapping
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	minMatchScore       = 45
+	minFuzzySimilarity  = 0.75
+	confidentMatchScore = 65
+	confidentMatchGap   = 10
+)
+
+type Match struct {
+	Company Company `json:"company"`
+	Score   int     `json:"score"`
+}
+
+type searchField struct {
+	words   []string
+	compact string
+	// exact is the score for matching the whole field, acronyms rank above
+	// full names since users mostly type those.
+	exact int
+	bonus float64
+}
+
+type indexEntry struct {
+	company  Company
+	fields   []searchField
+	initials string
+}
+
+// SearchIndex matches free text against the company directory by tadawul id,
+// acronym, initials and English or Arabic name, tolerating typos.
+type SearchIndex struct {
+	entries []indexEntry
+}
+
+func NewSearchIndex(companies []Company) *SearchIndex {
+	index := &SearchIndex{entries: make([]indexEntry, 0, len(companies))}
+	for _, c := range companies {
+		entry := indexEntry{
+			company: c,
+			fields: []searchField{
+				newSearchField(c.AcronymName, 95, 5),
+				newSearchField(c.AcronymNameAr, 95, 5),
+				newSearchField(c.CompanyName, 90, 0),
+				newSearchField(c.CompanyNameAr, 90, 0),
+			},
+		}
+		for _, w := range strings.Fields(Normalize(c.CompanyName)) {
+			entry.initials += string([]rune(w)[0])
+		}
+		index.entries = append(index.entries, entry)
+	}
+	return index
+}
+
+func newSearchField(value string, exact int, bonus float64) searchField {
+	words := searchWords(Normalize(value))
+	return searchField{words: words, compact: strings.Join(words, ""), exact: exact, bonus: bonus}
+}
+
+// Search returns up to limit companies matching query, best first.
+func (i *SearchIndex) Search(query string, limit int) []Match {
+	normalized := Normalize(query)
+	words := searchWords(normalized)
+	compact := strings.Join(words, "")
+	if compact == "" {
+		return nil
+	}
+
+	var matches []Match
+	for _, entry := range i.entries {
+		if score := entry.score(normalized, words, compact); score >= minMatchScore {
+			matches = append(matches, Match{Company: entry.company, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].Company.TadawulID < matches[b].Company.TadawulID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func (e indexEntry) score(normalized string, words []string, compact string) int {
+	if normalized == e.company.TadawulID {
+		return 100
+	}
+
+	best := 0.0
+	if len([]rune(compact)) >= 3 && compact == e.initials {
+		best = 85
+	}
+	for _, f := range e.fields {
+		best = max(best, f.score(words, compact))
+	}
+	return int(best)
+}
+
+func (f searchField) score(words []string, compact string) float64 {
+	if f.compact == "" {
+		return 0
+	}
+	if compact == f.compact {
+		return float64(f.exact)
+	}
+	if len([]rune(compact)) >= 3 && 2*len(compact) >= len(f.compact) && strings.HasPrefix(f.compact, compact) {
+		return float64(f.exact - 15)
+	}
+
+	matched := map[int]bool{}
+	var total float64
+	for _, q := range words {
+		bestSimilarity, bestWord := 0.0, -1
+		for n, w := range f.words {
+			s := wordSimilarity(q, w)
+			if s > bestSimilarity {
+				bestSimilarity, bestWord = s, n
+			}
+		}
+		if bestWord < 0 {
+			return 0
+		}
+		matched[bestWord] = true
+		total += bestSimilarity
+	}
+
+	coverage := float64(len(matched)) / float64(len(f.words))
+	return (50+30*coverage)*total/float64(len(words)) + f.bonus
+}
+
+// wordSimilarity scores a query word against a name word, zero when they are
+// too far apart to be the same word.
+func wordSimilarity(query, word string) float64 {
+	if query == word {
+		return 1
+	}
+	if len([]rune(query)) >= 3 && strings.HasPrefix(word, query) {
+		return 0.9
+	}
+	if len([]rune(query)) < 4 {
+		return 0
+	}
+	if s := similarity(query, word); s >= minFuzzySimilarity {
+		return s
+	}
+	return 0
+}
+
+// Disambiguate keeps only the best match when it clearly stands out, otherwise
+// it returns every match so the user can be asked which company they meant.
+func Disambiguate(matches []Match) []Match {
+	if len(matches) <= 1 {
+		return matches
+	}
+	if matches[0].Score >= confidentMatchScore && matches[0].Score-matches[1].Score >= confidentMatchGap {
+		return matches[:1]
+	}
+	return matches
+}
@@ -0,0 +1,120 @@
+package mapping
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "alef with hamza above", value: "أرامكو", want: "ارامكو"},
+		{name: "alef with hamza below", value: "الإنماء", want: "الانماء"},
+		{name: "alef with madda", value: "آل", want: "ال"},
+		{name: "hamza on waw and yaa", value: "مؤسسة هيئة", want: "موسسه هييه"},
+		{name: "ta marbuta and alef maqsura", value: "مدينة مستشفى", want: "مدينه مستشفي"},
+		{name: "diacritics and tatweel", value: "إِعْمَـار الاقتصاديّة", want: "اعمار الاقتصاديه"},
+		{name: "latin case and punctuation", value: "Al-Rajhi  Bank.", want: "al rajhi bank"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.value); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchWords(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "الراجحي", want: "راجحي"},
+		{value: "alrajhi", want: "rajhi"},
+		{value: "Al Rajhi Company", want: "rajhi"},
+		{value: "شركة الزيت", want: "زيت"},
+		// @NOTE: Short words keep their leading al, it is part of the name
+		{value: "البر", want: "البر"},
+		{value: "alba", want: "alba"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := strings.Join(searchWords(Normalize(tt.value)), " "); got != tt.want {
+				t.Errorf("searchWords(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "2222", want: "2222"},
+		{query: "aramco", want: "2222"},
+		{query: "أرامكو", want: "2222"},
+		{query: "ارامكو", want: "2222"},
+		{query: "rajhi", want: "1120"},
+		{query: "alrajhi", want: "1120"},
+		{query: "الراجحي", want: "1120"},
+		{query: "sabic", want: "2010"},
+		{query: "saudi arabian oil", want: "2222"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			matches := Search(tt.query, 5)
+			if len(matches) == 0 || matches[0].Company.TadawulID != tt.want {
+				t.Fatalf("Search(%q) = %v, want %s first", tt.query, ids(matches), tt.want)
+			}
+			if len(matches) > 1 && matches[0].Score <= matches[1].Score {
+				t.Errorf("Search(%q) scores %d and %d, want %s to stand out", tt.query, matches[0].Score, matches[1].Score, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchTiesAreOrderedByID(t *testing.T) {
+	matches := Search("bank", 5)
+	if got := strings.Join(ids(matches), ","); got != "1010,1020,1120,1140,1150" {
+		t.Errorf("Search(bank) = %s, want 1010,1020,1120,1140,1150", got)
+	}
+	for _, m := range matches[1:] {
+		if m.Score != matches[0].Score {
+			t.Errorf("score of %s = %d, want the tie at %d", m.Company.TadawulID, m.Score, matches[0].Score)
+		}
+	}
+	if got := Disambiguate(matches); len(got) != len(matches) {
+		t.Errorf("Disambiguate kept %d of %d tied matches", len(got), len(matches))
+	}
+}
+
+func TestSearchWithoutMatch(t *testing.T) {
+	for _, query := range []string{"", "  ", "شركة", "zzzzzz"} {
+		if matches := Search(query, 5); len(matches) != 0 {
+			t.Errorf("Search(%q) = %v, want no match", query, ids(matches))
+		}
+	}
+}
+
+func TestDisambiguate(t *testing.T) {
+	matches := Search("rajhi", 0)
+	if got := Disambiguate(matches); len(got) != 1 || got[0].Company.TadawulID != "1120" {
+		t.Errorf("Disambiguate(rajhi) = %v, want only 1120", ids(got))
+	}
+}
+
+func ids(matches []Match) []string {
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.Company.TadawulID
+	}
+	return result
+}
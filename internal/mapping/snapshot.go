@@ -0,0 +1,128 @@
+package mapping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Snapshot is a refreshed directory. Missing counts the full feeds in a row a
+// company was missing from and Removed lists the companies dropped as
+// delisted.
+type Snapshot struct {
+	Companies []Company      `json:"companies"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	Missing   map[string]int `json:"missing,omitempty"`
+	Removed   []string       `json:"removed,omitempty"`
+}
+
+// SnapshotStore persists the refreshed directory. Load returns nil without an
+// error when nothing has been saved yet.
+type SnapshotStore interface {
+	Load(ctx context.Context) (*Snapshot, error)
+	Save(ctx context.Context, snapshot Snapshot) error
+}
+
+// FileSnapshotStore keeps the snapshot as a JSON file, for single instance
+// deployments without a database.
+type FileSnapshotStore struct {
+	path string
+}
+
+func NewFileSnapshotStore(path string) *FileSnapshotStore {
+	return &FileSnapshotStore{path: path}
+}
+
+func (s *FileSnapshotStore) Load(ctx context.Context) (*Snapshot, error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mapping :: FileSnapshotStore.Load :: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, fmt.Errorf("mapping :: FileSnapshotStore.Load :: error unmarshalling snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Save writes to a temporary file first so a crash never leaves a truncated
+// snapshot behind.
+func (s *FileSnapshotStore) Save(ctx context.Context, snapshot Snapshot) error {
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("mapping :: FileSnapshotStore.Save :: error marshalling snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("mapping :: FileSnapshotStore.Save :: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("mapping :: FileSnapshotStore.Save :: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("mapping :: FileSnapshotStore.Save :: %w", err)
+	}
+	return nil
+}
+
+// PostgresSnapshotStore keeps a single snapshot row shared by every instance.
+type PostgresSnapshotStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresSnapshotStore(pool *pgxpool.Pool) *PostgresSnapshotStore {
+	return &PostgresSnapshotStore{pool: pool}
+}
+
+func (s *PostgresSnapshotStore) Load(ctx context.Context) (*Snapshot, error) {
+	var snapshot Snapshot
+	err := s.pool.QueryRow(ctx, `
+		SELECT companies, updated_at, missing, removed FROM company_directory WHERE id = 1`,
+	).Scan(&snapshot.Companies, &snapshot.UpdatedAt, &snapshot.Missing, &snapshot.Removed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mapping :: PostgresSnapshotStore.Load :: %w", err)
+	}
+	return &snapshot, nil
+}
+
+func (s *PostgresSnapshotStore) Save(ctx context.Context, snapshot Snapshot) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO company_directory (id, companies, updated_at, missing, removed) VALUES (1, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			companies = EXCLUDED.companies, updated_at = EXCLUDED.updated_at,
+			missing = EXCLUDED.missing, removed = EXCLUDED.removed`,
+		snapshot.Companies, snapshot.UpdatedAt, missingOrEmpty(snapshot.Missing), removedOrEmpty(snapshot.Removed),
+	)
+	if err != nil {
+		return fmt.Errorf("mapping :: PostgresSnapshotStore.Save :: %w", err)
+	}
+	return nil
+}
+
+// @NOTE: nil would be encoded as JSON null, the columns hold an empty object and array instead
+func missingOrEmpty(missing map[string]int) map[string]int {
+	if missing == nil {
+		return map[string]int{}
+	}
+	return missing
+}
+
+func removedOrEmpty(removed []string) []string {
+	if removed == nil {
+		return []string{}
+	}
+	return removed
+}
@@ -0,0 +1,85 @@
+package market
+
+import (
+	"patient-chatbot/internal/client/stock"
+	"sort"
+	"time"
+)
+
+// Dashboard is the market overview shown on the home screen.
+type Dashboard struct {
+	Gainers            []stock.TopFiveGainersOrLosersResponse `json:"gainers"`
+	Losers             []stock.TopFiveGainersOrLosersResponse `json:"losers"`
+	MostActiveByVolume []Mover                                `json:"mostActiveByVolume"`
+	MostActiveByValue  []Mover                                `json:"mostActiveByValue"`
+	Breadth            Breadth                                `json:"breadth"`
+	FiftyTwoWeekHighs  []Mover                                `json:"fiftyTwoWeekHighs"`
+	FiftyTwoWeekLows   []Mover                                `json:"fiftyTwoWeekLows"`
+	Timestamps         DashboardTimestamps                    `json:"timestamps"`
+}
+
+// DashboardTimestamps records when each upstream source was fetched, so the
+// client can tell how fresh every part of the dashboard is.
+type DashboardTimestamps struct {
+	Gainers     time.Time `json:"gainers"`
+	Losers      time.Time `json:"losers"`
+	MarketWatch time.Time `json:"marketWatch"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// NewDashboard derives the most active lists, breadth and 52 week extremes
+// from the market watch, keeping up to top companies in each list.
+func NewDashboard(
+	gainers []stock.TopFiveGainersOrLosersResponse,
+	losers []stock.TopFiveGainersOrLosersResponse,
+	quotes []stock.MarketWatchResponse,
+	top int,
+) Dashboard {
+	dashboard := Dashboard{
+		Gainers:           nonNil(gainers),
+		Losers:            nonNil(losers),
+		FiftyTwoWeekHighs: []Mover{},
+		FiftyTwoWeekLows:  []Mover{},
+	}
+	for _, q := range quotes {
+		dashboard.Breadth.add(q)
+		// @NOTE: The feed reports zero for the 52 week range of new listings
+		if q.Highest52Price > 0 && q.HighPrice >= q.Highest52Price {
+			dashboard.FiftyTwoWeekHighs = append(dashboard.FiftyTwoWeekHighs, NewMover(q))
+		}
+		if q.Lowest52Price > 0 && q.LowPrice > 0 && q.LowPrice <= q.Lowest52Price {
+			dashboard.FiftyTwoWeekLows = append(dashboard.FiftyTwoWeekLows, NewMover(q))
+		}
+	}
+	dashboard.MostActiveByVolume = mostActive(quotes, top, func(q stock.MarketWatchResponse) float64 {
+		return float64(q.Volume)
+	})
+	dashboard.MostActiveByValue = mostActive(quotes, top, TradedValue)
+	return dashboard
+}
+
+// mostActive returns up to n traded companies with the highest measure.
+func mostActive(quotes []stock.MarketWatchResponse, n int, measure func(stock.MarketWatchResponse) float64) []Mover {
+	candidates := make([]stock.MarketWatchResponse, 0, len(quotes))
+	for _, q := range quotes {
+		if q.Volume > 0 {
+			candidates = append(candidates, q)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return measure(candidates[i]) > measure(candidates[j])
+	})
+
+	movers := make([]Mover, 0, min(n, len(candidates)))
+	for _, q := range candidates[:min(n, len(candidates))] {
+		movers = append(movers, NewMover(q))
+	}
+	return movers
+}
+
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
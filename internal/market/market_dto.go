@@ -0,0 +1,9 @@
+package market
+
+const FunctionGetSectorPerformance = "GetSectorPerformance"
+
+type GetSectorPerformanceArguments struct {
+	Sector string `json:"sector,omitempty" description:"Optional sector to focus on, in English or Arabic, e.g. Banks or البنوك. Leave empty to compare all sectors"`
+	Sort   string `json:"sort,omitempty" enum:"weightedChange,averageChange,tradedValue" description:"How to rank the sectors. Defaults to weightedChange, the traded value weighted change"`
+	Top    int    `json:"top,omitempty" description:"How many top gainers and losers to list per sector, up to 5. Defaults to 3"`
+}
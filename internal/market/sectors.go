@@ -0,0 +1,203 @@
+package market
+
+import (
+	"math"
+	"patient-chatbot/internal/client/stock"
+	"sort"
+)
+
+// Mover is a company's move in the latest session.
+type Mover struct {
+	TadawulID        string  `json:"tadawulId"`
+	CompanyName      string  `json:"companyName"`
+	CompanyNameAr    string  `json:"companyNameAr"`
+	AcronymName      string  `json:"acronymName"`
+	AcronymNameAr    string  `json:"acronymNameAr"`
+	Sector           string  `json:"sector"`
+	SectorAr         string  `json:"sectorAr"`
+	Price            float64 `json:"price"`
+	Change           float64 `json:"change"`
+	ChangePercentage float64 `json:"changePercentage"`
+	Volume           int     `json:"volume"`
+	TradedValue      float64 `json:"tradedValue"`
+}
+
+func NewMover(q stock.MarketWatchResponse) Mover {
+	return Mover{
+		TadawulID:        q.TadawulID,
+		CompanyName:      q.CompanyName,
+		CompanyNameAr:    q.CompanyNameAr,
+		AcronymName:      q.AcronymName,
+		AcronymNameAr:    q.AcronymNameAr,
+		Sector:           q.Sector,
+		SectorAr:         q.SectorAr,
+		Price:            q.Price,
+		Change:           q.Change,
+		ChangePercentage: q.ChangePercentage,
+		Volume:           q.Volume,
+		TradedValue:      round2(TradedValue(q)),
+	}
+}
+
+// TradedValue estimates the session turnover as price times volume, the
+// feed does not carry the actual value traded.
+func TradedValue(q stock.MarketWatchResponse) float64 {
+	return q.Price * float64(q.Volume)
+}
+
+// Breadth counts the companies that rose, fell or did not move.
+type Breadth struct {
+	Advancers int `json:"advancers"`
+	Decliners int `json:"decliners"`
+	Unchanged int `json:"unchanged"`
+}
+
+func NewBreadth(quotes []stock.MarketWatchResponse) Breadth {
+	var b Breadth
+	for _, q := range quotes {
+		b.add(q)
+	}
+	return b
+}
+
+func (b *Breadth) add(q stock.MarketWatchResponse) {
+	switch {
+	case q.Change > 0:
+		b.Advancers++
+	case q.Change < 0:
+		b.Decliners++
+	default:
+		b.Unchanged++
+	}
+}
+
+type SectorSummary struct {
+	Sector   string `json:"sector"`
+	SectorAr string `json:"sectorAr"`
+	Breadth
+	Companies int `json:"companies"`
+	// AverageChangePercentage weighs every company equally while
+	// WeightedChangePercentage weighs them by traded value, so a handful of
+	// heavily traded names can outweigh many quiet ones.
+	AverageChangePercentage  float64 `json:"averageChangePercentage"`
+	WeightedChangePercentage float64 `json:"weightedChangePercentage"`
+	Volume                   int     `json:"volume"`
+	TradedValue              float64 `json:"tradedValue"`
+	TopGainers               []Mover `json:"topGainers"`
+	TopLosers                []Mover `json:"topLosers"`
+}
+
+type SectorSort string
+
+const (
+	SortWeightedChange SectorSort = "weightedChange"
+	SortAverageChange  SectorSort = "averageChange"
+	SortTradedValue    SectorSort = "tradedValue"
+	SortSectorName     SectorSort = "name"
+)
+
+// AggregateSectors groups the quotes by sector, keeping the top movers of
+// each. Sectors come out ordered by sortBy, best performing first.
+func AggregateSectors(quotes []stock.MarketWatchResponse, top int, sortBy SectorSort) []SectorSummary {
+	bySector := map[string][]stock.MarketWatchResponse{}
+	for _, q := range quotes {
+		bySector[q.Sector] = append(bySector[q.Sector], q)
+	}
+
+	sectors := make([]SectorSummary, 0, len(bySector))
+	for sector, members := range bySector {
+		summary := SectorSummary{Sector: sector, Companies: len(members)}
+		var changeSum, weightedSum float64
+		for _, q := range members {
+			if summary.SectorAr == "" {
+				summary.SectorAr = q.SectorAr
+			}
+			summary.add(q)
+			value := TradedValue(q)
+			changeSum += q.ChangePercentage
+			weightedSum += q.ChangePercentage * value
+			summary.Volume += q.Volume
+			summary.TradedValue += value
+		}
+		summary.AverageChangePercentage = round2(changeSum / float64(len(members)))
+		summary.WeightedChangePercentage = summary.AverageChangePercentage
+		if summary.TradedValue > 0 {
+			summary.WeightedChangePercentage = round2(weightedSum / summary.TradedValue)
+		}
+		summary.TradedValue = round2(summary.TradedValue)
+		summary.TopGainers = topMovers(members, top, true)
+		summary.TopLosers = topMovers(members, top, false)
+		sectors = append(sectors, summary)
+	}
+
+	sort.SliceStable(sectors, func(i, j int) bool {
+		a, b := sectors[i], sectors[j]
+		switch sortBy {
+		case SortAverageChange:
+			if a.AverageChangePercentage != b.AverageChangePercentage {
+				return a.AverageChangePercentage > b.AverageChangePercentage
+			}
+		case SortTradedValue:
+			if a.TradedValue != b.TradedValue {
+				return a.TradedValue > b.TradedValue
+			}
+		case SortSectorName:
+		default:
+			if a.WeightedChangePercentage != b.WeightedChangePercentage {
+				return a.WeightedChangePercentage > b.WeightedChangePercentage
+			}
+		}
+		return a.Sector < b.Sector
+	})
+	return sectors
+}
+
+// topMovers returns up to n companies that moved in the wanted direction,
+// biggest move first.
+func topMovers(quotes []stock.MarketWatchResponse, n int, gainers bool) []Mover {
+	candidates := make([]stock.MarketWatchResponse, 0, len(quotes))
+	for _, q := range quotes {
+		if (gainers && q.ChangePercentage > 0) || (!gainers && q.ChangePercentage < 0) {
+			candidates = append(candidates, q)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if gainers {
+			return candidates[i].ChangePercentage > candidates[j].ChangePercentage
+		}
+		return candidates[i].ChangePercentage < candidates[j].ChangePercentage
+	})
+
+	movers := make([]Mover, 0, min(n, len(candidates)))
+	for _, q := range candidates[:min(n, len(candidates))] {
+		movers = append(movers, NewMover(q))
+	}
+	return movers
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// SectorsOverview is the whole market broken down by sector.
+type SectorsOverview struct {
+	Breadth
+	Companies   int             `json:"companies"`
+	Volume      int             `json:"volume"`
+	TradedValue float64         `json:"tradedValue"`
+	Sectors     []SectorSummary `json:"sectors"`
+}
+
+func NewSectorsOverview(quotes []stock.MarketWatchResponse, top int, sortBy SectorSort) SectorsOverview {
+	overview := SectorsOverview{
+		Companies: len(quotes),
+		Sectors:   AggregateSectors(quotes, top, sortBy),
+	}
+	for _, q := range quotes {
+		overview.add(q)
+		overview.Volume += q.Volume
+		overview.TradedValue += TradedValue(q)
+	}
+	overview.TradedValue = round2(overview.TradedValue)
+	return overview
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"patient-chatbot/internal/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserID carries the X-User-Id header in the request context so services and
+// tools can scope their data. Requests without a valid id stay anonymous.
+//
+// The header is not authenticated here: the API must sit behind a gateway
+// that authenticates the user, strips any X-User-Id the client sent and sets
+// its own. When gatewaySecret is set the header is only trusted on requests
+// carrying it in X-Gateway-Secret, so clients reaching the API directly stay
+// anonymous.
+func UserID(gatewaySecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if gatewaySecret != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Gateway-Secret")), []byte(gatewaySecret)) != 1 {
+			c.Next()
+			return
+		}
+		id := c.GetHeader("X-User-Id")
+		if user.ValidID(id) {
+			c.Set("UserID", id)
+			c.Request = c.Request.WithContext(user.WithID(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
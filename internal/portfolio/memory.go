@@ -0,0 +1,72 @@
+package portfolio
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps transactions in process memory. It is used when no
+// database is configured.
+type MemoryRepository struct {
+	mu           sync.RWMutex
+	transactions map[string][]Transaction
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{transactions: map[string][]Transaction{}}
+}
+
+func (r *MemoryRepository) List(ctx context.Context, userID string) ([]Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.list(userID), nil
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, userID string, t Transaction) (*Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t.ID = uuid.NewString()
+	t.CreatedAt = time.Now().UTC()
+	if err := Validate(withTransaction(r.list(userID), t)); err != nil {
+		return nil, err
+	}
+	r.transactions[userID] = append(r.transactions[userID], t)
+	return &t, nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining, err := withoutTransaction(r.list(userID), id)
+	if err != nil {
+		return err
+	}
+	if err := Validate(remaining); err != nil {
+		return err
+	}
+	r.transactions[userID] = slices.DeleteFunc(r.transactions[userID], func(t Transaction) bool { return t.ID == id })
+	return nil
+}
+
+// list returns the user's transactions in the order they were traded, the
+// caller holds the lock.
+func (r *MemoryRepository) list(userID string) []Transaction {
+	all := slices.Clone(r.transactions[userID])
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Date != all[j].Date {
+			return all[i].Date < all[j].Date
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	if all == nil {
+		all = []Transaction{}
+	}
+	return all
+}
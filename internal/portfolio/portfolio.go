@@ -0,0 +1,46 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound             = errors.New("transaction not found")
+	ErrInsufficientQuantity = errors.New("selling more shares than held")
+	ErrFutureDate           = errors.New("transaction date is in the future")
+)
+
+type Side string
+
+const (
+	SideBuy  Side = "buy"
+	SideSell Side = "sell"
+)
+
+// DateLayout is the layout of Transaction.Date.
+const DateLayout = "2006-01-02"
+
+// Transaction is a buy or sell of a company's shares. Fees are the total
+// commission and VAT paid on the trade.
+type Transaction struct {
+	ID        string    `json:"id"`
+	TadawulID string    `json:"tadawulId"`
+	Side      Side      `json:"side"`
+	Quantity  int       `json:"quantity"`
+	Price     float64   `json:"price"`
+	Fees      float64   `json:"fees"`
+	Date      string    `json:"date"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Repository stores transactions per user, every method is scoped by userID.
+// List returns them in the order they were traded. Create and Delete validate
+// the transactions they would leave behind under a per user lock, failing
+// with ErrInsufficientQuantity when a sell would no longer be covered.
+type Repository interface {
+	List(ctx context.Context, userID string) ([]Transaction, error)
+	Create(ctx context.Context, userID string, t Transaction) (*Transaction, error)
+	Delete(ctx context.Context, userID string, id string) error
+}
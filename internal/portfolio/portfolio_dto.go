@@ -0,0 +1,7 @@
+package portfolio
+
+const FunctionGetPortfolio = "GetPortfolio"
+
+type GetPortfolioArguments struct {
+	Sector string `json:"sector,omitempty" description:"Optional sector to focus on, in English or Arabic, e.g. Banks or البنوك, to answer exposure questions. Leave empty for the whole portfolio"`
+}
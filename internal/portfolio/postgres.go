@@ -0,0 +1,116 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) List(ctx context.Context, userID string) ([]Transaction, error) {
+	transactions, err := list(ctx, r.pool, userID)
+	if err != nil {
+		return nil, fmt.Errorf("portfolio :: List :: %w", err)
+	}
+	return transactions, nil
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, userID string, t Transaction) (*Transaction, error) {
+	t.ID = uuid.NewString()
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockUser(ctx, tx, userID); err != nil {
+			return err
+		}
+		transactions, err := list(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := Validate(withTransaction(transactions, t)); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, `
+			INSERT INTO portfolio_transactions (id, user_id, tadawul_id, side, quantity, price, fees, traded_on)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8::date)
+			RETURNING created_at`,
+			t.ID, userID, t.TadawulID, t.Side, t.Quantity, t.Price, t.Fees, t.Date,
+		).Scan(&t.CreatedAt)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("portfolio :: Create :: %w", err)
+	}
+	return &t, nil
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, userID string, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockUser(ctx, tx, userID); err != nil {
+			return err
+		}
+		transactions, err := list(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		remaining, err := withoutTransaction(transactions, id)
+		if err != nil {
+			return err
+		}
+		if err := Validate(remaining); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM portfolio_transactions WHERE id = $1 AND user_id = $2", id, userID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("portfolio :: Delete :: %w", err)
+	}
+	return nil
+}
+
+// lockUser serializes the writes of a user until the transaction ends, so two
+// requests can't both validate against the same transactions.
+func lockUser(ctx context.Context, tx pgx.Tx, userID string) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('portfolio:' || $1))", userID)
+	return err
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func list(ctx context.Context, q querier, userID string) ([]Transaction, error) {
+	rows, err := q.Query(ctx, `
+		SELECT id, tadawul_id, side, quantity, price, fees, to_char(traded_on, 'YYYY-MM-DD'), created_at
+		FROM portfolio_transactions WHERE user_id = $1
+		ORDER BY traded_on, created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.TadawulID, &t.Side, &t.Quantity, &t.Price, &t.Fees, &t.Date, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
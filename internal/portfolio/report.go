@@ -0,0 +1,244 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"patient-chatbot/internal/client/stock"
+	"slices"
+	"sort"
+)
+
+// Position is an open holding valued at the latest price. Costs follow the
+// average cost method: buy fees are added to the cost, sell fees reduce the
+// realized P&L.
+type Position struct {
+	TadawulID               string  `json:"tadawulId"`
+	CompanyName             string  `json:"companyName"`
+	CompanyNameAr           string  `json:"companyNameAr"`
+	Sector                  string  `json:"sector"`
+	SectorAr                string  `json:"sectorAr"`
+	Quantity                int     `json:"quantity"`
+	AverageCost             float64 `json:"averageCost"`
+	CostBasis               float64 `json:"costBasis"`
+	Price                   float64 `json:"price"`
+	MarketValue             float64 `json:"marketValue"`
+	UnrealizedPnL           float64 `json:"unrealizedPnl"`
+	UnrealizedPnLPercentage float64 `json:"unrealizedPnlPercentage"`
+	RealizedPnL             float64 `json:"realizedPnl"`
+	DayChange               float64 `json:"dayChange"`
+	DayChangePercentage     float64 `json:"dayChangePercentage"`
+	Weight                  float64 `json:"weight"`
+	// Stale marks companies missing from the market watch, valued at the
+	// company directory snapshot price instead.
+	Stale bool `json:"stale,omitempty"`
+}
+
+type SectorAllocation struct {
+	Sector      string  `json:"sector"`
+	SectorAr    string  `json:"sectorAr"`
+	Positions   int     `json:"positions"`
+	MarketValue float64 `json:"marketValue"`
+	Weight      float64 `json:"weight"`
+}
+
+type Report struct {
+	Positions               []Position         `json:"positions"`
+	Allocation              []SectorAllocation `json:"allocation"`
+	CostBasis               float64            `json:"costBasis"`
+	MarketValue             float64            `json:"marketValue"`
+	UnrealizedPnL           float64            `json:"unrealizedPnl"`
+	UnrealizedPnLPercentage float64            `json:"unrealizedPnlPercentage"`
+	RealizedPnL             float64            `json:"realizedPnl"`
+	DayChange               float64            `json:"dayChange"`
+	DayChangePercentage     float64            `json:"dayChangePercentage"`
+	Transactions            int                `json:"transactions"`
+}
+
+// holding is the running state of one company while replaying transactions.
+type holding struct {
+	quantity int
+	cost     float64
+	realized float64
+}
+
+// Validate checks the transactions can be replayed in order, it fails with
+// ErrInsufficientQuantity on the first sell of more shares than held then.
+func Validate(transactions []Transaction) error {
+	_, err := replay(transactions)
+	return err
+}
+
+// withTransaction adds t to transactions listed in the order they were
+// traded, after the others of its date.
+func withTransaction(transactions []Transaction, t Transaction) []Transaction {
+	transactions = append(transactions, t)
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Date < transactions[j].Date })
+	return transactions
+}
+
+// withoutTransaction removes the transaction id, ErrNotFound when there is
+// none.
+func withoutTransaction(transactions []Transaction, id string) ([]Transaction, error) {
+	i := slices.IndexFunc(transactions, func(t Transaction) bool { return t.ID == id })
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	return slices.Delete(transactions, i, i+1), nil
+}
+
+// replay applies the transactions in order and returns the holding of every
+// company traded.
+func replay(transactions []Transaction) (map[string]*holding, error) {
+	holdings := map[string]*holding{}
+	for _, t := range transactions {
+		h, ok := holdings[t.TadawulID]
+		if !ok {
+			h = &holding{}
+			holdings[t.TadawulID] = h
+		}
+		switch t.Side {
+		case SideBuy:
+			h.quantity += t.Quantity
+			h.cost += float64(t.Quantity)*t.Price + t.Fees
+		case SideSell:
+			if t.Quantity > h.quantity {
+				return nil, fmt.Errorf("%w: %d %s on %s, %d held", ErrInsufficientQuantity, t.Quantity, t.TadawulID, t.Date, h.quantity)
+			}
+			averageCost := h.cost / float64(h.quantity)
+			h.realized += float64(t.Quantity)*(t.Price-averageCost) - t.Fees
+			h.quantity -= t.Quantity
+			h.cost -= float64(t.Quantity) * averageCost
+			if h.quantity == 0 {
+				h.cost = 0
+			}
+		}
+	}
+	return holdings, nil
+}
+
+// Compute values the holdings resulting from transactions with marketWatch,
+// falling back to fallback for companies it lacks. Positions come out largest
+// first and sectors by weight.
+func Compute(
+	transactions []Transaction,
+	marketWatch map[string]stock.MarketWatchResponse,
+	fallback func(tadawulID string) (stock.MarketWatchResponse, bool),
+) (*Report, error) {
+	holdings, err := replay(transactions)
+	if err != nil {
+		return nil, fmt.Errorf("portfolio :: Compute :: %w", err)
+	}
+
+	report := &Report{Positions: []Position{}, Allocation: []SectorAllocation{}, Transactions: len(transactions)}
+	for tadawulID, h := range holdings {
+		report.RealizedPnL += h.realized
+		if h.quantity == 0 {
+			continue
+		}
+
+		q, ok := marketWatch[tadawulID]
+		stale := !ok
+		if !ok {
+			q, _ = fallback(tadawulID)
+		}
+		p := Position{
+			TadawulID:     tadawulID,
+			CompanyName:   q.CompanyName,
+			CompanyNameAr: q.CompanyNameAr,
+			Sector:        q.Sector,
+			SectorAr:      q.SectorAr,
+			Quantity:      h.quantity,
+			AverageCost:   h.cost / float64(h.quantity),
+			CostBasis:     h.cost,
+			Price:         q.Price,
+			MarketValue:   float64(h.quantity) * q.Price,
+			RealizedPnL:   h.realized,
+			Stale:         stale,
+		}
+		// @NOTE: Without a price the position is carried at cost rather than zero
+		if q.Price <= 0 {
+			p.Price, p.MarketValue = p.AverageCost, p.CostBasis
+		} else {
+			p.DayChange = float64(h.quantity) * q.Change
+		}
+		p.UnrealizedPnL = p.MarketValue - p.CostBasis
+		p.UnrealizedPnLPercentage = percentage(p.UnrealizedPnL, p.CostBasis)
+		p.DayChangePercentage = percentage(p.DayChange, p.MarketValue-p.DayChange)
+
+		report.CostBasis += p.CostBasis
+		report.MarketValue += p.MarketValue
+		report.UnrealizedPnL += p.UnrealizedPnL
+		report.DayChange += p.DayChange
+		report.Positions = append(report.Positions, p)
+	}
+	report.UnrealizedPnLPercentage = percentage(report.UnrealizedPnL, report.CostBasis)
+	report.DayChangePercentage = percentage(report.DayChange, report.MarketValue-report.DayChange)
+
+	sort.Slice(report.Positions, func(i, j int) bool {
+		a, b := report.Positions[i], report.Positions[j]
+		if a.MarketValue != b.MarketValue {
+			return a.MarketValue > b.MarketValue
+		}
+		return a.TadawulID < b.TadawulID
+	})
+
+	bySector := map[string]*SectorAllocation{}
+	for i := range report.Positions {
+		p := &report.Positions[i]
+		p.Weight = percentage(p.MarketValue, report.MarketValue)
+		allocation, ok := bySector[p.Sector]
+		if !ok {
+			allocation = &SectorAllocation{Sector: p.Sector, SectorAr: p.SectorAr}
+			bySector[p.Sector] = allocation
+		}
+		allocation.Positions++
+		allocation.MarketValue += p.MarketValue
+	}
+	for _, allocation := range bySector {
+		allocation.Weight = percentage(allocation.MarketValue, report.MarketValue)
+		report.Allocation = append(report.Allocation, *allocation)
+	}
+	sort.Slice(report.Allocation, func(i, j int) bool {
+		a, b := report.Allocation[i], report.Allocation[j]
+		if a.MarketValue != b.MarketValue {
+			return a.MarketValue > b.MarketValue
+		}
+		return a.Sector < b.Sector
+	})
+
+	report.round()
+	return report, nil
+}
+
+func (r *Report) round() {
+	for i := range r.Positions {
+		p := &r.Positions[i]
+		for _, v := range []*float64{
+			&p.AverageCost, &p.CostBasis, &p.MarketValue, &p.UnrealizedPnL, &p.UnrealizedPnLPercentage,
+			&p.RealizedPnL, &p.DayChange, &p.DayChangePercentage, &p.Weight,
+		} {
+			*v = round2(*v)
+		}
+	}
+	for i := range r.Allocation {
+		r.Allocation[i].MarketValue = round2(r.Allocation[i].MarketValue)
+		r.Allocation[i].Weight = round2(r.Allocation[i].Weight)
+	}
+	for _, v := range []*float64{
+		&r.CostBasis, &r.MarketValue, &r.UnrealizedPnL, &r.UnrealizedPnLPercentage,
+		&r.RealizedPnL, &r.DayChange, &r.DayChangePercentage,
+	} {
+		*v = round2(*v)
+	}
+}
+
+func percentage(part float64, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return part / whole * 100
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
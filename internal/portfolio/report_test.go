@@ -0,0 +1,232 @@
+package portfolio
+
+import (
+	"errors"
+	"math"
+	"patient-chatbot/internal/client/stock"
+	"testing"
+)
+
+func buy(id string, tadawulID string, quantity int, price float64, fees float64, date string) Transaction {
+	return Transaction{ID: id, TadawulID: tadawulID, Side: SideBuy, Quantity: quantity, Price: price, Fees: fees, Date: date}
+}
+
+func sell(id string, tadawulID string, quantity int, price float64, fees float64, date string) Transaction {
+	return Transaction{ID: id, TadawulID: tadawulID, Side: SideSell, Quantity: quantity, Price: price, Fees: fees, Date: date}
+}
+
+func near(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []Transaction
+		wantQuantity int
+		wantAverage  float64
+		wantRealized float64
+		wantErr      error
+	}{
+		{
+			name:         "buy fees are part of the cost",
+			transactions: []Transaction{buy("1", "1120", 100, 10, 10, "2025-01-01")},
+			wantQuantity: 100, wantAverage: 10.1,
+		},
+		{
+			name: "partial sell keeps the average cost",
+			transactions: []Transaction{
+				buy("1", "1120", 100, 10, 10, "2025-01-01"),
+				sell("2", "1120", 40, 12, 8, "2025-01-02"),
+			},
+			// @NOTE: 40 * (12 - 10.1) - 8
+			wantQuantity: 60, wantAverage: 10.1, wantRealized: 68,
+		},
+		{
+			name: "full sell realizes the rest",
+			transactions: []Transaction{
+				buy("1", "1120", 100, 10, 10, "2025-01-01"),
+				sell("2", "1120", 40, 12, 8, "2025-01-02"),
+				sell("3", "1120", 60, 11, 6, "2025-01-03"),
+			},
+			wantQuantity: 0, wantRealized: 68 + 48,
+		},
+		{
+			name: "buys average together",
+			transactions: []Transaction{
+				buy("1", "1120", 10, 10, 0, "2025-01-01"),
+				buy("2", "1120", 30, 14, 0, "2025-01-02"),
+			},
+			wantQuantity: 40, wantAverage: 13,
+		},
+		{
+			name: "selling more than held",
+			transactions: []Transaction{
+				buy("1", "1120", 10, 10, 0, "2025-01-01"),
+				sell("2", "1120", 11, 10, 0, "2025-01-02"),
+			},
+			wantErr: ErrInsufficientQuantity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holdings, err := replay(tt.transactions)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := holdings["1120"]
+			if h.quantity != tt.wantQuantity {
+				t.Errorf("quantity = %d, want %d", h.quantity, tt.wantQuantity)
+			}
+			if h.quantity > 0 && !near(h.cost/float64(h.quantity), tt.wantAverage) {
+				t.Errorf("average cost = %v, want %v", h.cost/float64(h.quantity), tt.wantAverage)
+			}
+			if h.quantity == 0 && h.cost != 0 {
+				t.Errorf("cost = %v with nothing held, want 0", h.cost)
+			}
+			if !near(h.realized, tt.wantRealized) {
+				t.Errorf("realized = %v, want %v", h.realized, tt.wantRealized)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	held := []Transaction{
+		buy("1", "1120", 10, 10, 0, "2025-01-02"),
+		sell("2", "1120", 10, 12, 0, "2025-01-03"),
+	}
+
+	tests := []struct {
+		name    string
+		change  func([]Transaction) ([]Transaction, error)
+		wantErr error
+	}{
+		{
+			name: "sell dated before its buy",
+			change: func(transactions []Transaction) ([]Transaction, error) {
+				return withTransaction(transactions, sell("3", "1120", 5, 12, 0, "2025-01-01")), nil
+			},
+			wantErr: ErrInsufficientQuantity,
+		},
+		{
+			name: "sell on the day of its buy comes after it",
+			change: func(transactions []Transaction) ([]Transaction, error) {
+				transactions = withTransaction(transactions, buy("3", "1120", 5, 10, 0, "2025-01-04"))
+				return withTransaction(transactions, sell("4", "1120", 5, 12, 0, "2025-01-04")), nil
+			},
+		},
+		{
+			name: "deleting a buy a later sell depends on",
+			change: func(transactions []Transaction) ([]Transaction, error) {
+				return withoutTransaction(transactions, "1")
+			},
+			wantErr: ErrInsufficientQuantity,
+		},
+		{
+			name: "deleting the sell",
+			change: func(transactions []Transaction) ([]Transaction, error) {
+				return withoutTransaction(transactions, "2")
+			},
+		},
+		{
+			name: "deleting an unknown transaction",
+			change: func(transactions []Transaction) ([]Transaction, error) {
+				return withoutTransaction(transactions, "9")
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, err := tt.change(append([]Transaction{}, held...))
+			if err == nil {
+				err = Validate(transactions)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	transactions := []Transaction{
+		buy("1", "1120", 100, 10, 10, "2025-01-01"),
+		sell("2", "1120", 40, 12, 8, "2025-01-02"),
+		buy("3", "2222", 10, 30, 0, "2025-01-02"),
+		buy("4", "1180", 5, 20, 0, "2025-01-03"),
+		sell("5", "1180", 5, 22, 0, "2025-01-04"),
+	}
+	marketWatch := map[string]stock.MarketWatchResponse{
+		"1120": {TadawulID: "1120", Sector: "Banks", Price: 12, Change: 0.5},
+	}
+	fallback := func(tadawulID string) (stock.MarketWatchResponse, bool) {
+		// @NOTE: The directory knows the company but has no price for it
+		return stock.MarketWatchResponse{TadawulID: tadawulID, Sector: "Energy"}, true
+	}
+
+	report, err := Compute(transactions, marketWatch, fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Position{
+		{
+			TadawulID: "1120", Sector: "Banks", Quantity: 60, AverageCost: 10.1, CostBasis: 606, Price: 12, MarketValue: 720,
+			UnrealizedPnL: 114, UnrealizedPnLPercentage: 18.81, RealizedPnL: 68, DayChange: 30, DayChangePercentage: 4.35, Weight: 70.59,
+		},
+		{
+			TadawulID: "2222", Sector: "Energy", Quantity: 10, AverageCost: 30, CostBasis: 300, Price: 30, MarketValue: 300,
+			Weight: 29.41, Stale: true,
+		},
+	}
+	if len(report.Positions) != len(want) {
+		t.Fatalf("positions = %+v, want %+v", report.Positions, want)
+	}
+	for i := range want {
+		if report.Positions[i] != want[i] {
+			t.Errorf("position %d = %+v, want %+v", i, report.Positions[i], want[i])
+		}
+	}
+
+	wantAllocation := []SectorAllocation{
+		{Sector: "Banks", Positions: 1, MarketValue: 720, Weight: 70.59},
+		{Sector: "Energy", Positions: 1, MarketValue: 300, Weight: 29.41},
+	}
+	if len(report.Allocation) != len(wantAllocation) {
+		t.Fatalf("allocation = %+v, want %+v", report.Allocation, wantAllocation)
+	}
+	for i := range wantAllocation {
+		if report.Allocation[i] != wantAllocation[i] {
+			t.Errorf("allocation %d = %+v, want %+v", i, report.Allocation[i], wantAllocation[i])
+		}
+	}
+
+	// @NOTE: The closed 1180 position only shows up in the realized P&L
+	if report.RealizedPnL != 78 || report.CostBasis != 906 || report.MarketValue != 1020 || report.UnrealizedPnL != 114 {
+		t.Errorf("realized, cost, value, unrealized = %v, %v, %v, %v, want 78, 906, 1020, 114",
+			report.RealizedPnL, report.CostBasis, report.MarketValue, report.UnrealizedPnL)
+	}
+	if report.DayChange != 30 || report.DayChangePercentage != 3.03 || report.Transactions != len(transactions) {
+		t.Errorf("day change, day change %%, transactions = %v, %v, %d, want 30, 3.03, %d",
+			report.DayChange, report.DayChangePercentage, report.Transactions, len(transactions))
+	}
+}
+
+func TestComputeRejectsUncoveredSell(t *testing.T) {
+	_, err := Compute([]Transaction{sell("1", "1120", 1, 10, 0, "2025-01-01")}, nil, func(string) (stock.MarketWatchResponse, bool) {
+		return stock.MarketWatchResponse{}, false
+	})
+	if !errors.Is(err, ErrInsufficientQuantity) {
+		t.Errorf("error = %v, want %v", err, ErrInsufficientQuantity)
+	}
+}
@@ -0,0 +1,207 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+
+	maxRequestSize = 4096
+	// maxControlBacklog bounds the replies a client can leave unread, it is
+	// disconnected past that.
+	maxControlBacklog = 32
+)
+
+// Client is one WebSocket connection. Replies are queued in order while
+// channel messages are coalesced per channel: a client reading slower than
+// the poller skips the intermediate updates and gets a snapshot of the latest
+// state instead, so it never holds the hub back nor grows without bound.
+type Client struct {
+	hub  *Hub
+	conn *websocket.Conn
+
+	mu            sync.Mutex
+	subscriptions map[string]bool
+	control       []Message
+	pending       map[string]Message
+	order         []string
+	closed        bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+func newClient(hub *Hub, conn *websocket.Conn) *Client {
+	return &Client{
+		hub:           hub,
+		conn:          conn,
+		subscriptions: map[string]bool{},
+		pending:       map[string]Message{},
+		wake:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+}
+
+// reply queues a control message, it reports false when the client stopped
+// reading and was closed.
+func (c *Client) reply(message Message) bool {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	if len(c.control) >= maxControlBacklog {
+		c.mu.Unlock()
+		c.close()
+		return false
+	}
+	c.control = append(c.control, message)
+	c.mu.Unlock()
+	c.notify()
+	return true
+}
+
+// publish queues update for its channel, or snapshot when an earlier message
+// of the channel is still unsent.
+func (c *Client) publish(update Message, snapshot Message) {
+	c.mu.Lock()
+	if c.closed || !c.subscriptions[update.Channel] {
+		c.mu.Unlock()
+		return
+	}
+	if _, ok := c.pending[update.Channel]; ok {
+		c.pending[update.Channel] = snapshot
+	} else {
+		c.pending[update.Channel] = update
+		c.order = append(c.order, update.Channel)
+	}
+	c.mu.Unlock()
+	c.notify()
+}
+
+func (c *Client) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next takes everything queued, replies first.
+func (c *Client) next() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages := c.control
+	c.control = nil
+	for _, channel := range c.order {
+		// @NOTE: Channels unsubscribed since they were queued are no longer pending
+		if message, ok := c.pending[channel]; ok {
+			messages = append(messages, message)
+			delete(c.pending, channel)
+		}
+	}
+	c.order = c.order[:0]
+	return messages
+}
+
+func (c *Client) subscribed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	channels := make([]string, 0, len(c.subscriptions))
+	for channel := range c.subscriptions {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+}
+
+// readPump handles the client requests until the connection fails or the
+// client stops answering pings.
+func (c *Client) readPump() {
+	defer c.close()
+
+	c.conn.SetReadLimit(maxRequestSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		var request Request
+		if err := c.conn.ReadJSON(&request); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if (errors.As(err, &syntaxErr) || errors.As(err, &typeErr)) && c.reply(errorMessage("request is not valid JSON")) {
+				continue
+			}
+			return
+		}
+		if !c.handle(request) {
+			return
+		}
+	}
+}
+
+func (c *Client) handle(request Request) bool {
+	switch request.Action {
+	case ActionSubscribe:
+		return c.hub.subscribe(c, request.Channels)
+	case ActionUnsubscribe:
+		c.mu.Lock()
+		for _, channel := range request.Channels {
+			delete(c.subscriptions, channel)
+			delete(c.pending, channel)
+		}
+		c.mu.Unlock()
+		return c.reply(Message{Type: TypeUnsubscribed, Channels: request.Channels})
+	case ActionPing:
+		return c.reply(Message{Type: TypePong})
+	}
+	return c.reply(errorMessage("unknown action " + request.Action))
+}
+
+// writePump sends the queued messages and pings the client every pingPeriod.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+			return
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close()
+				return
+			}
+		case <-c.wake:
+			for _, message := range c.next() {
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if err := c.conn.WriteJSON(message); err != nil {
+					c.close()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/market"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// ChannelDashboard streams the dashboard, quote channels are "quote:" followed
+// by the tadawul id.
+const (
+	ChannelDashboard   = "dashboard"
+	ChannelQuotePrefix = "quote:"
+
+	MaxSubscriptions = 100
+)
+
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+	ActionPing        = "ping"
+)
+
+const (
+	TypeSubscribed   = "subscribed"
+	TypeUnsubscribed = "unsubscribed"
+	TypeSnapshot     = "snapshot"
+	TypeUpdate       = "update"
+	TypeError        = "error"
+	TypePong         = "pong"
+)
+
+// Request is a message sent by the client.
+type Request struct {
+	Action   string   `json:"action"`
+	Channels []string `json:"channels"`
+}
+
+// Message is a message sent to the client. Snapshots carry the whole state of
+// the channel, updates only its top level fields that changed.
+type Message struct {
+	Type     string          `json:"type"`
+	Channel  string          `json:"channel,omitempty"`
+	Channels []string        `json:"channels,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Message  string          `json:"message,omitempty"`
+}
+
+func errorMessage(message string) Message {
+	return Message{Type: TypeError, Message: message}
+}
+
+// Source is the market data the hub polls.
+type Source interface {
+	GetMarketWatch() ([]stock.MarketWatchResponse, error)
+	GetDashboard() (*market.Dashboard, error)
+}
+
+// state is the last value published on a channel, whole and by field.
+type state struct {
+	full   json.RawMessage
+	fields map[string]json.RawMessage
+}
+
+// Hub polls the source on behalf of every connected client, only for the
+// channels someone is subscribed to, and fans the changes out to them.
+type Hub struct {
+	source   Source
+	interval time.Duration
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	states  map[string]state
+
+	kick chan struct{}
+}
+
+// NewHub accepts WebSocket connections from allowedOrigin, or from clients
+// that send no Origin header.
+func NewHub(source Source, interval time.Duration, allowedOrigin string) *Hub {
+	return &Hub{
+		source:   source,
+		interval: interval,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 4096,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || strings.EqualFold(origin, allowedOrigin)
+			},
+		},
+		clients: map[*Client]struct{}{},
+		states:  map[string]state{},
+		kick:    make(chan struct{}, 1),
+	}
+}
+
+// Serve upgrades the request and serves the connection until it closes.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request) error {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return fmt.Errorf("realtime :: Hub.Serve :: %w", err)
+	}
+
+	client := newClient(h, conn)
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, client)
+		h.mu.Unlock()
+	}()
+
+	go client.writePump()
+	client.readPump()
+	return nil
+}
+
+// Run polls every interval, and right away when a client subscribes to a
+// channel without a known state, until ctx is done.
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-h.kick:
+		}
+		h.Poll()
+	}
+}
+
+// Poll fetches the subscribed channels once and publishes what changed.
+func (h *Hub) Poll() {
+	channels := h.channels()
+	h.prune(channels)
+	if len(channels) == 0 {
+		return
+	}
+
+	values := map[string]any{}
+	var quotes bool
+	for channel := range channels {
+		quotes = quotes || strings.HasPrefix(channel, ChannelQuotePrefix)
+	}
+	if quotes {
+		marketWatch, err := h.source.GetMarketWatch()
+		if err != nil {
+			log.Warn().Msg("realtime :: Hub.Poll :: " + err.Error())
+		}
+		for _, q := range marketWatch {
+			if channel := ChannelQuotePrefix + q.TadawulID; channels[channel] {
+				values[channel] = q
+			}
+		}
+	}
+	if channels[ChannelDashboard] {
+		dashboard, err := h.source.GetDashboard()
+		if err != nil {
+			log.Warn().Msg("realtime :: Hub.Poll :: " + err.Error())
+		} else {
+			values[ChannelDashboard] = dashboard
+		}
+	}
+
+	for channel, value := range values {
+		if err := h.update(channel, value); err != nil {
+			log.Error().Msg("realtime :: Hub.Poll :: " + err.Error())
+		}
+	}
+}
+
+// update stores value as the state of channel and publishes the difference
+// with the previous state, or a snapshot when there was none.
+func (h *Hub) update(channel string, value any) error {
+	next, err := newState(value)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %w", channel, err)
+	}
+
+	h.mu.Lock()
+	previous, known := h.states[channel]
+	h.states[channel] = next
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	snapshot := Message{Type: TypeSnapshot, Channel: channel, Data: next.full}
+	update := snapshot
+	if known {
+		changed := diff(previous, next, volatileFields(channel))
+		if changed == nil {
+			return nil
+		}
+		if update.Data, err = json.Marshal(changed); err != nil {
+			return fmt.Errorf("error encoding %s update: %w", channel, err)
+		}
+		update.Type = TypeUpdate
+	}
+	for _, client := range clients {
+		client.publish(update, snapshot)
+	}
+	return nil
+}
+
+// subscribe validates and adds the channels, sends the known states right
+// away and has the poller fetch the others.
+func (h *Hub) subscribe(c *Client, channels []string) bool {
+	for _, channel := range channels {
+		if err := validChannel(channel); err != nil {
+			return c.reply(errorMessage(err.Error()))
+		}
+	}
+
+	c.mu.Lock()
+	added := []string{}
+	for _, channel := range channels {
+		if !c.subscriptions[channel] {
+			added = append(added, channel)
+		}
+	}
+	if len(c.subscriptions)+len(added) > MaxSubscriptions {
+		c.mu.Unlock()
+		return c.reply(errorMessage(fmt.Sprintf("at most %d subscriptions per connection", MaxSubscriptions)))
+	}
+	for _, channel := range added {
+		c.subscriptions[channel] = true
+	}
+	c.mu.Unlock()
+
+	if !c.reply(Message{Type: TypeSubscribed, Channels: channels}) {
+		return false
+	}
+	missing := false
+	for _, channel := range added {
+		h.mu.Lock()
+		current, ok := h.states[channel]
+		h.mu.Unlock()
+		if !ok {
+			missing = true
+			continue
+		}
+		snapshot := Message{Type: TypeSnapshot, Channel: channel, Data: current.full}
+		c.publish(snapshot, snapshot)
+	}
+	if missing {
+		select {
+		case h.kick <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// channels returns every channel at least one client is subscribed to.
+func (h *Hub) channels() map[string]bool {
+	h.mu.Lock()
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	channels := map[string]bool{}
+	for _, client := range clients {
+		for _, channel := range client.subscribed() {
+			channels[channel] = true
+		}
+	}
+	return channels
+}
+
+// prune forgets the states nobody is subscribed to anymore so a later
+// subscriber is not sent an old snapshot.
+func (h *Hub) prune(channels map[string]bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for channel := range h.states {
+		if !channels[channel] {
+			delete(h.states, channel)
+		}
+	}
+}
+
+func validChannel(channel string) error {
+	if channel == ChannelDashboard {
+		return nil
+	}
+	tadawulID, ok := strings.CutPrefix(channel, ChannelQuotePrefix)
+	if !ok {
+		return fmt.Errorf("unknown channel %q", channel)
+	}
+	if _, err := mapping.Lookup(tadawulID); err != nil {
+		return fmt.Errorf("unknown channel %q: %s", channel, err)
+	}
+	return nil
+}
+
+func newState(value any) (state, error) {
+	full, err := json.Marshal(value)
+	if err != nil {
+		return state{}, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(full, &fields); err != nil {
+		return state{}, err
+	}
+	return state{full: full, fields: fields}, nil
+}
+
+// volatileFields change on every poll, they are sent along with real changes
+// but do not make one on their own.
+func volatileFields(channel string) map[string]bool {
+	if channel == ChannelDashboard {
+		return map[string]bool{"timestamps": true}
+	}
+	return nil
+}
+
+// diff returns the fields of next that differ from previous, nil when only
+// volatile ones do.
+func diff(previous state, next state, volatile map[string]bool) map[string]json.RawMessage {
+	changed := map[string]json.RawMessage{}
+	meaningful := false
+	for key, value := range next.fields {
+		if old, ok := previous.fields[key]; ok && bytes.Equal(old, value) {
+			continue
+		}
+		changed[key] = value
+		meaningful = meaningful || !volatile[key]
+	}
+	if !meaningful {
+		return nil
+	}
+	// @NOTE: Quote updates keep their id so clients can route them without the channel
+	if id, ok := next.fields["tadawulId"]; ok {
+		changed["tadawulId"] = id
+	}
+	return changed
+}
@@ -0,0 +1,180 @@
+package realtime
+
+import (
+	"encoding/json"
+	"patient-chatbot/internal/client/stock"
+	"testing"
+	"time"
+)
+
+func fields(t *testing.T, value any) state {
+	t.Helper()
+	s, err := newState(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	previous := map[string]any{"tadawulId": "2222", "price": 27.5, "volume": 100, "timestamps": 1}
+
+	tests := []struct {
+		name     string
+		next     map[string]any
+		volatile map[string]bool
+		want     map[string]string
+	}{
+		{name: "nothing changed", next: previous, want: nil},
+		{name: "changed fields and the id", next: map[string]any{"tadawulId": "2222", "price": 27.6, "volume": 100, "timestamps": 1}, want: map[string]string{"tadawulId": `"2222"`, "price": "27.6"}},
+		{name: "new field", next: map[string]any{"tadawulId": "2222", "price": 27.5, "volume": 100, "timestamps": 1, "high": 28}, want: map[string]string{"tadawulId": `"2222"`, "high": "28"}},
+		{name: "only volatile fields changed", next: map[string]any{"tadawulId": "2222", "price": 27.5, "volume": 100, "timestamps": 2}, volatile: map[string]bool{"timestamps": true}, want: nil},
+		{name: "volatile fields ride along", next: map[string]any{"tadawulId": "2222", "price": 27.5, "volume": 101, "timestamps": 2}, volatile: map[string]bool{"timestamps": true}, want: map[string]string{"tadawulId": `"2222"`, "volume": "101", "timestamps": "2"}},
+		{name: "volatile only on its channel", next: map[string]any{"tadawulId": "2222", "price": 27.5, "volume": 100, "timestamps": 2}, want: map[string]string{"tadawulId": `"2222"`, "timestamps": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diff(fields(t, previous), fields(t, tt.next), tt.volatile)
+			if (got == nil) != (tt.want == nil) || len(got) != len(tt.want) {
+				t.Fatalf("diff() = %s, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if string(got[key]) != value {
+					t.Errorf("%s = %s, want %s", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+// connected registers a client without a connection, nothing here writes to
+// it.
+func connected(t *testing.T, hub *Hub, channels ...string) *Client {
+	t.Helper()
+	client := newClient(hub, nil)
+	hub.mu.Lock()
+	hub.clients[client] = struct{}{}
+	hub.mu.Unlock()
+	if !client.handle(Request{Action: ActionSubscribe, Channels: channels}) {
+		t.Fatal("subscribe closed the client")
+	}
+	if messages := client.next(); len(messages) != 1 || messages[0].Type != TypeSubscribed {
+		t.Fatalf("subscribe replied %+v", messages)
+	}
+	return client
+}
+
+func publish(t *testing.T, hub *Hub, channel string, value any) {
+	t.Helper()
+	if err := hub.update(channel, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertMessages(t *testing.T, got []Message, want ...Message) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("messages = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Type != want[i].Type || got[i].Channel != want[i].Channel {
+			t.Errorf("message %d = %s %s, want %s %s", i, got[i].Type, got[i].Channel, want[i].Type, want[i].Channel)
+		}
+		if want[i].Data != nil && string(got[i].Data) != string(want[i].Data) {
+			t.Errorf("message %d data = %s, want %s", i, got[i].Data, want[i].Data)
+		}
+	}
+}
+
+func encode(t *testing.T, value any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPublishCoalescesPendingMessages(t *testing.T) {
+	hub := NewHub(nil, time.Second, "")
+	client := connected(t, hub, "quote:2222")
+	quote := stock.MarketWatchResponse{TadawulID: "2222", Price: 27.5}
+
+	publish(t, hub, "quote:2222", quote)
+	assertMessages(t, client.next(), Message{Type: TypeSnapshot, Channel: "quote:2222", Data: encode(t, quote)})
+
+	quote.Price = 27.6
+	publish(t, hub, "quote:2222", quote)
+	assertMessages(t, client.next(), Message{Type: TypeUpdate, Channel: "quote:2222", Data: json.RawMessage(`{"price":27.6,"tadawulId":"2222"}`)})
+
+	// @NOTE: The second update can't be applied without the unsent first one
+	quote.Price = 27.7
+	publish(t, hub, "quote:2222", quote)
+	quote.Volume = 500
+	publish(t, hub, "quote:2222", quote)
+	assertMessages(t, client.next(), Message{Type: TypeSnapshot, Channel: "quote:2222", Data: encode(t, quote)})
+
+	publish(t, hub, "quote:2222", quote)
+	assertMessages(t, client.next())
+}
+
+func TestRepliesComeBeforeChannelMessages(t *testing.T) {
+	hub := NewHub(nil, time.Second, "")
+	client := connected(t, hub, "quote:2222", "quote:1120")
+
+	publish(t, hub, "quote:1120", stock.MarketWatchResponse{TadawulID: "1120"})
+	publish(t, hub, "quote:2222", stock.MarketWatchResponse{TadawulID: "2222"})
+	client.handle(Request{Action: ActionPing})
+	assertMessages(t, client.next(),
+		Message{Type: TypePong},
+		Message{Type: TypeSnapshot, Channel: "quote:1120"},
+		Message{Type: TypeSnapshot, Channel: "quote:2222"},
+	)
+}
+
+func TestUnsubscribeDropsPendingMessages(t *testing.T) {
+	hub := NewHub(nil, time.Second, "")
+	client := connected(t, hub, "quote:2222", "quote:1120")
+
+	publish(t, hub, "quote:2222", stock.MarketWatchResponse{TadawulID: "2222"})
+	publish(t, hub, "quote:1120", stock.MarketWatchResponse{TadawulID: "1120"})
+	client.handle(Request{Action: ActionUnsubscribe, Channels: []string{"quote:2222"}})
+	assertMessages(t, client.next(),
+		Message{Type: TypeUnsubscribed},
+		Message{Type: TypeSnapshot, Channel: "quote:1120"},
+	)
+
+	publish(t, hub, "quote:2222", stock.MarketWatchResponse{TadawulID: "2222", Price: 1})
+	assertMessages(t, client.next())
+}
+
+func TestVolatileChangesAreSuppressed(t *testing.T) {
+	hub := NewHub(nil, time.Second, "")
+	client := connected(t, hub, ChannelDashboard)
+
+	publish(t, hub, ChannelDashboard, map[string]any{"index": 11000, "timestamps": 1})
+	assertMessages(t, client.next(), Message{Type: TypeSnapshot, Channel: ChannelDashboard})
+
+	publish(t, hub, ChannelDashboard, map[string]any{"index": 11000, "timestamps": 2})
+	assertMessages(t, client.next())
+
+	publish(t, hub, ChannelDashboard, map[string]any{"index": 11010, "timestamps": 3})
+	assertMessages(t, client.next(), Message{Type: TypeUpdate, Channel: ChannelDashboard, Data: json.RawMessage(`{"index":11010,"timestamps":3}`)})
+}
+
+func TestSubscribeSendsTheKnownState(t *testing.T) {
+	hub := NewHub(nil, time.Second, "")
+	first := connected(t, hub, "quote:2222")
+	publish(t, hub, "quote:2222", stock.MarketWatchResponse{TadawulID: "2222"})
+	first.next()
+
+	second := connected(t, hub)
+	second.handle(Request{Action: ActionSubscribe, Channels: []string{"quote:2222"}})
+	assertMessages(t, second.next(), Message{Type: TypeSubscribed}, Message{Type: TypeSnapshot, Channel: "quote:2222"})
+
+	if !second.handle(Request{Action: ActionSubscribe, Channels: []string{"quote:0000"}}) {
+		t.Fatal("an unknown channel closed the client")
+	}
+	assertMessages(t, second.next(), Message{Type: TypeError})
+}
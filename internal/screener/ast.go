@@ -0,0 +1,144 @@
+package screener
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Node is a node of a parsed filter. Pos is the 1-based position of the node
+// in the filter, String renders it back with only the parentheses it needs.
+type Node interface {
+	Pos() int
+	String() string
+}
+
+// Field is a company attribute, Name is its canonical spelling.
+type Field struct {
+	Name string
+	pos  int
+}
+
+type Number struct {
+	Value float64
+	pos   int
+}
+
+type String struct {
+	Value string
+	pos   int
+}
+
+type Bool struct {
+	Value bool
+	pos   int
+}
+
+// Unary is a negation, Op is "-" or "not".
+type Unary struct {
+	Op  string
+	X   Node
+	pos int
+}
+
+// Binary is an arithmetic, comparison, contains or logical operation.
+type Binary struct {
+	Op    string
+	Left  Node
+	Right Node
+	pos   int
+}
+
+// In tests X against a list of values, Negated for "not in".
+type In struct {
+	X       Node
+	Values  []Node
+	Negated bool
+	pos     int
+}
+
+func (n *Field) Pos() int  { return n.pos }
+func (n *Number) Pos() int { return n.pos }
+func (n *String) Pos() int { return n.pos }
+func (n *Bool) Pos() int   { return n.pos }
+func (n *Unary) Pos() int  { return n.pos }
+func (n *Binary) Pos() int { return n.pos }
+func (n *In) Pos() int     { return n.pos }
+
+func (n *Field) String() string  { return n.Name }
+func (n *Number) String() string { return strconv.FormatFloat(n.Value, 'f', -1, 64) }
+func (n *String) String() string { return "'" + strings.ReplaceAll(n.Value, "'", "''") + "'" }
+func (n *Bool) String() string   { return strconv.FormatBool(n.Value) }
+
+func (n *Unary) String() string {
+	if n.Op == "not" {
+		return "not " + wrap(n.X, precedenceNot, false)
+	}
+	return "-" + wrap(n.X, precedenceNegate, false)
+}
+
+func (n *Binary) String() string {
+	p := precedence(n.Op)
+	return wrap(n.Left, p, false) + " " + n.Op + " " + wrap(n.Right, p, true)
+}
+
+func (n *In) String() string {
+	values := make([]string, len(n.Values))
+	for i, v := range n.Values {
+		values[i] = v.String()
+	}
+	op := " in "
+	if n.Negated {
+		op = " not in "
+	}
+	return wrap(n.X, precedenceCompare, false) + op + "(" + strings.Join(values, ", ") + ")"
+}
+
+const (
+	precedenceOr = iota + 1
+	precedenceAnd
+	precedenceNot
+	precedenceCompare
+	precedenceAdd
+	precedenceMultiply
+	precedenceNegate
+	precedenceOperand
+)
+
+func precedence(op string) int {
+	switch op {
+	case "or":
+		return precedenceOr
+	case "and":
+		return precedenceAnd
+	case "+", "-":
+		return precedenceAdd
+	case "*", "/":
+		return precedenceMultiply
+	}
+	return precedenceCompare
+}
+
+func nodePrecedence(n Node) int {
+	switch n := n.(type) {
+	case *Binary:
+		return precedence(n.Op)
+	case *In:
+		return precedenceCompare
+	case *Unary:
+		if n.Op == "not" {
+			return precedenceNot
+		}
+		return precedenceNegate
+	}
+	return precedenceOperand
+}
+
+// wrap parenthesizes a child binding looser than its parent, or as loose on
+// the right side since operators associate to the left.
+func wrap(n Node, parent int, right bool) string {
+	p := nodePrecedence(n)
+	if p < parent || right && p == parent {
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
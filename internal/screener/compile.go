@@ -0,0 +1,317 @@
+package screener
+
+import (
+	"math"
+	"patient-chatbot/internal/mapping"
+	"strings"
+)
+
+// Filter is a parsed and type checked filter, ready to be matched against
+// quotes.
+type Filter struct {
+	root  Node
+	match func(q *Quote) bool
+}
+
+// Compile parses the filter and checks that it is a condition comparing
+// values of the same type. An empty filter matches every company.
+func Compile(source string) (*Filter, error) {
+	if strings.TrimSpace(source) == "" {
+		return &Filter{match: func(*Quote) bool { return true }}, nil
+	}
+	root, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	match, err := compileCondition(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{root: root, match: match}, nil
+}
+
+func (f *Filter) Match(q *Quote) bool {
+	return f.match(q)
+}
+
+// String is the filter in canonical form, empty when it matches everything.
+func (f *Filter) String() string {
+	if f.root == nil {
+		return ""
+	}
+	return f.root.String()
+}
+
+func kindOf(n Node) kind {
+	switch n := n.(type) {
+	case *Field:
+		f, _ := lookupField(n.Name)
+		return f.kind
+	case *Number:
+		return kindNumber
+	case *String:
+		return kindText
+	case *Unary:
+		if n.Op == "-" {
+			return kindNumber
+		}
+	case *Binary:
+		switch n.Op {
+		case "+", "-", "*", "/":
+			return kindNumber
+		}
+	}
+	return kindBool
+}
+
+func expected(n Node, want kind) error {
+	return syntaxError(n.Pos(), "expected a %s, got %s %s", want, kindOf(n), n)
+}
+
+func compileCondition(n Node) (func(q *Quote) bool, error) {
+	switch n := n.(type) {
+	case *Bool:
+		value := n.Value
+		return func(*Quote) bool { return value }, nil
+	case *Unary:
+		if n.Op != "not" {
+			break
+		}
+		x, err := compileCondition(n.X)
+		if err != nil {
+			return nil, err
+		}
+		return func(q *Quote) bool { return !x(q) }, nil
+	case *Binary:
+		switch n.Op {
+		case "and", "or":
+			left, err := compileCondition(n.Left)
+			if err != nil {
+				return nil, err
+			}
+			right, err := compileCondition(n.Right)
+			if err != nil {
+				return nil, err
+			}
+			if n.Op == "and" {
+				return func(q *Quote) bool { return left(q) && right(q) }, nil
+			}
+			return func(q *Quote) bool { return left(q) || right(q) }, nil
+		case "+", "-", "*", "/":
+		default:
+			return compileComparison(n)
+		}
+	case *In:
+		return compileIn(n)
+	}
+	return nil, expected(n, kindBool)
+}
+
+func compileComparison(n *Binary) (func(q *Quote) bool, error) {
+	leftKind, rightKind := kindOf(n.Left), kindOf(n.Right)
+	if leftKind == kindBool {
+		return nil, syntaxError(n.Left.Pos(), "%s can't be compared, only numbers and text can", n.Left)
+	}
+	if leftKind != rightKind {
+		return nil, syntaxError(n.Pos(), "can't compare %s %s with %s %s", leftKind, n.Left, rightKind, n.Right)
+	}
+
+	if leftKind == kindText {
+		left, err := compileText(n.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := compileText(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case "=":
+			return func(q *Quote) bool { return anyMatch(left(q), right(q), equal) }, nil
+		case "!=":
+			return func(q *Quote) bool { return !anyMatch(left(q), right(q), equal) }, nil
+		case "contains":
+			return func(q *Quote) bool { return anyMatch(left(q), right(q), strings.Contains) }, nil
+		}
+		return nil, syntaxError(n.Pos(), "text can only be compared with =, != or contains, not %s", n.Op)
+	}
+
+	if n.Op == "contains" {
+		return nil, syntaxError(n.Pos(), "contains only applies to text")
+	}
+	left, err := compileNumber(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := compileNumber(n.Right)
+	if err != nil {
+		return nil, err
+	}
+	var compare func(a, b float64) bool
+	switch n.Op {
+	case "=":
+		compare = func(a, b float64) bool { return a == b }
+	case "!=":
+		compare = func(a, b float64) bool { return a != b }
+	case "<":
+		compare = func(a, b float64) bool { return a < b }
+	case "<=":
+		compare = func(a, b float64) bool { return a <= b }
+	case ">":
+		compare = func(a, b float64) bool { return a > b }
+	case ">=":
+		compare = func(a, b float64) bool { return a >= b }
+	}
+	// @NOTE: A value undefined for a company, like a division by zero, matches nothing
+	return func(q *Quote) bool {
+		a, b := left(q), right(q)
+		return !math.IsNaN(a) && !math.IsNaN(b) && compare(a, b)
+	}, nil
+}
+
+func compileIn(n *In) (func(q *Quote) bool, error) {
+	k := kindOf(n.X)
+	if k == kindBool {
+		return nil, syntaxError(n.X.Pos(), "%s can't be compared, only numbers and text can", n.X)
+	}
+	for _, v := range n.Values {
+		if kindOf(v) != k {
+			return nil, expected(v, k)
+		}
+	}
+
+	var match func(q *Quote) bool
+	if k == kindText {
+		x, err := compileText(n.X)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]func(q *Quote) []string, len(n.Values))
+		for i, v := range n.Values {
+			if values[i], err = compileText(v); err != nil {
+				return nil, err
+			}
+		}
+		match = func(q *Quote) bool {
+			spellings := x(q)
+			for _, value := range values {
+				if anyMatch(spellings, value(q), equal) {
+					return true
+				}
+			}
+			return false
+		}
+	} else {
+		x, err := compileNumber(n.X)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]func(q *Quote) float64, len(n.Values))
+		for i, v := range n.Values {
+			if values[i], err = compileNumber(v); err != nil {
+				return nil, err
+			}
+		}
+		match = func(q *Quote) bool {
+			a := x(q)
+			for _, value := range values {
+				if a == value(q) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if n.Negated {
+		return func(q *Quote) bool { return !match(q) }, nil
+	}
+	return match, nil
+}
+
+func compileNumber(n Node) (func(q *Quote) float64, error) {
+	switch n := n.(type) {
+	case *Number:
+		value := n.Value
+		return func(*Quote) float64 { return value }, nil
+	case *Field:
+		if f, _ := lookupField(n.Name); f.kind == kindNumber {
+			return f.number, nil
+		}
+	case *Unary:
+		if n.Op != "-" {
+			break
+		}
+		x, err := compileNumber(n.X)
+		if err != nil {
+			return nil, err
+		}
+		return func(q *Quote) float64 { return -x(q) }, nil
+	case *Binary:
+		var operation func(a, b float64) float64
+		switch n.Op {
+		case "+":
+			operation = func(a, b float64) float64 { return a + b }
+		case "-":
+			operation = func(a, b float64) float64 { return a - b }
+		case "*":
+			operation = func(a, b float64) float64 { return a * b }
+		case "/":
+			operation = func(a, b float64) float64 {
+				if b == 0 {
+					return math.NaN()
+				}
+				return a / b
+			}
+		default:
+			return nil, expected(n, kindNumber)
+		}
+		left, err := compileNumber(n.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := compileNumber(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		return func(q *Quote) float64 { return operation(left(q), right(q)) }, nil
+	}
+	return nil, expected(n, kindNumber)
+}
+
+// compileText returns the normalized spellings of a text value.
+func compileText(n Node) (func(q *Quote) []string, error) {
+	switch n := n.(type) {
+	case *String:
+		value := []string{mapping.Normalize(n.Value)}
+		return func(*Quote) []string { return value }, nil
+	case *Field:
+		if f, _ := lookupField(n.Name); f.kind == kindText {
+			return func(q *Quote) []string {
+				spellings := f.text(q)
+				normalized := make([]string, 0, len(spellings))
+				for _, s := range spellings {
+					if s != "" {
+						normalized = append(normalized, mapping.Normalize(s))
+					}
+				}
+				return normalized
+			}, nil
+		}
+	}
+	return nil, expected(n, kindText)
+}
+
+func equal(a, b string) bool {
+	return a == b
+}
+
+func anyMatch(left []string, right []string, match func(a, b string) bool) bool {
+	for _, a := range left {
+		for _, b := range right {
+			if match(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
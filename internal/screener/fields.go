@@ -0,0 +1,95 @@
+package screener
+
+import (
+	"patient-chatbot/internal/market"
+	"sort"
+	"strings"
+)
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindNumber
+	kindText
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindText:
+		return "text"
+	}
+	return "condition"
+}
+
+// field is a company attribute a filter can test and results can be sorted
+// by. Text fields have every spelling a value is matched against, the first
+// one is used for sorting.
+type field struct {
+	name   string
+	kind   kind
+	number func(q *Quote) float64
+	text   func(q *Quote) []string
+}
+
+func numberField(name string, number func(q *Quote) float64) field {
+	return field{name: name, kind: kindNumber, number: number}
+}
+
+func textField(name string, text func(q *Quote) []string) field {
+	return field{name: name, kind: kindText, text: text}
+}
+
+// @NOTE: English names match the Arabic spelling too, like the directory sector filter
+var fields = []field{
+	textField("tadawulId", func(q *Quote) []string { return []string{q.TadawulID} }),
+	textField("companyName", func(q *Quote) []string { return []string{q.CompanyName, q.CompanyNameAr} }),
+	textField("companyNameAr", func(q *Quote) []string { return []string{q.CompanyNameAr} }),
+	textField("acronymName", func(q *Quote) []string { return []string{q.AcronymName, q.AcronymNameAr} }),
+	textField("acronymNameAr", func(q *Quote) []string { return []string{q.AcronymNameAr} }),
+	textField("sector", func(q *Quote) []string { return []string{q.Sector, q.SectorAr} }),
+	textField("sectorAr", func(q *Quote) []string { return []string{q.SectorAr} }),
+	numberField("price", func(q *Quote) float64 { return q.Price }),
+	numberField("change", func(q *Quote) float64 { return q.Change }),
+	numberField("changePercentage", func(q *Quote) float64 { return q.ChangePercentage }),
+	numberField("openPrice", func(q *Quote) float64 { return q.OpenPrice }),
+	numberField("highPrice", func(q *Quote) float64 { return q.HighPrice }),
+	numberField("lowPrice", func(q *Quote) float64 { return q.LowPrice }),
+	numberField("highest52Price", func(q *Quote) float64 { return q.Highest52Price }),
+	numberField("lowest52Price", func(q *Quote) float64 { return q.Lowest52Price }),
+	numberField("volume", func(q *Quote) float64 { return float64(q.Volume) }),
+	numberField("numberOfTrades", func(q *Quote) float64 { return float64(q.NumberOfTrades) }),
+	numberField("tradedValue", func(q *Quote) float64 { return market.TradedValue(q.MarketWatchResponse) }),
+	numberField("bestBidPrice", func(q *Quote) float64 { return q.BestBidPrice }),
+	numberField("bestAskPrice", func(q *Quote) float64 { return q.BestAskPrice }),
+}
+
+var fieldsByName = func() map[string]field {
+	byName := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byName[strings.ToLower(f.name)] = f
+	}
+	return byName
+}()
+
+func lookupField(name string) (field, bool) {
+	f, ok := fieldsByName[strings.ToLower(name)]
+	return f, ok
+}
+
+// Fields returns the names of the fields filters can use, in alphabetical
+// order.
+func Fields() []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func fieldList() string {
+	return strings.Join(Fields(), ", ")
+}
@@ -0,0 +1,148 @@
+package screener
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	// text is the operator or the lower cased keyword for operators, the
+	// unquoted value for strings and the source text otherwise.
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keywords are matched case insensitively and lexed as operators.
+var keywords = map[string]bool{
+	"and":      true,
+	"or":       true,
+	"not":      true,
+	"in":       true,
+	"contains": true,
+	"true":     true,
+	"false":    true,
+}
+
+// lex splits the filter into tokens, positions are 1-based rune offsets.
+func lex(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: pos})
+			i++
+		case r == '\'' || r == '"':
+			value, next, err := lexString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: pos})
+			i = next
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: pos})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			word := string(runes[start:i])
+			if lower := strings.ToLower(word); keywords[lower] {
+				tokens = append(tokens, token{kind: tokenOperator, text: lower, pos: pos})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, text: word, pos: pos})
+			}
+		default:
+			operator, width := lexOperator(runes[i:])
+			if width == 0 {
+				return nil, syntaxError(pos, "unexpected character %q", r)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: pos})
+			i += width
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+// lexString reads a quoted string starting at runes[start], a doubled quote
+// stands for the quote itself.
+func lexString(runes []rune, start int) (string, int, error) {
+	quote := runes[start]
+	var b strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] != quote {
+			b.WriteRune(runes[i])
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == quote {
+			b.WriteRune(quote)
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, syntaxError(start+1, "unterminated string")
+}
+
+// lexOperator matches the longest symbol operator at the start of runes and
+// returns its canonical spelling and length, 0 when there is none.
+func lexOperator(runes []rune) (string, int) {
+	if len(runes) >= 2 {
+		switch string(runes[:2]) {
+		case "<=", ">=", "!=":
+			return string(runes[:2]), 2
+		case "==":
+			return "=", 2
+		case "<>":
+			return "!=", 2
+		case "&&":
+			return "and", 2
+		case "||":
+			return "or", 2
+		}
+	}
+	switch runes[0] {
+	case '=', '<', '>', '+', '-', '*', '/':
+		return string(runes[0]), 1
+	}
+	return "", 0
+}
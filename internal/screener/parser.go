@@ -0,0 +1,300 @@
+package screener
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	MaxFilterLength = 1000
+	// maxDepth bounds the nesting of parentheses and negations.
+	maxDepth = 32
+)
+
+var ErrInvalidQuery = errors.New("invalid screener query")
+
+// SyntaxError is a filter that does not parse or type check, Position is the
+// 1-based character it was detected at.
+type SyntaxError struct {
+	Message  string `json:"message"`
+	Position int    `json:"position"`
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return ErrInvalidQuery
+}
+
+func syntaxError(pos int, format string, args ...any) error {
+	return &SyntaxError{Message: fmt.Sprintf(format, args...), Position: pos}
+}
+
+// Parse parses a filter such as
+//
+//	sector = 'Banks' and changePercentage > 2 and price < highest52Price * 0.8
+//
+// into its AST. Keywords and field names are case insensitive.
+func Parse(source string) (Node, error) {
+	if len([]rune(source)) > MaxFilterLength {
+		return nil, syntaxError(MaxFilterLength+1, "filter is longer than %d characters", MaxFilterLength)
+	}
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, syntaxError(t.pos, "unexpected %s", t)
+	}
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+// accept consumes the next token when it is one of the operators.
+func (p *parser) accept(operators ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return t, false
+	}
+	for _, op := range operators {
+		if t.text == op {
+			return p.advance(), true
+		}
+	}
+	return t, false
+}
+
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return syntaxError(pos, "filter is nested deeper than %d levels", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) or() (Node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("or")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: "or", Left: left, Right: right, pos: t.pos}
+	}
+}
+
+func (p *parser) and() (Node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("and")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: "and", Left: left, Right: right, pos: t.pos}
+	}
+}
+
+func (p *parser) not() (Node, error) {
+	t, ok := p.accept("not")
+	if !ok {
+		return p.comparison()
+	}
+	if err := p.enter(t.pos); err != nil {
+		return nil, err
+	}
+	x, err := p.not()
+	p.depth--
+	if err != nil {
+		return nil, err
+	}
+	return &Unary{Op: "not", X: x, pos: t.pos}, nil
+}
+
+func (p *parser) comparison() (Node, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.accept("=", "!=", "<", "<=", ">", ">=", "contains"); ok {
+		right, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return &Binary{Op: t.text, Left: left, Right: right, pos: t.pos}, nil
+	}
+
+	t := p.peek()
+	negated := false
+	if t.kind == tokenOperator && t.text == "not" && p.tokens[p.next+1].text == "in" {
+		p.advance()
+		negated = true
+	}
+	if _, ok := p.accept("in"); !ok {
+		if negated {
+			return nil, syntaxError(p.peek().pos, "expected in after not")
+		}
+		return left, nil
+	}
+	values, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+	return &In{X: left, Values: values, Negated: negated, pos: t.pos}, nil
+}
+
+// list parses the parenthesized literals of an in operator.
+func (p *parser) list() ([]Node, error) {
+	if t := p.advance(); t.kind != tokenLeftParen {
+		return nil, syntaxError(t.pos, "expected ( after in, got %s", t)
+	}
+	var values []Node
+	for {
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		t := p.advance()
+		switch t.kind {
+		case tokenComma:
+			continue
+		case tokenRightParen:
+			return values, nil
+		}
+		return nil, syntaxError(t.pos, "expected , or ) in list, got %s", t)
+	}
+}
+
+func (p *parser) additive() (Node, error) {
+	left, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: t.text, Left: left, Right: right, pos: t.pos}
+	}
+}
+
+func (p *parser) multiplicative() (Node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: t.text, Left: left, Right: right, pos: t.pos}
+	}
+}
+
+func (p *parser) unary() (Node, error) {
+	t, ok := p.accept("-")
+	if !ok {
+		return p.operand()
+	}
+	if err := p.enter(t.pos); err != nil {
+		return nil, err
+	}
+	x, err := p.unary()
+	p.depth--
+	if err != nil {
+		return nil, err
+	}
+	// @NOTE: Folds negative literals so they render and compare as numbers
+	if number, ok := x.(*Number); ok {
+		return &Number{Value: -number.Value, pos: t.pos}, nil
+	}
+	return &Unary{Op: "-", X: x, pos: t.pos}, nil
+}
+
+func (p *parser) operand() (Node, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, syntaxError(t.pos, "invalid number %s", t)
+		}
+		return &Number{Value: value, pos: t.pos}, nil
+	case tokenString:
+		return &String{Value: t.text, pos: t.pos}, nil
+	case tokenIdent:
+		field, ok := lookupField(t.text)
+		if !ok {
+			return nil, syntaxError(t.pos, "unknown field %s, known fields are %s", t, fieldList())
+		}
+		return &Field{Name: field.name, pos: t.pos}, nil
+	case tokenOperator:
+		if t.text == "true" || t.text == "false" {
+			return &Bool{Value: t.text == "true", pos: t.pos}, nil
+		}
+	case tokenLeftParen:
+		if err := p.enter(t.pos); err != nil {
+			return nil, err
+		}
+		node, err := p.or()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != tokenRightParen {
+			return nil, syntaxError(closing.pos, "expected ) to close the ( at position %d, got %s", t.pos, closing)
+		}
+		return node, nil
+	}
+	return nil, syntaxError(t.pos, "unexpected %s", t)
+}
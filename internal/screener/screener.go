@@ -0,0 +1,155 @@
+package screener
+
+import (
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/market"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultSort     = "tradedValue"
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Quote is a company's latest quote. Stale marks companies missing from the
+// market watch, priced from the company directory snapshot instead.
+type Quote struct {
+	stock.MarketWatchResponse
+	Stale bool
+}
+
+// Query is a filter with the order and page of the matches to return. Sort
+// is a field name and Order asc or desc, they default to the highest traded
+// value first.
+type Query struct {
+	Filter   string
+	Sort     string
+	Order    string
+	Page     int
+	PageSize int
+}
+
+// Match is a company the filter matched.
+type Match struct {
+	market.Mover
+	OpenPrice      float64 `json:"openPrice"`
+	HighPrice      float64 `json:"highPrice"`
+	LowPrice       float64 `json:"lowPrice"`
+	Highest52Price float64 `json:"highest52Price"`
+	Lowest52Price  float64 `json:"lowest52Price"`
+	NumberOfTrades int     `json:"numberOfTrades"`
+	Stale          bool    `json:"stale,omitempty"`
+}
+
+func newMatch(q Quote) Match {
+	return Match{
+		Mover:          market.NewMover(q.MarketWatchResponse),
+		OpenPrice:      q.OpenPrice,
+		HighPrice:      q.HighPrice,
+		LowPrice:       q.LowPrice,
+		Highest52Price: q.Highest52Price,
+		Lowest52Price:  q.Lowest52Price,
+		NumberOfTrades: q.NumberOfTrades,
+		Stale:          q.Stale,
+	}
+}
+
+// Result is a page of the matches. Filter is the query filter in canonical
+// form, Total the number of companies it matched.
+type Result struct {
+	Filter    string  `json:"filter"`
+	Sort      string  `json:"sort"`
+	Order     string  `json:"order"`
+	Companies []Match `json:"companies"`
+	Total     int     `json:"total"`
+	Page      int     `json:"page"`
+	PageSize  int     `json:"pageSize"`
+}
+
+// Screen returns the page of quotes matching the query filter.
+func Screen(quotes []Quote, query Query) (*Result, error) {
+	filter, err := Compile(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Sort == "" {
+		query.Sort = DefaultSort
+	}
+	sortField, ok := lookupField(query.Sort)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort field %q, known fields are %s", ErrInvalidQuery, query.Sort, fieldList())
+	}
+	order := strings.ToLower(query.Order)
+	switch order {
+	case "":
+		order = "desc"
+	case "asc", "desc":
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc, got %q", ErrInvalidQuery, query.Order)
+	}
+	page, pageSize := max(query.Page, 1), query.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	pageSize = min(pageSize, MaxPageSize)
+
+	var matches []Quote
+	for i := range quotes {
+		if filter.Match(&quotes[i]) {
+			matches = append(matches, quotes[i])
+		}
+	}
+	sortQuotes(matches, sortField, order == "desc")
+
+	result := &Result{
+		Filter:    filter.String(),
+		Sort:      sortField.name,
+		Order:     order,
+		Companies: []Match{},
+		Total:     len(matches),
+		Page:      page,
+		PageSize:  pageSize,
+	}
+	// @NOTE: Pages past the end are clamped before multiplying so a huge page can't overflow
+	start := len(matches)
+	if page-1 < len(matches)/pageSize+1 {
+		start = min((page-1)*pageSize, len(matches))
+	}
+	end := min(start+pageSize, len(matches))
+	for _, q := range matches[start:end] {
+		result.Companies = append(result.Companies, newMatch(q))
+	}
+	return result, nil
+}
+
+// sortQuotes orders the quotes by the field, ties by tadawul id so pages
+// don't overlap.
+func sortQuotes(quotes []Quote, f field, descending bool) {
+	compare := func(a, b *Quote) int {
+		if f.kind == kindNumber {
+			x, y := f.number(a), f.number(b)
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+		return strings.Compare(strings.ToLower(f.text(a)[0]), strings.ToLower(f.text(b)[0]))
+	}
+	sort.SliceStable(quotes, func(i, j int) bool {
+		c := compare(&quotes[i], &quotes[j])
+		if descending {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return quotes[i].TadawulID < quotes[j].TadawulID
+	})
+}
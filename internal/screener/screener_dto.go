@@ -0,0 +1,10 @@
+package screener
+
+const FunctionScreenStocks = "ScreenStocks"
+
+type ScreenStocksArguments struct {
+	Filter string `json:"filter" description:"Filter expression, e.g. sector = 'Banks' and changePercentage > 2 and price < highest52Price * 0.8. Compare fields with = != < <= > >=, text with = != contains or in ('a', 'b'), combine with and, or, not and parentheses, compute with + - * /. Text is quoted with single quotes and matched case insensitively, in English or Arabic. Leave empty to rank every company"`
+	Sort   string `json:"sort,omitempty" description:"Field to rank the companies by. Defaults to tradedValue"`
+	Order  string `json:"order,omitempty" enum:"asc,desc" description:"Sort order. Defaults to desc, the highest first"`
+	Limit  int    `json:"limit,omitempty" description:"How many companies to return, up to 25. Defaults to 10"`
+}
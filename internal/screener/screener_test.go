@@ -0,0 +1,209 @@
+package screener
+
+import (
+	"errors"
+	"math"
+	"patient-chatbot/internal/client/stock"
+	"strings"
+	"testing"
+)
+
+func quote(tadawulID string, sector string, price float64, changePercentage float64, volume int) Quote {
+	return Quote{MarketWatchResponse: stock.MarketWatchResponse{
+		TadawulID:        tadawulID,
+		Sector:           sector,
+		Price:            price,
+		ChangePercentage: changePercentage,
+		Volume:           volume,
+	}}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "and binds tighter than or", source: "price > 1 or price < 2 and volume > 3", want: "price > 1 or price < 2 and volume > 3"},
+		{name: "parentheses override precedence", source: "(price > 1 or price < 2) and volume > 3", want: "(price > 1 or price < 2) and volume > 3"},
+		{name: "multiplication binds tighter than addition", source: "price + change * 2 > 3", want: "price + change * 2 > 3"},
+		{name: "redundant parentheses are dropped", source: "(price * 2) + change > 3", want: "price * 2 + change > 3"},
+		{name: "operators associate to the left", source: "price - (change - 1) > 0", want: "price - (change - 1) > 0"},
+		{name: "not applies to the comparison", source: "not price > 1 and volume > 2", want: "not price > 1 and volume > 2"},
+		{name: "not in", source: "sector not in ('Banks', 'Energy')", want: "sector not in ('Banks', 'Energy')"},
+		{name: "negative literal is folded", source: "changePercentage < -2.5", want: "changePercentage < -2.5"},
+		{name: "negative literal in a list", source: "changePercentage in (-1, 2)", want: "changePercentage in (-1, 2)"},
+		{name: "negated field", source: "-change > 1", want: "-change > 1"},
+		{name: "keywords and fields are case insensitive", source: "PRICE > 1 AND Sector = 'Banks'", want: "price > 1 and sector = 'Banks'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFolding(t *testing.T) {
+	node, err := Parse("price > -2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	right := node.(*Binary).Right
+	if number, ok := right.(*Number); !ok || number.Value != -2 {
+		t.Errorf("right operand = %#v, want the number -2", right)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		position int
+		message  string
+	}{
+		{name: "unknown field", source: "price > 1 and foo = 2", position: 15, message: "unknown field"},
+		{name: "missing operand", source: "price >", position: 8, message: "unexpected"},
+		{name: "unclosed parenthesis", source: "(price > 1", position: 11, message: "expected ) to close the ( at position 1"},
+		{name: "trailing token", source: "price > 1 2", position: 11, message: "unexpected"},
+		{name: "not without in", source: "sector not 'Banks'", position: 8, message: "unexpected \"not\""},
+		{name: "list without parenthesis", source: "sector in 'Banks'", position: 11, message: "expected ( after in"},
+		{name: "unterminated string", source: "sector = 'Banks", position: 10, message: ""},
+		{name: "number compared with text", source: "price = 'x'", position: 7, message: "can't compare"},
+		{name: "text in arithmetic", source: "sector + 1 > 2", position: 1, message: ""},
+		{name: "number is not a condition", source: "price and volume > 1", position: 1, message: ""},
+		{name: "too deep", source: strings.Repeat("not ", maxDepth+1) + "price > 1", position: 4*maxDepth + 1, message: "nested deeper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.source)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Compile(%q) error = %v, want a SyntaxError", tt.source, err)
+			}
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("error %v does not wrap ErrInvalidQuery", err)
+			}
+			if syntaxErr.Position != tt.position {
+				t.Errorf("position = %d, want %d (%s)", syntaxErr.Position, tt.position, syntaxErr.Message)
+			}
+			if !strings.Contains(syntaxErr.Message, tt.message) {
+				t.Errorf("message = %q, want it to contain %q", syntaxErr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	banks := quote("1120", "Banks", 100, 2, 1000)
+	energy := quote("2222", "Energy", 25, -3, 0)
+
+	tests := []struct {
+		name   string
+		source string
+		want   map[string]bool
+	}{
+		{name: "empty filter matches everything", source: "", want: map[string]bool{"1120": true, "2222": true}},
+		{name: "comparison", source: "price > 50", want: map[string]bool{"1120": true, "2222": false}},
+		{name: "and before or", source: "sector = 'Energy' or price > 50 and changePercentage < 0", want: map[string]bool{"1120": false, "2222": true}},
+		{name: "parenthesized or", source: "(sector = 'Energy' or price > 50) and changePercentage < 0", want: map[string]bool{"1120": false, "2222": true}},
+		{name: "in", source: "sector in ('banks', 'Materials')", want: map[string]bool{"1120": true, "2222": false}},
+		{name: "not in", source: "sector not in ('Banks')", want: map[string]bool{"1120": false, "2222": true}},
+		{name: "negative literal", source: "changePercentage <= -3", want: map[string]bool{"1120": false, "2222": true}},
+		{name: "arithmetic", source: "price * changePercentage / 100 > 1", want: map[string]bool{"1120": true, "2222": false}},
+		{name: "division by zero matches no comparison", source: "price / volume >= 0", want: map[string]bool{"1120": true, "2222": false}},
+		{name: "division by zero fails not equal too", source: "price / volume != 1", want: map[string]bool{"1120": true, "2222": false}},
+		{name: "contains", source: "sector contains 'erg'", want: map[string]bool{"1120": false, "2222": true}},
+		{name: "not", source: "not sector = 'Banks'", want: map[string]bool{"1120": false, "2222": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := Compile(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, q := range []Quote{banks, energy} {
+				if got := filter.Match(&q); got != tt.want[q.TadawulID] {
+					t.Errorf("Match(%s) = %v, want %v", q.TadawulID, got, tt.want[q.TadawulID])
+				}
+			}
+		})
+	}
+}
+
+func TestDivisionByZeroIsNaN(t *testing.T) {
+	node, err := Parse("price / volume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	number, err := compileNumber(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := quote("2222", "Energy", 25, 0, 0)
+	if value := number(&q); !math.IsNaN(value) {
+		t.Errorf("price / volume = %v with no volume, want NaN", value)
+	}
+}
+
+func TestScreen(t *testing.T) {
+	quotes := []Quote{
+		quote("1120", "Banks", 100, 2, 1000),
+		quote("1180", "Banks", 40, 1, 3000),
+		quote("2222", "Energy", 25, -3, 5000),
+		quote("2010", "Materials", 80, 4, 2000),
+	}
+
+	tests := []struct {
+		name      string
+		query     Query
+		wantIDs   []string
+		wantTotal int
+		wantPage  int
+		wantSize  int
+		wantErr   error
+	}{
+		{name: "defaults sort by traded value", query: Query{}, wantIDs: []string{"2010", "2222", "1180", "1120"}, wantTotal: 4, wantPage: 1, wantSize: DefaultPageSize},
+		{name: "sort ascending", query: Query{Filter: "sector = 'Banks'", Sort: "price", Order: "asc"}, wantIDs: []string{"1180", "1120"}, wantTotal: 2, wantPage: 1, wantSize: DefaultPageSize},
+		{name: "second page", query: Query{Sort: "price", Order: "desc", Page: 2, PageSize: 3}, wantIDs: []string{"2222"}, wantTotal: 4, wantPage: 2, wantSize: 3},
+		{name: "page past the end", query: Query{Page: 3, PageSize: 2}, wantIDs: []string{}, wantTotal: 4, wantPage: 3, wantSize: 2},
+		{name: "huge page does not overflow", query: Query{Page: math.MaxInt, PageSize: MaxPageSize}, wantIDs: []string{}, wantTotal: 4, wantPage: math.MaxInt, wantSize: MaxPageSize},
+		{name: "page size is capped", query: Query{PageSize: MaxPageSize + 1}, wantIDs: []string{"2010", "2222", "1180", "1120"}, wantTotal: 4, wantPage: 1, wantSize: MaxPageSize},
+		{name: "unknown sort field", query: Query{Sort: "foo"}, wantErr: ErrInvalidQuery},
+		{name: "unknown order", query: Query{Order: "up"}, wantErr: ErrInvalidQuery},
+		{name: "invalid filter", query: Query{Filter: "price >"}, wantErr: ErrInvalidQuery},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Screen(quotes, tt.query)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]string, len(result.Companies))
+			for i, match := range result.Companies {
+				ids[i] = match.TadawulID
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("companies = %v, want %v", ids, tt.wantIDs)
+			}
+			if result.Total != tt.wantTotal || result.Page != tt.wantPage || result.PageSize != tt.wantSize {
+				t.Errorf("total, page, page size = %d, %d, %d, want %d, %d, %d", result.Total, result.Page, result.PageSize, tt.wantTotal, tt.wantPage, tt.wantSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"patient-chatbot/internal/alert"
+	"patient-chatbot/internal/user"
+	"strings"
+)
+
+const (
+	defaultAlertHistory = 50
+	maxAlertHistory     = 200
+)
+
+func (s *Service) ListAlerts(ctx context.Context) ([]alert.Rule, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.alerts.ListRules(ctx, userID)
+}
+
+func (s *Service) CreateAlert(ctx context.Context, rule alert.Rule) (*alert.Rule, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rule.WebhookURL = strings.TrimSpace(rule.WebhookURL)
+	if err := rule.Validate(); err != nil {
+		return nil, fmt.Errorf("service :: CreateAlert :: %w", err)
+	}
+	if rule.WebhookURL != "" && s.cfg.AlertWebhookSecret == "" {
+		return nil, fmt.Errorf("service :: CreateAlert :: %w", alert.ErrWebhooksDisabled)
+	}
+	if err := knownCompanies([]string{rule.TadawulID}); err != nil {
+		return nil, fmt.Errorf("service :: CreateAlert :: %w", err)
+	}
+	return s.alerts.CreateRule(ctx, userID, rule)
+}
+
+func (s *Service) DeleteAlert(ctx context.Context, id string) error {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return err
+	}
+	return s.alerts.DeleteRule(ctx, userID, id)
+}
+
+// ListAlertHistory returns the user's fired alerts, latest first, optionally
+// only those with status.
+func (s *Service) ListAlertHistory(ctx context.Context, status alert.Status, limit int) ([]alert.Event, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultAlertHistory
+	}
+	return s.alerts.ListEvents(ctx, userID, status, min(limit, maxAlertHistory))
+}
+
+// RetryAlertEvent queues a dead lettered event for delivery again.
+func (s *Service) RetryAlertEvent(ctx context.Context, id string) (*alert.Event, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.alerts.RetryEvent(ctx, userID, id)
+}
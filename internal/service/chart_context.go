@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"patient-chatbot/internal/alert"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/dto"
+	"patient-chatbot/internal/indicators"
+	"patient-chatbot/internal/market"
+	"patient-chatbot/internal/portfolio"
+	"patient-chatbot/internal/screener"
+	"patient-chatbot/internal/tool"
+	"patient-chatbot/internal/watchlist"
+	"sort"
+	"strings"
+)
+
+const maxContextSeriesPoints = 12
+
+// registerCharts declares how the chart of every tool result is summarized
+// for the model when the user asks about it. The indicators report has a
+// close too, so it is probed before the price series.
+func (s *Service) registerCharts() {
+	s.tools.RegisterCharts(
+		tool.NewChart(dto.ChartsScreener, "filter", renderScreenerContext),
+		tool.NewChart(dto.ChartsAlerts, "alerts", renderAlertsContext),
+		tool.NewChart(dto.ChartsPortfolio, "allocation", renderPortfolioContext),
+		tool.NewChart(dto.ChartsWatchlist, "watchlist", renderWatchlistContext),
+		tool.NewChart(dto.ChartsSectorPerformance, "sectors", renderSectorsContext),
+		tool.NewChart(dto.ChartsTechnicalIndicators, "indicators", renderIndicatorsContext),
+		tool.NewChart(dto.ChartsDetailedCompanyStockPrices, "close", renderSeriesContext),
+		tool.NewChart(dto.ChartsSearchCompanyStocks, "tadawulID", renderSearchContext),
+	)
+}
+
+func renderSearchContext(companies tool.List[stock.SearchCompanyStocksResponse]) string {
+	if len(companies) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("The user is looking at these companies:\n")
+	for _, c := range companies {
+		fmt.Fprintf(&b, "- %s, tadawul id %s", joinNonEmpty(" / ", c.CompanyName, c.AcrynomName, c.CompanyNameAr), c.TadawulID)
+		if sector := joinNonEmpty(" / ", c.Sector, c.SectorAr); sector != "" {
+			fmt.Fprintf(&b, ", sector %s", sector)
+		}
+		fmt.Fprintf(&b, ": price %.2f SAR, change %+.2f (%+.2f%%)\n", c.Price, c.Change, c.ChangePercent)
+	}
+	return b.String()
+}
+
+func renderIndicatorsContext(report indicators.Report) string {
+	if report.Bars == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "The user is looking at technical indicators for tadawul id %s over %d bars from %s to %s", report.TadawulID, report.Bars, report.From, report.To)
+	if report.Close.Valid() {
+		fmt.Fprintf(&b, ", latest close %.2f SAR", float64(report.Close))
+	}
+	b.WriteString(":\n")
+	for _, indicator := range report.Indicators {
+		keys := make([]string, 0, len(indicator.Latest))
+		for key := range indicator.Latest {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		readings := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if value := indicator.Latest[key]; value.Valid() {
+				readings = append(readings, fmt.Sprintf("%s %.2f", key, float64(value)))
+			}
+		}
+		if len(readings) == 0 {
+			fmt.Fprintf(&b, "- %s: not enough bars\n", strings.ToUpper(string(indicator.Name)))
+			continue
+		}
+		fmt.Fprintf(&b, "- %s: %s", strings.ToUpper(string(indicator.Name)), strings.Join(readings, ", "))
+		if indicator.Signal != "" {
+			fmt.Fprintf(&b, " (%s)", indicator.Signal)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderSectorsContext(overview market.SectorsOverview) string {
+	if len(overview.Sectors) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "The user is looking at sector performance: %d advancers, %d decliners, %d unchanged, traded value %.0f SAR:\n",
+		overview.Advancers, overview.Decliners, overview.Unchanged, overview.TradedValue)
+	for _, s := range overview.Sectors {
+		fmt.Fprintf(&b, "- %s: weighted change %+.2f%%, average change %+.2f%%, %d up / %d down, traded value %.0f SAR",
+			joinNonEmpty(" / ", s.Sector, s.SectorAr), s.WeightedChangePercentage, s.AverageChangePercentage, s.Advancers, s.Decliners, s.TradedValue)
+		if len(s.TopGainers) > 0 {
+			fmt.Fprintf(&b, ", top gainer %s %+.2f%%", s.TopGainers[0].CompanyName, s.TopGainers[0].ChangePercentage)
+		}
+		if len(s.TopLosers) > 0 {
+			fmt.Fprintf(&b, ", top loser %s %+.2f%%", s.TopLosers[0].CompanyName, s.TopLosers[0].ChangePercentage)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderWatchlistContext(watchlists tool.List[watchlist.Quotes]) string {
+	var b strings.Builder
+	for _, w := range watchlists {
+		fmt.Fprintf(&b, "The user is looking at their watchlist %q: %d up, %d down, average change %+.2f%%:\n",
+			w.Watchlist.Name, w.Breadth.Advancers, w.Breadth.Decliners, w.AverageChangePercentage)
+		for _, q := range w.Quotes {
+			fmt.Fprintf(&b, "- %s (%s): %.2f SAR, %+.2f%%", joinNonEmpty(" / ", q.CompanyName, q.CompanyNameAr), q.TadawulID, q.Price, q.ChangePercentage)
+			if q.Stale {
+				b.WriteString(" (last known price)")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func renderPortfolioContext(report portfolio.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The user is looking at their portfolio: market value %.2f SAR, cost %.2f SAR, unrealized P&L %+.2f SAR (%+.2f%%), realized P&L %+.2f SAR, today %+.2f SAR (%+.2f%%).\n",
+		report.MarketValue, report.CostBasis, report.UnrealizedPnL, report.UnrealizedPnLPercentage, report.RealizedPnL, report.DayChange, report.DayChangePercentage)
+	for _, p := range report.Positions {
+		fmt.Fprintf(&b, "- %s (%s): %d shares at average cost %.2f, price %.2f, unrealized %+.2f SAR (%+.2f%%), %.2f%% of the portfolio\n",
+			joinNonEmpty(" / ", p.CompanyName, p.CompanyNameAr), p.TadawulID, p.Quantity, p.AverageCost, p.Price, p.UnrealizedPnL, p.UnrealizedPnLPercentage, p.Weight)
+	}
+	for _, a := range report.Allocation {
+		fmt.Fprintf(&b, "- Sector %s: %.2f%% (%.2f SAR)\n", joinNonEmpty(" / ", a.Sector, a.SectorAr), a.Weight, a.MarketValue)
+	}
+	return b.String()
+}
+
+func renderAlertsContext(summary alert.Summary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The user is looking at their %d price alerts:\n", len(summary.Alerts))
+	for _, r := range summary.Alerts {
+		fmt.Fprintf(&b, "- %s %s", r.TadawulID, r.Condition)
+		if r.Threshold != 0 {
+			fmt.Fprintf(&b, " %g", r.Threshold)
+		}
+		if r.Repeat {
+			b.WriteString(", repeating")
+		}
+		if r.Triggered {
+			b.WriteString(", triggered")
+		}
+		b.WriteString("\n")
+	}
+	for _, e := range summary.History {
+		fmt.Fprintf(&b, "- Fired %s: %s %s at %.2f SAR (value %g), %s\n",
+			e.TriggeredAt.Format("2006-01-02 15:04"), e.TadawulID, e.Condition, e.Price, e.Value, e.Status)
+	}
+	return b.String()
+}
+
+func renderScreenerContext(result screener.Result) string {
+	var b strings.Builder
+	filter := result.Filter
+	if filter == "" {
+		filter = "every company"
+	}
+	fmt.Fprintf(&b, "The user is looking at stock screener results for %s: %d companies match, sorted by %s %s, page %d:\n",
+		filter, result.Total, result.Sort, result.Order, result.Page)
+	for _, c := range result.Companies {
+		fmt.Fprintf(&b, "- %s (%s), %s: %.2f SAR, %+.2f%%, 52 week range %.2f to %.2f, traded value %.0f SAR",
+			joinNonEmpty(" / ", c.CompanyName, c.CompanyNameAr), c.TadawulID, joinNonEmpty(" / ", c.Sector, c.SectorAr),
+			c.Price, c.ChangePercentage, c.Lowest52Price, c.Highest52Price, c.TradedValue)
+		if c.Stale {
+			b.WriteString(" (last known price)")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func renderSeriesContext(series []stock.GetDetailedCompanyStockPricesResponse) string {
+	if len(series) == 0 {
+		return ""
+	}
+
+	bars := append([]stock.GetDetailedCompanyStockPricesResponse(nil), series...)
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Date < bars[j].Date })
+
+	first, last := bars[0], bars[len(bars)-1]
+	high, low := bars[0], bars[0]
+	var totalVolume int
+	for _, bar := range bars {
+		if bar.High > high.High {
+			high = bar
+		}
+		if bar.Low < low.Low {
+			low = bar
+		}
+		totalVolume += bar.Volume
+	}
+
+	change := last.Close - first.Open
+	changePercent := 0.0
+	if first.Open != 0 {
+		changePercent = change / first.Open * 100
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "The user is looking at a price chart with %d bars from %s to %s:\n", len(bars), first.Date, last.Date)
+	fmt.Fprintf(&b, "- Latest close: %.2f SAR on %s\n", last.Close, last.Date)
+	fmt.Fprintf(&b, "- Period change: %+.2f SAR (%+.2f%%) from an open of %.2f\n", change, changePercent, first.Open)
+	fmt.Fprintf(&b, "- Period high: %.2f on %s, period low: %.2f on %s\n", high.High, high.Date, low.Low, low.Date)
+	fmt.Fprintf(&b, "- Average volume: %d\n", totalVolume/len(bars))
+	b.WriteString("- Closes (date: close):")
+	for _, bar := range downsample(bars, maxContextSeriesPoints) {
+		fmt.Fprintf(&b, " %s: %.2f;", bar.Date, bar.Close)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// downsample picks n evenly spaced bars, always keeping the first and last.
+func downsample(bars []stock.GetDetailedCompanyStockPricesResponse, n int) []stock.GetDetailedCompanyStockPricesResponse {
+	if len(bars) <= n || n < 2 {
+		return bars
+	}
+	sampled := make([]stock.GetDetailedCompanyStockPricesResponse, 0, n)
+	step := float64(len(bars)-1) / float64(n-1)
+	for i := 0; i < n; i++ {
+		sampled = append(sampled, bars[int(math.Round(float64(i)*step))])
+	}
+	return sampled
+}
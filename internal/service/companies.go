@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"patient-chatbot/internal/mapping"
+)
+
+func (s *Service) ListCompanies(options mapping.ListOptions) ([]mapping.Company, int) {
+	return mapping.List(options)
+}
+
+func (s *Service) GetCompany(tadawulID string) (*mapping.Company, error) {
+	company, err := mapping.Lookup(tadawulID)
+	if err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
+
+// RefreshDirectory pulls the market watch into the company directory right
+// away instead of waiting for the next scheduled refresh.
+func (s *Service) RefreshDirectory(ctx context.Context) (*mapping.Diff, error) {
+	return s.directory.Refresh(ctx)
+}
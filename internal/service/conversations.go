@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"patient-chatbot/internal/conversation"
+	"patient-chatbot/internal/dto"
+	"patient-chatbot/internal/tool"
+	"patient-chatbot/internal/user"
+)
+
+type chatSession struct {
+	conversationID string
+	newMessage     *dto.Message
+	messages       []dto.Message
+	context        *dto.Context
+}
+
+// startChat resolves the history the model sees: the request's own messages
+// for stateless chats, or the stored conversation plus the new message.
+func (s *Service) startChat(ctx context.Context, request dto.ChatRequestDTO) (*chatSession, error) {
+	if request.Message == nil {
+		return &chatSession{messages: request.Messages, context: request.Context}, nil
+	}
+
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var conv *conversation.Conversation
+	if request.ConversationID == "" {
+		conv, err = s.conversations.Create(ctx, userID, conversation.TitleFrom(request.Message.Content))
+	} else {
+		conv, err = s.conversations.Get(ctx, userID, request.ConversationID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("service :: startChat :: %w", err)
+	}
+
+	stored, err := s.conversations.Messages(ctx, userID, conv.ID)
+	if err != nil {
+		return nil, fmt.Errorf("service :: startChat :: %w", err)
+	}
+
+	session := &chatSession{
+		conversationID: conv.ID,
+		newMessage:     request.Message,
+		context:        request.Context,
+	}
+	for _, m := range stored {
+		switch m.Role {
+		case dto.UserRole, dto.AssistantRole:
+			session.messages = append(session.messages, dto.Message{Role: m.Role, Content: m.Content})
+		case dto.ToolRole:
+			// @NOTE: Follow-ups are about the chart the last answer showed
+			if request.Context == nil && len(m.Data) > 0 && m.Chart != "" {
+				session.context = &dto.Context{Chart: string(m.Chart), Stocks: m.Data}
+			}
+		}
+	}
+	session.messages = append(session.messages, dto.Message{Role: dto.UserRole, Content: request.Message.Content})
+	return session, nil
+}
+
+func (s *Service) finishChat(ctx context.Context, session *chatSession, answer string, results []tool.Result) (*dto.LLMResponse, error) {
+	response := newLLMResponse(answer, results)
+	if session.conversationID == "" {
+		return response, nil
+	}
+
+	messages := []conversation.Message{
+		{Role: dto.UserRole, Content: session.newMessage.Content},
+	}
+	for _, result := range results {
+		message := conversation.Message{Role: dto.ToolRole, ToolName: result.Name, Chart: result.Chart}
+		if result.Data != nil {
+			data, err := json.Marshal(result.Data)
+			if err != nil {
+				return nil, fmt.Errorf("service :: finishChat :: error marshalling tool result: %w", err)
+			}
+			message.Data = data
+		}
+		messages = append(messages, message)
+	}
+	messages = append(messages, conversation.Message{Role: dto.AssistantRole, Content: answer})
+
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.conversations.AppendMessages(ctx, userID, session.conversationID, messages...); err != nil {
+		return nil, fmt.Errorf("service :: finishChat :: %w", err)
+	}
+	response.ConversationID = session.conversationID
+	return response, nil
+}
+
+func (s *Service) ListConversations(ctx context.Context, page int, pageSize int) ([]conversation.Conversation, int, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.conversations.List(ctx, userID, pageSize, (page-1)*pageSize)
+}
+
+func (s *Service) GetConversation(ctx context.Context, id string) (*conversation.Conversation, []conversation.Message, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	conv, err := s.conversations.Get(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages, err := s.conversations.Messages(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conv, messages, nil
+}
+
+func (s *Service) RenameConversation(ctx context.Context, id string, title string) (*conversation.Conversation, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.conversations.Rename(ctx, userID, id, title)
+}
+
+func (s *Service) DeleteConversation(ctx context.Context, id string) error {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return err
+	}
+	return s.conversations.Delete(ctx, userID, id)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/market"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	otherSector   = "Other"
+	otherSectorAr = "أخرى"
+
+	dashboardListSize = 5
+)
+
+// GetDashboard fetches the top movers and the market watch concurrently and
+// fails if any of them fails, a partial dashboard would show a wrong breadth.
+func (s *Service) GetDashboard() (*market.Dashboard, error) {
+	var (
+		gainers, losers []stock.TopFiveGainersOrLosersResponse
+		quotes          []stock.MarketWatchResponse
+		timestamps      market.DashboardTimestamps
+	)
+
+	var group errgroup.Group
+	group.Go(func() error {
+		var err error
+		gainers, err = s.marketData.GetTodayTopFiveGainersOrLosers(stock.TopGainers)
+		if err != nil {
+			return fmt.Errorf("service :: GetDashboard :: error getting top gainers: %w", err)
+		}
+		timestamps.Gainers = time.Now().UTC()
+		return nil
+	})
+	group.Go(func() error {
+		var err error
+		losers, err = s.marketData.GetTodayTopFiveGainersOrLosers(stock.TopLosers)
+		if err != nil {
+			return fmt.Errorf("service :: GetDashboard :: error getting top losers: %w", err)
+		}
+		timestamps.Losers = time.Now().UTC()
+		return nil
+	})
+	group.Go(func() error {
+		var err error
+		quotes, err = s.marketQuotes()
+		if err != nil {
+			return fmt.Errorf("service :: GetDashboard :: %w", err)
+		}
+		timestamps.MarketWatch = time.Now().UTC()
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	dashboard := market.NewDashboard(gainers, losers, quotes, dashboardListSize)
+	timestamps.GeneratedAt = time.Now().UTC()
+	dashboard.Timestamps = timestamps
+	return &dashboard, nil
+}
+
+// GetSectors aggregates the latest market watch by sector.
+func (s *Service) GetSectors(top int, sortBy market.SectorSort) (*market.SectorsOverview, error) {
+	quotes, err := s.marketQuotes()
+	if err != nil {
+		return nil, err
+	}
+	overview := market.NewSectorsOverview(quotes, top, sortBy)
+	return &overview, nil
+}
+
+// GetMarketWatch returns the latest quote of every listed company.
+func (s *Service) GetMarketWatch() ([]stock.MarketWatchResponse, error) {
+	return s.marketQuotes()
+}
+
+// marketQuotes returns the market watch with sectors completed from the
+// company directory for rows the feed left blank.
+func (s *Service) marketQuotes() ([]stock.MarketWatchResponse, error) {
+	marketWatch, err := s.marketData.GetMarketWatch()
+	if err != nil {
+		return nil, fmt.Errorf("service :: marketQuotes :: error getting market watch: %w", err)
+	}
+	quotes := make([]stock.MarketWatchResponse, len(marketWatch))
+	for i, q := range marketWatch {
+		if q.Sector == "" {
+			if company, err := mapping.Lookup(q.TadawulID); err == nil {
+				q.Sector, q.SectorAr = company.Sector, company.SectorAr
+			}
+		}
+		if q.Sector == "" {
+			q.Sector, q.SectorAr = otherSector, otherSectorAr
+		}
+		quotes[i] = q
+	}
+	return quotes, nil
+}
+
+// marketWatchByTadawulID indexes the market watch by tadawul id. It is empty
+// when the market watch is unavailable, callers price from directoryQuote then.
+func (s *Service) marketWatchByTadawulID() map[string]stock.MarketWatchResponse {
+	marketWatch := map[string]stock.MarketWatchResponse{}
+	quotes, err := s.marketQuotes()
+	if err != nil {
+		log.Warn().Msg("service :: marketWatchByTadawulID :: using directory prices: " + err.Error())
+	}
+	for _, q := range quotes {
+		marketWatch[q.TadawulID] = q
+	}
+	return marketWatch
+}
+
+// directoryQuote prices a company from the directory snapshot.
+func directoryQuote(tadawulID string) (stock.MarketWatchResponse, bool) {
+	company, err := mapping.Lookup(tadawulID)
+	if err != nil {
+		return stock.MarketWatchResponse{}, false
+	}
+	return company.MarketWatch(), true
+}
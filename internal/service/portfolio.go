@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"patient-chatbot/internal/portfolio"
+	"patient-chatbot/internal/timeseries"
+	"patient-chatbot/internal/user"
+	"time"
+)
+
+func (s *Service) ListTransactions(ctx context.Context) ([]portfolio.Transaction, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.portfolios.List(ctx, userID)
+}
+
+// AddTransaction records a trade, dated today in Riyadh when no date is given.
+// Sells that would take a holding below zero at their date are rejected.
+func (s *Service) AddTransaction(ctx context.Context, t portfolio.Transaction) (*portfolio.Transaction, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := knownCompanies([]string{t.TadawulID}); err != nil {
+		return nil, fmt.Errorf("service :: AddTransaction :: %w", err)
+	}
+	today := time.Now().In(timeseries.Riyadh).Format(portfolio.DateLayout)
+	if t.Date == "" {
+		t.Date = today
+	}
+	if t.Date > today {
+		return nil, portfolio.ErrFutureDate
+	}
+
+	created, err := s.portfolios.Create(ctx, userID, t)
+	if err != nil {
+		return nil, fmt.Errorf("service :: AddTransaction :: %w", err)
+	}
+	return created, nil
+}
+
+// DeleteTransaction removes a trade unless a later sell depends on it.
+func (s *Service) DeleteTransaction(ctx context.Context, id string) error {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.portfolios.Delete(ctx, userID, id); err != nil {
+		return fmt.Errorf("service :: DeleteTransaction :: %w", err)
+	}
+	return nil
+}
+
+// GetPortfolio values the user's holdings with the latest market watch.
+func (s *Service) GetPortfolio(ctx context.Context) (*portfolio.Report, error) {
+	transactions, err := s.ListTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report, err := portfolio.Compute(transactions, s.marketWatchByTadawulID(), directoryQuote)
+	if err != nil {
+		return nil, fmt.Errorf("service :: GetPortfolio :: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/screener"
+	"sort"
+)
+
+// Screen runs the screener over every company of the directory, priced with
+// the latest market watch.
+func (s *Service) Screen(query screener.Query) (*screener.Result, error) {
+	result, err := screener.Screen(s.screenerQuotes(), query)
+	if err != nil {
+		return nil, fmt.Errorf("service :: Screen :: %w", err)
+	}
+	return result, nil
+}
+
+// screenerQuotes quotes the directory companies, from the directory snapshot
+// for those missing from the market watch, plus the market watch companies
+// the directory doesn't list yet.
+func (s *Service) screenerQuotes() []screener.Quote {
+	marketWatch := s.marketWatchByTadawulID()
+	companies := mapping.Current().Companies()
+
+	quotes := make([]screener.Quote, 0, max(len(companies), len(marketWatch)))
+	listed := make(map[string]bool, len(companies))
+	for _, company := range companies {
+		listed[company.TadawulID] = true
+		if q, ok := marketWatch[company.TadawulID]; ok {
+			quotes = append(quotes, screener.Quote{MarketWatchResponse: q})
+			continue
+		}
+		q := company.MarketWatch()
+		if q.Sector == "" {
+			q.Sector, q.SectorAr = otherSector, otherSectorAr
+		}
+		quotes = append(quotes, screener.Quote{MarketWatchResponse: q, Stale: true})
+	}
+
+	var unlisted []string
+	for id := range marketWatch {
+		if !listed[id] {
+			unlisted = append(unlisted, id)
+		}
+	}
+	sort.Strings(unlisted)
+	for _, id := range unlisted {
+		quotes = append(quotes, screener.Quote{MarketWatchResponse: marketWatch[id]})
+	}
+	return quotes
+}
@@ -2,194 +2,159 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"math"
-	"math/rand/v2"
-	"os"
+	"patient-chatbot/internal/alert"
 	"patient-chatbot/internal/client/llm"
 	"patient-chatbot/internal/client/stock"
 	"patient-chatbot/internal/config"
+	"patient-chatbot/internal/conversation"
 	"patient-chatbot/internal/dto"
-	"strings"
+	"patient-chatbot/internal/history"
+	"patient-chatbot/internal/indicators"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/portfolio"
+	"patient-chatbot/internal/timeseries"
+	"patient-chatbot/internal/tool"
+	"patient-chatbot/internal/watchlist"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
-var MOCK_DATA = os.Getenv("MOCK_DATA") == "true"
+// historyTimeout bounds the price store lookup, upstream is asked instead
+// when it is slow.
+const historyTimeout = 2 * time.Second
 
 type Service struct {
-	cfg         *config.Config
-	llmClient   *llm.LLMClient
-	stockClient *stock.StockClient
+	cfg        *config.Config
+	llmClient  *llm.LLMClient
+	marketData stock.MarketDataProvider
+	tools      *tool.Registry
+
+	conversations conversation.Repository
+	watchlists    watchlist.Repository
+	portfolios    portfolio.Repository
+	alerts        alert.Repository
+	prices        history.Store
+	directory     *mapping.Refresher
 }
 
 func NewService(
 	cfg *config.Config,
 	llmClient *llm.LLMClient,
-	stockClient *stock.StockClient,
+	marketData stock.MarketDataProvider,
+	conversations conversation.Repository,
+	watchlists watchlist.Repository,
+	portfolios portfolio.Repository,
+	alerts alert.Repository,
+	prices history.Store,
+	directory *mapping.Refresher,
 ) *Service {
-	return &Service{
-		cfg:         cfg,
-		llmClient:   llmClient,
-		stockClient: stockClient,
+	s := &Service{
+		cfg:           cfg,
+		llmClient:     llmClient,
+		marketData:    marketData,
+		tools:         tool.NewRegistry(),
+		conversations: conversations,
+		watchlists:    watchlists,
+		portfolios:    portfolios,
+		alerts:        alerts,
+		prices:        prices,
+		directory:     directory,
 	}
+	s.registerTools()
+	s.registerCharts()
+	return s
 }
 
-func (s *Service) Chat(ctx context.Context, request dto.ChatRequestDTO) (*dto.LLMResponse, error) {
-	messages := request.Messages
-	answerContext := request.Context
-
-	if len(messages) > 50 {
-		messages = messages[len(messages)-50:]
-	}
-
-	answer, toolCalls, err := s.llmClient.Chat(ctx, messages, answerContext)
+func (s *Service) Chat(ctx context.Context, lang string, request dto.ChatRequestDTO) (*dto.LLMResponse, error) {
+	session, err := s.startChat(ctx, request)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(toolCalls) > 0 {
-		toolCall := toolCalls[0]
-		switch toolCall.Function.Name {
-		case stock.FunctionSearchCompanyStocks:
-			var rawArg json.RawMessage = toolCall.Function.Arguments
-			var jsonText string
-			if err := json.Unmarshal(rawArg, &jsonText); err != nil {
-				return nil, fmt.Errorf("decoding arguments wrapper: %w", err)
-			}
-			var searchCompanyStocksResponse stock.SearchCompanyStocksArguments
-			err := json.Unmarshal([]byte(jsonText), &searchCompanyStocksResponse)
-			if err != nil {
-				return nil, err
-			}
-			if MOCK_DATA {
-				return &dto.LLMResponse{
-					Answer: answer,
-					Stocks: s.GetMockSearchCompanyStocks(searchCompanyStocksResponse.CompanyName),
-					Chart:  dto.ChartsSearchCompanyStocks,
-				}, nil
-			}
-			getDetailedCompanyStockPricesResponse, err := s.stockClient.SearchCompanyStocks(searchCompanyStocksResponse.CompanyName)
-			if err != nil {
-				return nil, err
-			}
-			if getDetailedCompanyStockPricesResponse == nil {
-				return &dto.LLMResponse{
-					Answer: "Sorry, I couldn't find any stocks for " + searchCompanyStocksResponse.CompanyName,
-					Stocks: nil,
-					Chart:  dto.ChartsSearchCompanyStocks,
-				}, nil
-			}
-			return &dto.LLMResponse{
-				Answer: answer,
-				Stocks: getDetailedCompanyStockPricesResponse,
-				Chart:  dto.ChartsSearchCompanyStocks,
-			}, nil
-		case stock.FunctionGetDetailedCompanyStockPrices:
-			if MOCK_DATA {
-				return &dto.LLMResponse{
-					Answer: answer,
-					Stocks: s.GetMockCompanyChart(),
-					Chart:  dto.ChartsDetailedCompanyStockPrices,
-				}, nil
-			}
-			var rawArg json.RawMessage = toolCall.Function.Arguments
-			var jsonText string
-			if err := json.Unmarshal(rawArg, &jsonText); err != nil {
-				return nil, fmt.Errorf("service :: Chat :: error decoding arguments wrapper: %w", err)
-			}
-			var getDetailedCompanyStockPricesResponseArguments stock.GetDetailedCompanyStockPricesResponseArguments
-			err := json.Unmarshal([]byte(jsonText), &getDetailedCompanyStockPricesResponseArguments)
-			if err != nil {
-				return nil, fmt.Errorf("service :: Chat :: error unmarshalling arguments: %w", err)
-			}
-			getDetailedCompanyStockPricesResponse, err := s.stockClient.GetDetailedCompanyStockPrices(getDetailedCompanyStockPricesResponseArguments.TadawulID)
-			if err != nil {
-				return nil, fmt.Errorf("service :: Chat :: error getting detailed company stock prices: %w", err)
-			}
-
-			return &dto.LLMResponse{
-				Answer: answer,
-				Stocks: getDetailedCompanyStockPricesResponse,
-				Chart:  dto.ChartsDetailedCompanyStockPrices,
-			}, nil
-		}
+	answer, results, err := s.llmClient.Chat(ctx, lang, session.messages, session.context, s.tools)
+	if err != nil {
+		return nil, err
 	}
-
-	return &dto.LLMResponse{
-		Answer: answer,
-		Stocks: nil,
-		Chart:  "",
-	}, nil
-
+	return s.finishChat(ctx, session, answer, results)
 }
 
-func (s *Service) GetDashboard() ([]stock.TopFiveGainersOrLosersResponse, error) {
-	topFiveGainers, err := s.stockClient.GetTodayTopFiveGainersOrLosers(stock.TopGainers)
+func (s *Service) ChatStream(ctx context.Context, lang string, request dto.ChatRequestDTO, callbacks *llm.StreamCallbacks) (*dto.LLMResponse, error) {
+	session, err := s.startChat(ctx, request)
 	if err != nil {
 		return nil, err
 	}
-	topFiveLosers, err := s.stockClient.GetTodayTopFiveGainersOrLosers(stock.TopLosers)
+	answer, results, err := s.llmClient.ChatStream(ctx, lang, session.messages, session.context, s.tools, callbacks)
 	if err != nil {
 		return nil, err
 	}
-
-	topFiveGainersAndLosers := make([]stock.TopFiveGainersOrLosersResponse, len(topFiveGainers)+len(topFiveLosers))
-	copy(topFiveGainersAndLosers, topFiveGainers)
-	copy(topFiveGainersAndLosers[len(topFiveGainers):], topFiveLosers)
-
-	return topFiveGainersAndLosers, nil
+	return s.finishChat(ctx, session, answer, results)
 }
 
-func (s *Service) GetCompanyChart(ID string) ([]stock.GetDetailedCompanyStockPricesResponse, error) {
-	if MOCK_DATA {
-		return s.GetMockCompanyChart(), nil
+func newLLMResponse(answer string, results []tool.Result) *dto.LLMResponse {
+	response := &dto.LLMResponse{
+		Answer: answer,
+		Stocks: nil,
+		Chart:  "",
+	}
+	// @NOTE: The frontend renders a single chart, so the latest tool result wins
+	for i := len(results) - 1; i >= 0; i-- {
+		if results[i].Data != nil {
+			response.Stocks = results[i].Data
+			response.Chart = results[i].Chart
+			break
+		}
 	}
-	return s.stockClient.GetDetailedCompanyStockPrices(ID)
+	return response
 }
 
-func (s *Service) GetMockSearchCompanyStocks(companyName string) *stock.SearchCompanyStocksResponse {
-	return &stock.SearchCompanyStocksResponse{
-		TadawulID:     fmt.Sprintf("%04d", 100+rand.IntN(9900)),
-		CompanyID:     1,
-		CompanyName:   strings.ToUpper(companyName),
-		Sector:        "Technology",
-		AcrynomNameAr: companyName,
-		ArgaamID:      fmt.Sprintf("%04d", 100+rand.IntN(10100)),
-		CompanyNameAr: "",
-		SectorAr:      "التكنولوجيا",
-		AcrynomName:   companyName,
-		Price:         math.Round(rand.Float64()*1000) / 100,
-		Change:        math.Round(rand.Float64()*100) / 100,
-		ChangePercent: math.Round(rand.Float64()*100) / 100,
+// GetCompanyChart returns the company prices as the provider reports them, or
+// resampled to interval when one is given. Daily prices come from the price
+// store when it covers the whole query.
+func (s *Service) GetCompanyChart(
+	ID string,
+	query stock.PriceQuery,
+	interval timeseries.Interval,
+	gaps timeseries.GapPolicy,
+) ([]stock.GetDetailedCompanyStockPricesResponse, error) {
+	prices, err := s.companyPrices(ID, query)
+	if err != nil || interval == "" {
+		return prices, err
 	}
+	return timeseries.Resample(prices, interval, gaps), nil
 }
-func (s *Service) GetMockCompanyChart() []stock.GetDetailedCompanyStockPricesResponse {
-
-	base := 100.0
 
-	mockData := make([]stock.GetDetailedCompanyStockPricesResponse, 31)
-
-	for i := 0; i < 31; i++ {
-		prevClose := base
-		if i > 0 {
-			prevClose = mockData[i-1].Close
+func (s *Service) companyPrices(ID string, query stock.PriceQuery) ([]stock.GetDetailedCompanyStockPricesResponse, error) {
+	if s.prices != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+		defer cancel()
+		prices, ok, err := history.Serve(ctx, s.prices, ID, query, time.Now(), s.cfg.PriceHistoryMaxAge)
+		if err != nil {
+			log.Warn().Msg("service :: companyPrices :: " + err.Error())
 		}
-
-		delta := rand.NormFloat64() * 2.0
-		open := prevClose + rand.NormFloat64()*0.5
-		close := prevClose + delta
-		high := math.Max(open, close) + rand.Float64()*1.0
-		low := math.Min(open, close) - rand.Float64()*1.0
-
-		mockData[i] = stock.GetDetailedCompanyStockPricesResponse{
-			Date:   time.Now().AddDate(0, 0, -i).Format("2006-01-02"),
-			Open:   open,
-			Close:  close,
-			High:   high,
-			Low:    low,
-			Volume: int(1000 + rand.Float64()*5000),
+		if ok {
+			return prices, nil
 		}
 	}
-	return mockData
+	return s.marketData.GetDetailedCompanyStockPrices(ID, query)
+}
+
+// GetCompanyIndicators computes the technical indicators over the company
+// prices resampled to interval, daily bars unless another interval is given.
+func (s *Service) GetCompanyIndicators(
+	ID string,
+	query stock.PriceQuery,
+	interval timeseries.Interval,
+	names []indicators.Name,
+	params indicators.Params,
+) (*indicators.Report, error) {
+	if interval == "" {
+		interval = timeseries.Interval1D
+	}
+	prices, err := s.GetCompanyChart(ID, query, interval, timeseries.GapSkip)
+	if err != nil {
+		return nil, err
+	}
+	report := indicators.Compute(prices, names, params)
+	report.TadawulID = ID
+	return &report, nil
 }
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/alert"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/dto"
+	"patient-chatbot/internal/indicators"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/market"
+	"patient-chatbot/internal/portfolio"
+	"patient-chatbot/internal/screener"
+	"patient-chatbot/internal/timeseries"
+	"patient-chatbot/internal/tool"
+	"patient-chatbot/internal/user"
+	"patient-chatbot/internal/watchlist"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxCompanyMatches   = 5
+	defaultSectorMovers = 3
+	maxSectorMovers     = 5
+	defaultScreenerRows = 10
+	maxScreenerRows     = 25
+)
+
+func (s *Service) registerTools() {
+	s.tools.Register(
+		tool.New(
+			string(stock.FunctionSearchCompanyStocks),
+			"Search for a company's stocks by giving the company name, acronym or tadawul id, in English or Arabic. "+
+				"Returns a single company when the match is clear, otherwise a list of candidates: "+
+				"when several companies are returned, ask the user which one they mean before answering",
+			s.searchCompanyStocksTool,
+		),
+		tool.New(
+			string(stock.FunctionGetDetailedCompanyStockPrices),
+			"Get detailed daily company stock prices (open, high, low, close, volume) by giving the company tadawul id. "+
+				"Defaults to the last month, use period for other ranges, from/to to narrow it down to specific dates "+
+				"and interval to get weekly or monthly bars",
+			s.getDetailedCompanyStockPricesTool,
+		),
+		tool.New(
+			indicators.FunctionGetTechnicalIndicators,
+			"Get computed technical indicators (SMA, EMA, RSI, MACD, Bollinger Bands, ATR, VWAP, OBV) for a company by its tadawul id, "+
+				"with their latest values and a signal such as overbought, oversold or bullish_crossover. "+
+				"Use it to answer questions about momentum, trend or volatility instead of estimating them",
+			s.getTechnicalIndicatorsTool,
+		),
+		tool.New(
+			market.FunctionGetSectorPerformance,
+			"Get today's performance of every Tadawul sector or of one sector: advancers and decliners, "+
+				"average and traded value weighted change, volume, traded value and the top movers. "+
+				"Use it for sector rotation questions and to compare sectors",
+			s.getSectorPerformanceTool,
+		),
+		tool.New(
+			watchlist.FunctionGetWatchlist,
+			"Get the user's watchlists with the latest price and change of every company in them, "+
+				"plus how many companies are up or down and their average change. "+
+				"Use it when the user asks about their watchlist",
+			s.getWatchlistTool,
+		),
+		tool.New(
+			watchlist.FunctionUpdateWatchlist,
+			"Add companies to or remove companies from one of the user's watchlists. "+
+				"If a company name is ambiguous or the user has several watchlists the error says so: ask the user which one they mean",
+			s.updateWatchlistTool,
+		),
+		tool.New(
+			portfolio.FunctionGetPortfolio,
+			"Get the user's portfolio from their recorded transactions: every holding with quantity, average cost, "+
+				"market value, unrealized and realized P&L, today's change and weight, plus the allocation by sector. "+
+				"Use it for questions about the user's holdings, performance or exposure",
+			s.getPortfolioTool,
+		),
+		tool.New(
+			alert.FunctionCreateAlert,
+			"Set up a price alert for the user on one company: the price crossing a level, the day's change reaching a percentage, "+
+				"a volume spike or a new 52 week high or low. Use it when the user asks to be told when something happens to a stock",
+			s.createAlertTool,
+		),
+		tool.New(
+			alert.FunctionGetAlerts,
+			"Get the user's price alerts, or with history the alerts that fired recently and whether they were delivered",
+			s.getAlertsTool,
+		),
+		tool.New(
+			screener.FunctionScreenStocks,
+			"Find the companies matching criteria on sector, price, today's move, volume or 52 week range, ranked by a field. "+
+				"Use it whenever the user asks which stocks or how many stocks meet conditions, or for the top companies by a measure, "+
+				"instead of listing companies from memory. Fields: "+strings.Join(screener.Fields(), ", ")+". "+
+				"If the filter is invalid the error says where, fix it and try again",
+			s.screenStocksTool,
+		),
+	)
+}
+
+func (s *Service) searchCompanyStocksTool(ctx context.Context, args stock.SearchCompanyStocksArguments) (*tool.Result, error) {
+	result := &tool.Result{Chart: dto.ChartsSearchCompanyStocks}
+
+	matches := mapping.Disambiguate(mapping.Search(args.CompanyName, maxCompanyMatches))
+	if len(matches) == 0 {
+		// @NOTE: Companies listed after the directory snapshot are only known upstream
+		searchCompanyStocksResponse, err := s.marketData.SearchCompanyStocks(args.CompanyName)
+		if err != nil {
+			return nil, fmt.Errorf("service :: searchCompanyStocksTool :: error searching company stocks: %w", err)
+		}
+		if searchCompanyStocksResponse != nil {
+			result.Data = searchCompanyStocksResponse
+		}
+		return result, nil
+	}
+
+	companies := s.quoteCompanies(matches)
+	if len(companies) == 1 {
+		result.Data = companies[0]
+	} else {
+		result.Data = companies
+	}
+	return result, nil
+}
+
+// quoteCompanies prices the matched companies from the market watch, falling
+// back to the directory snapshot prices when it is unavailable.
+func (s *Service) quoteCompanies(matches []mapping.Match) []stock.SearchCompanyStocksResponse {
+	quotes := map[string]stock.MarketWatchResponse{}
+	marketWatch, err := s.marketData.GetMarketWatch()
+	if err != nil {
+		log.Warn().Msg("service :: quoteCompanies :: using directory prices: " + err.Error())
+	}
+	for _, m := range marketWatch {
+		quotes[m.TadawulID] = m
+	}
+
+	companies := make([]stock.SearchCompanyStocksResponse, 0, len(matches))
+	for _, match := range matches {
+		if quote, ok := quotes[match.Company.TadawulID]; ok {
+			companies = append(companies, quote.SearchResponse())
+			continue
+		}
+		c := match.Company
+		companies = append(companies, stock.SearchCompanyStocksResponse{
+			TadawulID:     c.TadawulID,
+			CompanyID:     c.CompanyID,
+			CompanyName:   c.CompanyName,
+			CompanyNameAr: c.CompanyNameAr,
+			AcrynomName:   c.AcronymName,
+			AcrynomNameAr: c.AcronymNameAr,
+			Sector:        c.Sector,
+			SectorAr:      c.SectorAr,
+			Price:         c.Price,
+			Change:        c.Change,
+			ChangePercent: c.ChangePercentage,
+		})
+	}
+	return companies
+}
+
+func (s *Service) getDetailedCompanyStockPricesTool(ctx context.Context, args stock.GetDetailedCompanyStockPricesResponseArguments) (*tool.Result, error) {
+	result := &tool.Result{Chart: dto.ChartsDetailedCompanyStockPrices}
+	query, err := stock.NewPriceQuery(args.Period, args.From, args.To)
+	if err != nil {
+		return nil, fmt.Errorf("service :: getDetailedCompanyStockPricesTool :: %w", err)
+	}
+	var interval timeseries.Interval
+	if args.Interval != "" {
+		if interval, err = timeseries.ParseInterval(args.Interval); err != nil {
+			return nil, fmt.Errorf("service :: getDetailedCompanyStockPricesTool :: %w", err)
+		}
+	}
+	getDetailedCompanyStockPricesResponse, err := s.GetCompanyChart(args.TadawulID, query, interval, timeseries.GapSkip)
+	if err != nil {
+		return nil, fmt.Errorf("service :: getDetailedCompanyStockPricesTool :: error getting detailed company stock prices: %w", err)
+	}
+	result.Data = getDetailedCompanyStockPricesResponse
+	return result, nil
+}
+
+func (s *Service) getTechnicalIndicatorsTool(ctx context.Context, args indicators.GetTechnicalIndicatorsArguments) (*tool.Result, error) {
+	names, err := indicators.ParseNames(args.Indicators)
+	if err != nil {
+		return nil, fmt.Errorf("service :: getTechnicalIndicatorsTool :: %w", err)
+	}
+	period := args.Period
+	if period == "" {
+		period = string(indicators.DefaultPeriod)
+	}
+	query, err := stock.NewPriceQuery(period, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("service :: getTechnicalIndicatorsTool :: %w", err)
+	}
+	var interval timeseries.Interval
+	if args.Interval != "" {
+		if interval, err = timeseries.ParseInterval(args.Interval); err != nil {
+			return nil, fmt.Errorf("service :: getTechnicalIndicatorsTool :: %w", err)
+		}
+	}
+	report, err := s.GetCompanyIndicators(args.TadawulID, query, interval, names, indicators.Params{})
+	if err != nil {
+		return nil, fmt.Errorf("service :: getTechnicalIndicatorsTool :: error computing indicators: %w", err)
+	}
+	// @NOTE: Only the latest readings go back to the model, the full lines are
+	// available from the indicators endpoint
+	return &tool.Result{Chart: dto.ChartsTechnicalIndicators, Data: report.Summary()}, nil
+}
+
+func (s *Service) getSectorPerformanceTool(ctx context.Context, args market.GetSectorPerformanceArguments) (*tool.Result, error) {
+	top := args.Top
+	if top <= 0 {
+		top = defaultSectorMovers
+	}
+	overview, err := s.GetSectors(min(top, maxSectorMovers), market.SectorSort(args.Sort))
+	if err != nil {
+		return nil, fmt.Errorf("service :: getSectorPerformanceTool :: %w", err)
+	}
+
+	if sector := mapping.Normalize(args.Sector); sector != "" {
+		var matching []market.SectorSummary
+		for _, summary := range overview.Sectors {
+			if mapping.Normalize(summary.Sector) == sector || mapping.Normalize(summary.SectorAr) == sector {
+				matching = append(matching, summary)
+			}
+		}
+		// @NOTE: An unknown sector keeps every sector so the model can pick the closest one
+		if len(matching) > 0 {
+			overview.Sectors = matching
+		}
+	}
+	return &tool.Result{Chart: dto.ChartsSectorPerformance, Data: overview}, nil
+}
+
+func (s *Service) getWatchlistTool(ctx context.Context, args watchlist.GetWatchlistArguments) (*tool.Result, error) {
+	watchlists, err := s.ListWatchlists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service :: getWatchlistTool :: %w", err)
+	}
+	if args.Watchlist != "" {
+		w, err := findWatchlist(watchlists, args.Watchlist)
+		if err != nil {
+			return nil, fmt.Errorf("service :: getWatchlistTool :: %w", err)
+		}
+		watchlists = []watchlist.Watchlist{*w}
+	}
+
+	result := &tool.Result{Chart: dto.ChartsWatchlist}
+	if len(watchlists) > 0 {
+		result.Data = s.quoteWatchlists(watchlists...)
+	}
+	return result, nil
+}
+
+func (s *Service) updateWatchlistTool(ctx context.Context, args watchlist.UpdateWatchlistArguments) (*tool.Result, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service :: updateWatchlistTool :: %w", err)
+	}
+	tadawulIDs, err := resolveCompanies(args.Companies)
+	if err != nil {
+		return nil, fmt.Errorf("service :: updateWatchlistTool :: %w", err)
+	}
+	watchlists, err := s.watchlists.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service :: updateWatchlistTool :: %w", err)
+	}
+
+	var target *watchlist.Watchlist
+	switch {
+	case args.Watchlist != "":
+		target, err = findWatchlist(watchlists, args.Watchlist)
+	case len(watchlists) == 1:
+		target = &watchlists[0]
+	case len(watchlists) > 1:
+		err = fmt.Errorf("the user has several watchlists (%s), ask which one to use", watchlistNames(watchlists))
+	}
+	creating := args.Action == "add" && (len(watchlists) == 0 || errors.Is(err, watchlist.ErrNotFound))
+	if err != nil && !creating {
+		return nil, fmt.Errorf("service :: updateWatchlistTool :: %w", err)
+	}
+
+	var updated *watchlist.Watchlist
+	switch {
+	case creating:
+		name := args.Watchlist
+		if name == "" {
+			name = watchlist.DefaultName
+		}
+		updated, err = s.watchlists.Create(ctx, userID, name, tadawulIDs)
+	case target == nil:
+		err = errors.New("the user has no watchlist yet")
+	case args.Action == "remove":
+		updated, err = s.watchlists.RemoveItems(ctx, userID, target.ID, tadawulIDs...)
+	default:
+		updated, err = s.watchlists.AddItems(ctx, userID, target.ID, tadawulIDs...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("service :: updateWatchlistTool :: %w", err)
+	}
+	return &tool.Result{Chart: dto.ChartsWatchlist, Data: s.quoteWatchlists(*updated)}, nil
+}
+
+// resolveCompanies maps free text company names to tadawul ids, failing on the
+// first name that matches no company or several.
+func resolveCompanies(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no companies given")
+	}
+	tadawulIDs := make([]string, 0, len(names))
+	for _, name := range names {
+		matches := mapping.Disambiguate(mapping.Search(name, maxCompanyMatches))
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no company matches %q", name)
+		case 1:
+			tadawulIDs = append(tadawulIDs, matches[0].Company.TadawulID)
+		default:
+			candidates := make([]string, 0, len(matches))
+			for _, m := range matches {
+				candidates = append(candidates, fmt.Sprintf("%s (%s)", m.Company.CompanyName, m.Company.TadawulID))
+			}
+			return nil, fmt.Errorf("%q matches several companies, ask the user which one they mean: %s", name, strings.Join(candidates, ", "))
+		}
+	}
+	return tadawulIDs, nil
+}
+
+func findWatchlist(watchlists []watchlist.Watchlist, name string) (*watchlist.Watchlist, error) {
+	for i, w := range watchlists {
+		if mapping.Normalize(w.Name) == mapping.Normalize(name) {
+			return &watchlists[i], nil
+		}
+	}
+	if len(watchlists) == 0 {
+		return nil, fmt.Errorf("%w: the user has no watchlist yet", watchlist.ErrNotFound)
+	}
+	return nil, fmt.Errorf("%w: %q, the user has %s", watchlist.ErrNotFound, name, watchlistNames(watchlists))
+}
+
+func watchlistNames(watchlists []watchlist.Watchlist) string {
+	names := make([]string, 0, len(watchlists))
+	for _, w := range watchlists {
+		names = append(names, w.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (s *Service) getPortfolioTool(ctx context.Context, args portfolio.GetPortfolioArguments) (*tool.Result, error) {
+	report, err := s.GetPortfolio(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service :: getPortfolioTool :: %w", err)
+	}
+	// @NOTE: The allocation stays whole so the model can state the sector's share
+	if sector := mapping.Normalize(args.Sector); sector != "" {
+		var positions []portfolio.Position
+		for _, p := range report.Positions {
+			if mapping.Normalize(p.Sector) == sector || mapping.Normalize(p.SectorAr) == sector {
+				positions = append(positions, p)
+			}
+		}
+		report.Positions = positions
+	}
+	return &tool.Result{Chart: dto.ChartsPortfolio, Data: report}, nil
+}
+
+func (s *Service) createAlertTool(ctx context.Context, args alert.CreateAlertArguments) (*tool.Result, error) {
+	tadawulIDs, err := resolveCompanies([]string{args.Company})
+	if err != nil {
+		return nil, fmt.Errorf("service :: createAlertTool :: %w", err)
+	}
+	if _, err := s.CreateAlert(ctx, alert.Rule{
+		TadawulID: tadawulIDs[0],
+		Condition: alert.Condition(args.Condition),
+		Threshold: args.Threshold,
+		Repeat:    args.Repeat,
+	}); err != nil {
+		return nil, fmt.Errorf("service :: createAlertTool :: %w", err)
+	}
+	return s.getAlertsTool(ctx, alert.GetAlertsArguments{})
+}
+
+func (s *Service) getAlertsTool(ctx context.Context, args alert.GetAlertsArguments) (*tool.Result, error) {
+	rules, err := s.ListAlerts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service :: getAlertsTool :: %w", err)
+	}
+	summary := alert.Summary{Alerts: rules}
+	if args.History {
+		if summary.History, err = s.ListAlertHistory(ctx, "", defaultAlertHistory); err != nil {
+			return nil, fmt.Errorf("service :: getAlertsTool :: %w", err)
+		}
+	}
+	return &tool.Result{Chart: dto.ChartsAlerts, Data: summary}, nil
+}
+
+func (s *Service) screenStocksTool(ctx context.Context, args screener.ScreenStocksArguments) (*tool.Result, error) {
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultScreenerRows
+	}
+	result, err := s.Screen(screener.Query{
+		Filter:   args.Filter,
+		Sort:     args.Sort,
+		Order:    args.Order,
+		PageSize: min(limit, maxScreenerRows),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service :: screenStocksTool :: %w", err)
+	}
+	return &tool.Result{Chart: dto.ChartsScreener, Data: result}, nil
+}
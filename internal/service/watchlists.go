@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"patient-chatbot/internal/mapping"
+	"patient-chatbot/internal/user"
+	"patient-chatbot/internal/watchlist"
+	"strings"
+)
+
+func (s *Service) ListWatchlists(ctx context.Context) ([]watchlist.Watchlist, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.watchlists.List(ctx, userID)
+}
+
+func (s *Service) GetWatchlist(ctx context.Context, id string) (*watchlist.Watchlist, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.watchlists.Get(ctx, userID, id)
+}
+
+func (s *Service) CreateWatchlist(ctx context.Context, name string, tadawulIDs []string) (*watchlist.Watchlist, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// @NOTE: Binding only checks the name is set, it may still be blank
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, watchlist.ErrEmptyName
+	}
+	if err := knownCompanies(tadawulIDs); err != nil {
+		return nil, fmt.Errorf("service :: CreateWatchlist :: %w", err)
+	}
+	return s.watchlists.Create(ctx, userID, name, tadawulIDs)
+}
+
+func (s *Service) RenameWatchlist(ctx context.Context, id string, name string) (*watchlist.Watchlist, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, watchlist.ErrEmptyName
+	}
+	return s.watchlists.Rename(ctx, userID, id, name)
+}
+
+func (s *Service) DeleteWatchlist(ctx context.Context, id string) error {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return err
+	}
+	return s.watchlists.Delete(ctx, userID, id)
+}
+
+func (s *Service) AddToWatchlist(ctx context.Context, id string, tadawulIDs []string) (*watchlist.Watchlist, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := knownCompanies(tadawulIDs); err != nil {
+		return nil, fmt.Errorf("service :: AddToWatchlist :: %w", err)
+	}
+	return s.watchlists.AddItems(ctx, userID, id, tadawulIDs...)
+}
+
+func (s *Service) RemoveFromWatchlist(ctx context.Context, id string, tadawulIDs []string) (*watchlist.Watchlist, error) {
+	userID, err := user.IDFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.watchlists.RemoveItems(ctx, userID, id, tadawulIDs...)
+}
+
+// GetWatchlistQuotes prices every company of the watchlist in one market watch
+// call.
+func (s *Service) GetWatchlistQuotes(ctx context.Context, id string) (*watchlist.Quotes, error) {
+	w, err := s.GetWatchlist(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	quotes := s.quoteWatchlists(*w)
+	return &quotes[0], nil
+}
+
+// quoteWatchlists prices the watchlists in one market watch call.
+func (s *Service) quoteWatchlists(watchlists ...watchlist.Watchlist) []watchlist.Quotes {
+	marketWatch := s.marketWatchByTadawulID()
+	priced := make([]watchlist.Quotes, 0, len(watchlists))
+	for _, w := range watchlists {
+		priced = append(priced, watchlist.NewQuotes(w, marketWatch, directoryQuote))
+	}
+	return priced
+}
+
+// knownCompanies rejects tadawul ids missing from the company directory.
+func knownCompanies(tadawulIDs []string) error {
+	for _, id := range tadawulIDs {
+		if _, err := mapping.Lookup(id); err != nil {
+			return fmt.Errorf("%w: %s", err, id)
+		}
+	}
+	return nil
+}
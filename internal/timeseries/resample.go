@@ -0,0 +1,224 @@
+package timeseries
+
+import (
+	"errors"
+	"fmt"
+	"patient-chatbot/internal/client/stock"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Interval string
+
+const (
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval1h  Interval = "1h"
+	Interval1D  Interval = "1D"
+	Interval1W  Interval = "1W"
+	Interval1M  Interval = "1M"
+)
+
+var Intervals = []Interval{Interval5m, Interval15m, Interval1h, Interval1D, Interval1W, Interval1M}
+
+// GapPolicy decides what happens to trading sessions without any bar.
+type GapPolicy string
+
+const (
+	// GapSkip leaves missing sessions out of the series.
+	GapSkip GapPolicy = "skip"
+	// GapForwardFill repeats the previous close with zero volume.
+	GapForwardFill GapPolicy = "ffill"
+	// GapMark forward fills like GapForwardFill and flags the bar with Gap.
+	GapMark GapPolicy = "mark"
+)
+
+var ErrInvalidResample = errors.New("invalid resample options")
+
+// Riyadh is the Tadawul exchange timezone, Saudi Arabia has no DST.
+var Riyadh = time.FixedZone("Asia/Riyadh", 3*60*60)
+
+// Tadawul continuous trading session, Sunday to Thursday.
+const (
+	SessionOpen  = 10 * time.Hour
+	SessionClose = 15 * time.Hour
+)
+
+const barDateLayout = "2006-01-02T15:04:05"
+
+func ParseInterval(value string) (Interval, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "1d", "1D":
+		return Interval1D, nil
+	case "1w", "1W":
+		return Interval1W, nil
+	case "1h", "1H", "60m":
+		return Interval1h, nil
+	}
+	for _, i := range Intervals {
+		if Interval(value) == i {
+			return i, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown interval %q", ErrInvalidResample, value)
+}
+
+func ParseGapPolicy(value string) (GapPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "skip":
+		return GapSkip, nil
+	case "ffill", "forward-fill", "forward_fill":
+		return GapForwardFill, nil
+	case "mark":
+		return GapMark, nil
+	}
+	return "", fmt.Errorf("%w: unknown gap policy %q", ErrInvalidResample, value)
+}
+
+// IsTradingDay reports whether Tadawul trades on the day of t, ignoring
+// public holidays.
+func IsTradingDay(t time.Time) bool {
+	switch t.In(Riyadh).Weekday() {
+	case time.Friday, time.Saturday:
+		return false
+	}
+	return true
+}
+
+func (i Interval) intraday() time.Duration {
+	switch i {
+	case Interval5m:
+		return 5 * time.Minute
+	case Interval15m:
+		return 15 * time.Minute
+	case Interval1h:
+		return time.Hour
+	}
+	return 0
+}
+
+// BucketStart returns the start of the interval containing t in Riyadh time.
+// Weeks start on Sunday, the first day of the Tadawul week.
+func (i Interval) BucketStart(t time.Time) time.Time {
+	t = t.In(Riyadh)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Riyadh)
+	switch i {
+	case Interval1D:
+		return day
+	case Interval1W:
+		return day.AddDate(0, 0, -int(day.Weekday()))
+	case Interval1M:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, Riyadh)
+	}
+	d := i.intraday()
+	return day.Add(t.Sub(day) / d * d)
+}
+
+// next returns the bucket expected to follow start, skipping Fridays,
+// Saturdays and, for intraday intervals, the hours outside the session.
+func (i Interval) next(start time.Time) time.Time {
+	switch i {
+	case Interval1D:
+		next := start.AddDate(0, 0, 1)
+		for !IsTradingDay(next) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	case Interval1W:
+		return start.AddDate(0, 0, 7)
+	case Interval1M:
+		return start.AddDate(0, 1, 0)
+	}
+
+	next := start.Add(i.intraday())
+	day := time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, Riyadh)
+	if IsTradingDay(day) && next.Before(day.Add(SessionOpen)) {
+		return day.Add(SessionOpen)
+	}
+	if !IsTradingDay(day) || !next.Before(day.Add(SessionClose)) {
+		day = day.AddDate(0, 0, 1)
+		for !IsTradingDay(day) {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day.Add(SessionOpen)
+	}
+	return next
+}
+
+type bucket struct {
+	start time.Time
+	bar   stock.GetDetailedCompanyStockPricesResponse
+}
+
+// Resample aggregates bars into the given interval and returns them oldest
+// first. Bars with an unparseable date are dropped and a bar repeating the
+// time of another replaces it. The input may be in any order.
+func Resample(
+	bars []stock.GetDetailedCompanyStockPricesResponse,
+	interval Interval,
+	gaps GapPolicy,
+) []stock.GetDetailedCompanyStockPricesResponse {
+	type timedBar struct {
+		at  time.Time
+		bar stock.GetDetailedCompanyStockPricesResponse
+	}
+	timed := make([]timedBar, 0, len(bars))
+	for _, bar := range bars {
+		at, err := stock.ParseBarDate(bar.Date)
+		if err != nil {
+			continue
+		}
+		timed = append(timed, timedBar{at: at, bar: bar})
+	}
+	sort.SliceStable(timed, func(a, b int) bool { return timed[a].at.Before(timed[b].at) })
+	unique := timed[:0]
+	for _, tb := range timed {
+		if n := len(unique); n > 0 && unique[n-1].at.Equal(tb.at) {
+			unique[n-1] = tb
+			continue
+		}
+		unique = append(unique, tb)
+	}
+	timed = unique
+
+	var buckets []bucket
+	for _, tb := range timed {
+		start := interval.BucketStart(tb.at)
+		if n := len(buckets); n > 0 && buckets[n-1].start.Equal(start) {
+			agg := &buckets[n-1].bar
+			agg.High = max(agg.High, tb.bar.High)
+			agg.Low = min(agg.Low, tb.bar.Low)
+			agg.Close = tb.bar.Close
+			agg.Volume += tb.bar.Volume
+			agg.X, agg.Y = tb.bar.X, tb.bar.Y
+			continue
+		}
+		bar := tb.bar
+		bar.Date = start.Format(barDateLayout)
+		bar.Gap = false
+		buckets = append(buckets, bucket{start: start, bar: bar})
+	}
+
+	result := make([]stock.GetDetailedCompanyStockPricesResponse, 0, len(buckets))
+	for n, b := range buckets {
+		if n > 0 && gaps != GapSkip {
+			prev := buckets[n-1].bar
+			for expected := interval.next(buckets[n-1].start); expected.Before(b.start); expected = interval.next(expected) {
+				result = append(result, stock.GetDetailedCompanyStockPricesResponse{
+					Date:  expected.Format(barDateLayout),
+					Open:  prev.Close,
+					Close: prev.Close,
+					High:  prev.Close,
+					Low:   prev.Close,
+					X:     prev.X,
+					Y:     prev.Y,
+					Gap:   gaps == GapMark,
+				})
+			}
+		}
+		result = append(result, b.bar)
+	}
+	return result
+}
@@ -0,0 +1,200 @@
+package timeseries
+
+import (
+	"patient-chatbot/internal/client/stock"
+	"testing"
+)
+
+func bar(date string, open float64, high float64, low float64, close float64, volume int) stock.GetDetailedCompanyStockPricesResponse {
+	return stock.GetDetailedCompanyStockPricesResponse{Date: date, Open: open, High: high, Low: low, Close: close, Volume: volume}
+}
+
+func gap(date string, close float64, marked bool) stock.GetDetailedCompanyStockPricesResponse {
+	return stock.GetDetailedCompanyStockPricesResponse{Date: date, Open: close, High: close, Low: close, Close: close, Gap: marked}
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name     string
+		bars     []stock.GetDetailedCompanyStockPricesResponse
+		interval Interval
+		gaps     GapPolicy
+		want     []stock.GetDetailedCompanyStockPricesResponse
+	}{
+		{
+			name: "weeks start on sunday",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-05", 10, 12, 9, 11, 100),
+				bar("2025-01-09", 11, 14, 10, 13, 200),
+				bar("2025-01-12", 13, 13, 8, 9, 50),
+			},
+			interval: Interval1W,
+			gaps:     GapSkip,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-05T00:00:00", 10, 14, 9, 13, 300),
+				bar("2025-01-12T00:00:00", 13, 13, 8, 9, 50),
+			},
+		},
+		{
+			name: "weeks are cut in riyadh time",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09", 10, 10, 10, 10, 100),
+				// @NOTE: Saturday 22:00 UTC is already Sunday in Riyadh
+				bar("2025-01-11T22:00:00Z", 11, 11, 11, 11, 100),
+			},
+			interval: Interval1W,
+			gaps:     GapSkip,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-05T00:00:00", 10, 10, 10, 10, 100),
+				bar("2025-01-12T00:00:00", 11, 11, 11, 11, 100),
+			},
+		},
+		{
+			name: "months",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-02", 10, 11, 9, 10, 100),
+				bar("2025-01-30", 10, 15, 10, 14, 100),
+				bar("2025-02-02", 14, 14, 12, 12, 100),
+				bar("2025-04-01", 12, 13, 11, 13, 100),
+			},
+			interval: Interval1M,
+			gaps:     GapForwardFill,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-01T00:00:00", 10, 15, 9, 14, 200),
+				bar("2025-02-01T00:00:00", 14, 14, 12, 12, 100),
+				gap("2025-03-01T00:00:00", 12, false),
+				bar("2025-04-01T00:00:00", 12, 13, 11, 13, 100),
+			},
+		},
+		{
+			name: "skip leaves missing sessions out",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09", 10, 10, 10, 10, 100),
+				bar("2025-01-13", 11, 11, 11, 11, 100),
+			},
+			interval: Interval1D,
+			gaps:     GapSkip,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09T00:00:00", 10, 10, 10, 10, 100),
+				bar("2025-01-13T00:00:00", 11, 11, 11, 11, 100),
+			},
+		},
+		{
+			name: "ffill repeats the close over the weekend gap",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09", 10, 10, 10, 10, 100),
+				bar("2025-01-13", 11, 11, 11, 11, 100),
+			},
+			interval: Interval1D,
+			gaps:     GapForwardFill,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09T00:00:00", 10, 10, 10, 10, 100),
+				gap("2025-01-12T00:00:00", 10, false),
+				bar("2025-01-13T00:00:00", 11, 11, 11, 11, 100),
+			},
+		},
+		{
+			name: "mark flags the filled bars",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09", 10, 10, 10, 10, 100),
+				bar("2025-01-13", 11, 11, 11, 11, 100),
+			},
+			interval: Interval1D,
+			gaps:     GapMark,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09T00:00:00", 10, 10, 10, 10, 100),
+				gap("2025-01-12T00:00:00", 10, true),
+				bar("2025-01-13T00:00:00", 11, 11, 11, 11, 100),
+			},
+		},
+		{
+			name: "intraday gaps stay inside the session",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09T13:00:00", 10, 10, 10, 10, 100),
+				bar("2025-01-12T10:00:00", 11, 11, 11, 11, 100),
+			},
+			interval: Interval1h,
+			gaps:     GapMark,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-09T13:00:00", 10, 10, 10, 10, 100),
+				gap("2025-01-09T14:00:00", 10, true),
+				bar("2025-01-12T10:00:00", 11, 11, 11, 11, 100),
+			},
+		},
+		{
+			name: "unsorted input",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-06", 11, 12, 11, 12, 100),
+				bar("2025-01-05", 10, 11, 10, 11, 100),
+				bar("2025-01-07", 12, 13, 12, 13, 100),
+			},
+			interval: Interval1W,
+			gaps:     GapSkip,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-05T00:00:00", 10, 13, 10, 13, 300),
+			},
+		},
+		{
+			name: "duplicate bar replaces the earlier one",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-05", 10, 11, 10, 11, 100),
+				bar("2025-01-06", 11, 12, 11, 12, 100),
+				bar("2025-01-05 00:00:00", 10, 11, 9, 10, 150),
+			},
+			interval: Interval1W,
+			gaps:     GapSkip,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-05T00:00:00", 10, 12, 9, 12, 250),
+			},
+		},
+		{
+			name: "unparseable dates are dropped",
+			bars: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("yesterday", 1, 1, 1, 1, 1),
+				bar("2025-01-05", 10, 10, 10, 10, 100),
+			},
+			interval: Interval1D,
+			gaps:     GapSkip,
+			want: []stock.GetDetailedCompanyStockPricesResponse{
+				bar("2025-01-05T00:00:00", 10, 10, 10, 10, 100),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resample(tt.bars, tt.interval, tt.gaps)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Resample() = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("bar %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseGapPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    GapPolicy
+		wantErr bool
+	}{
+		{value: "", want: GapSkip},
+		{value: "Skip", want: GapSkip},
+		{value: "forward-fill", want: GapForwardFill},
+		{value: " mark ", want: GapMark},
+		{value: "zero", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseGapPolicy(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseGapPolicy(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"patient-chatbot/internal/dto"
+)
+
+// Chart renders a chart payload the user is looking at as a compact plain
+// text summary for the system prompt.
+type Chart struct {
+	Chart dto.Chart
+	// Probe is a top level key only this chart's payload has, it recognizes
+	// payloads sent without naming the chart.
+	Probe  string
+	render func(raw json.RawMessage) (string, error)
+}
+
+// NewChart declares the context renderer of a chart whose payload decodes
+// into T.
+func NewChart[T any](chart dto.Chart, probe string, render func(data T) string) Chart {
+	return Chart{
+		Chart: chart,
+		Probe: probe,
+		render: func(raw json.RawMessage) (string, error) {
+			var data T
+			if err := json.Unmarshal(raw, &data); err != nil {
+				return "", fmt.Errorf("error decoding %s: %w", chart, err)
+			}
+			return render(data), nil
+		},
+	}
+}
+
+// List decodes a JSON array, or a single object as a list of one.
+type List[T any] []T
+
+func (l *List[T]) UnmarshalJSON(raw []byte) error {
+	var list []T
+	if err := json.Unmarshal(raw, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var single T
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return err
+	}
+	*l = List[T]{single}
+	return nil
+}
+
+// RegisterCharts adds chart renderers. Payloads without a chart name are
+// probed in registration order, so charts whose payload carries another
+// chart's probe key must come first.
+func (r *Registry) RegisterCharts(charts ...Chart) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range charts {
+		for _, registered := range r.charts {
+			if registered.Chart == c.Chart {
+				panic(fmt.Errorf("chart %q registered twice", c.Chart))
+			}
+		}
+		r.charts = append(r.charts, c)
+	}
+}
+
+// RenderContext renders the chart the user is looking at, empty when there
+// is none.
+func (r *Registry) RenderContext(answerContext *dto.Context) (string, error) {
+	if answerContext == nil || answerContext.Stocks == nil {
+		return "", nil
+	}
+
+	raw, err := json.Marshal(answerContext.Stocks)
+	if err != nil {
+		return "", fmt.Errorf("tool :: RenderContext :: error marshalling stocks: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var chart *Chart
+	if answerContext.Chart != "" {
+		for i := range r.charts {
+			if string(r.charts[i].Chart) == answerContext.Chart {
+				chart = &r.charts[i]
+				break
+			}
+		}
+	} else {
+		chart = r.detectChart(raw)
+	}
+	if chart == nil {
+		return "", fmt.Errorf("tool :: RenderContext :: unknown chart %q", answerContext.Chart)
+	}
+
+	text, err := chart.render(raw)
+	if err != nil {
+		return "", fmt.Errorf("tool :: RenderContext :: %w", err)
+	}
+	return text, nil
+}
+
+// detectChart guesses the chart from the payload shape for clients that send
+// stocks without naming the chart.
+func (r *Registry) detectChart(raw []byte) *Chart {
+	var probe []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		var single map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil
+		}
+		probe = append(probe, single)
+	}
+	if len(probe) == 0 {
+		return nil
+	}
+	for i := range r.charts {
+		if _, ok := probe[0][r.charts[i].Probe]; ok {
+			return &r.charts[i]
+		}
+	}
+	return nil
+}
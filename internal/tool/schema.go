@@ -0,0 +1,95 @@
+package tool
+
+import (
+	"reflect"
+	"strings"
+)
+
+type Schema struct {
+	Type                 string             `json:"type"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// SchemaOf builds a JSON schema from a Go value. Struct fields are named by
+// their json tag, documented by a `description` tag, restricted by a
+// comma-separated `enum` tag and required unless tagged omitempty.
+func SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{Type: "object", Properties: map[string]*Schema{}}
+	}
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &Schema{Type: "object"}
+	}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	additional := false
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		Required:             []string{},
+		AdditionalProperties: &additional,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, omitEmpty := f.Name, false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, p := range parts[1:] {
+				if p == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+
+		prop := schemaOf(f.Type)
+		prop.Description = f.Tag.Get("description")
+		if enum := f.Tag.Get("enum"); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+		s.Properties[name] = prop
+
+		if !omitEmpty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"patient-chatbot/internal/dto"
+	"sort"
+	"sync"
+)
+
+type Result struct {
+	Name  string
+	Data  interface{}
+	Chart dto.Chart
+}
+
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  *Schema
+	handle      func(ctx context.Context, args json.RawMessage) (*Result, error)
+}
+
+// New declares a tool whose parameter schema is derived from the fields of T.
+func New[T any](name string, description string, handler func(ctx context.Context, args T) (*Result, error)) Tool {
+	var zero T
+	return Tool{
+		Name:        name,
+		Description: description,
+		Parameters:  SchemaOf(zero),
+		handle: func(ctx context.Context, raw json.RawMessage) (*Result, error) {
+			var args T
+			if err := DecodeArguments(raw, &args); err != nil {
+				return nil, err
+			}
+			return handler(ctx, args)
+		},
+	}
+}
+
+type Registry struct {
+	mu     sync.RWMutex
+	tools  map[string]Tool
+	charts []Chart
+}
+
+func NewRegistry() *Registry {
+	return &Registry{tools: map[string]Tool{}}
+}
+
+func (r *Registry) Register(tools ...Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range tools {
+		if _, ok := r.tools[t.Name]; ok {
+			panic(fmt.Errorf("tool %q registered twice", t.Name))
+		}
+		r.tools[t.Name] = t
+	}
+}
+
+// Tools returns the registered tools sorted by name so request payloads are stable.
+func (r *Registry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		tools = append(tools, t)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+func (r *Registry) Dispatch(ctx context.Context, name string, args json.RawMessage) (*Result, error) {
+	r.mu.RLock()
+	t, ok := r.tools[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("tool :: Dispatch :: unknown tool %q", name)
+	}
+
+	result, err := t.handle(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("tool :: Dispatch :: %s :: %w", name, err)
+	}
+	if result != nil {
+		result.Name = name
+	}
+	return result, nil
+}
+
+// DecodeArguments accepts tool arguments either as a JSON object or as the
+// JSON-encoded string OpenAI-compatible APIs send.
+func DecodeArguments(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+
+	var jsonText string
+	if err := json.Unmarshal(raw, &jsonText); err == nil {
+		raw = json.RawMessage(jsonText)
+	}
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("error unmarshalling arguments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+var ErrMissingID = errors.New("no user id, the user is not signed in")
+
+// validID keeps ids opaque but bounded, they come straight from a header.
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:@-]{1,128}$`)
+
+type contextKey struct{}
+
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IDFrom returns the user the request is made for, or ErrMissingID for
+// anonymous requests.
+func IDFrom(ctx context.Context) (string, error) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	if !ok || id == "" {
+		return "", ErrMissingID
+	}
+	return id, nil
+}
@@ -0,0 +1,154 @@
+package watchlist
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps watchlists in process memory. It is used when no
+// database is configured.
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	watchlists map[string]map[string]*Watchlist
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{watchlists: map[string]map[string]*Watchlist{}}
+}
+
+func (r *MemoryRepository) List(ctx context.Context, userID string) ([]Watchlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]Watchlist, 0, len(r.watchlists[userID]))
+	for _, w := range r.watchlists[userID] {
+		all = append(all, copyOf(w))
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+	return all, nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, userID string, id string) (*Watchlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	w, ok := r.watchlists[userID][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := copyOf(w)
+	return &copied, nil
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, userID string, name string, tadawulIDs []string) (*Watchlist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	owned := r.watchlists[userID]
+	if len(owned) >= MaxWatchlists {
+		return nil, ErrLimitReached
+	}
+	if r.nameTaken(userID, "", name) {
+		return nil, ErrDuplicateName
+	}
+	tadawulIDs = dedupe(tadawulIDs)
+	if len(tadawulIDs) > MaxItems {
+		return nil, ErrLimitReached
+	}
+
+	now := time.Now().UTC()
+	w := &Watchlist{ID: uuid.NewString(), Name: name, TadawulIDs: tadawulIDs, CreatedAt: now, UpdatedAt: now}
+	if owned == nil {
+		owned = map[string]*Watchlist{}
+		r.watchlists[userID] = owned
+	}
+	owned[w.ID] = w
+	copied := copyOf(w)
+	return &copied, nil
+}
+
+func (r *MemoryRepository) Rename(ctx context.Context, userID string, id string, name string) (*Watchlist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.watchlists[userID][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if r.nameTaken(userID, id, name) {
+		return nil, ErrDuplicateName
+	}
+	w.Name = name
+	w.UpdatedAt = time.Now().UTC()
+	copied := copyOf(w)
+	return &copied, nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.watchlists[userID][id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.watchlists[userID], id)
+	return nil
+}
+
+func (r *MemoryRepository) AddItems(ctx context.Context, userID string, id string, tadawulIDs ...string) (*Watchlist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.watchlists[userID][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	items := dedupe(append(slices.Clone(w.TadawulIDs), tadawulIDs...))
+	if len(items) > MaxItems {
+		return nil, ErrLimitReached
+	}
+	w.TadawulIDs = items
+	w.UpdatedAt = time.Now().UTC()
+	copied := copyOf(w)
+	return &copied, nil
+}
+
+func (r *MemoryRepository) RemoveItems(ctx context.Context, userID string, id string, tadawulIDs ...string) (*Watchlist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.watchlists[userID][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	w.TadawulIDs = slices.DeleteFunc(slices.Clone(w.TadawulIDs), func(item string) bool {
+		return slices.Contains(tadawulIDs, item)
+	})
+	w.UpdatedAt = time.Now().UTC()
+	copied := copyOf(w)
+	return &copied, nil
+}
+
+func (r *MemoryRepository) nameTaken(userID string, exceptID string, name string) bool {
+	for _, w := range r.watchlists[userID] {
+		if w.ID != exceptID && strings.EqualFold(w.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyOf(w *Watchlist) Watchlist {
+	copied := *w
+	copied.TadawulIDs = slices.Clone(w.TadawulIDs)
+	if copied.TadawulIDs == nil {
+		copied.TadawulIDs = []string{}
+	}
+	return copied
+}
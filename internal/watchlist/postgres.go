@@ -0,0 +1,225 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const uniqueViolation = "23505"
+
+const selectWatchlists = `
+	SELECT w.id, w.name,
+		COALESCE(array_agg(i.tadawul_id ORDER BY i.position) FILTER (WHERE i.tadawul_id IS NOT NULL), '{}'),
+		w.created_at, w.updated_at
+	FROM watchlists w
+	LEFT JOIN watchlist_items i ON i.watchlist_id = w.id`
+
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) List(ctx context.Context, userID string) ([]Watchlist, error) {
+	rows, err := r.pool.Query(ctx, selectWatchlists+`
+		WHERE w.user_id = $1
+		GROUP BY w.id ORDER BY w.created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("watchlist :: List :: %w", err)
+	}
+	defer rows.Close()
+
+	watchlists := []Watchlist{}
+	for rows.Next() {
+		var w Watchlist
+		if err := rows.Scan(&w.ID, &w.Name, &w.TadawulIDs, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("watchlist :: List :: error scanning: %w", err)
+		}
+		watchlists = append(watchlists, w)
+	}
+	return watchlists, rows.Err()
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, userID string, id string) (*Watchlist, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+	return r.get(ctx, r.pool, userID, id)
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, userID string, name string, tadawulIDs []string) (*Watchlist, error) {
+	tadawulIDs = dedupe(tadawulIDs)
+	if len(tadawulIDs) > MaxItems {
+		return nil, ErrLimitReached
+	}
+
+	var w *Watchlist
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		// @NOTE: Serializes creations per user so two requests can't both pass the limit
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", userID); err != nil {
+			return err
+		}
+		var count int
+		if err := tx.QueryRow(ctx, "SELECT count(*) FROM watchlists WHERE user_id = $1", userID).Scan(&count); err != nil {
+			return err
+		}
+		if count >= MaxWatchlists {
+			return ErrLimitReached
+		}
+
+		id := uuid.NewString()
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO watchlists (id, user_id, name) VALUES ($1, $2, $3)`,
+			id, userID, name,
+		); err != nil {
+			return err
+		}
+		if err := insertItems(ctx, tx, id, tadawulIDs); err != nil {
+			return err
+		}
+		var err error
+		w, err = r.get(ctx, tx, userID, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("watchlist :: Create :: %w", mapError(err))
+	}
+	return w, nil
+}
+
+func (r *PostgresRepository) Rename(ctx context.Context, userID string, id string, name string) (*Watchlist, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE watchlists SET name = $3, updated_at = now() WHERE id = $1 AND user_id = $2`,
+		id, userID, name,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("watchlist :: Rename :: %w", mapError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.get(ctx, r.pool, userID, id)
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, userID string, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+	tag, err := r.pool.Exec(ctx, "DELETE FROM watchlists WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("watchlist :: Delete :: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) AddItems(ctx context.Context, userID string, id string, tadawulIDs ...string) (*Watchlist, error) {
+	return r.updateItems(ctx, "AddItems", userID, id, func(tx pgx.Tx) error {
+		if err := insertItems(ctx, tx, id, dedupe(tadawulIDs)); err != nil {
+			return err
+		}
+		var count int
+		if err := tx.QueryRow(ctx, "SELECT count(*) FROM watchlist_items WHERE watchlist_id = $1", id).Scan(&count); err != nil {
+			return err
+		}
+		if count > MaxItems {
+			return ErrLimitReached
+		}
+		return nil
+	})
+}
+
+func (r *PostgresRepository) RemoveItems(ctx context.Context, userID string, id string, tadawulIDs ...string) (*Watchlist, error) {
+	return r.updateItems(ctx, "RemoveItems", userID, id, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM watchlist_items WHERE watchlist_id = $1 AND tadawul_id = ANY($2)`,
+			id, tadawulIDs,
+		)
+		return err
+	})
+}
+
+// updateItems locks the watchlist row, checking it belongs to the user, and
+// runs update in the same transaction.
+func (r *PostgresRepository) updateItems(ctx context.Context, method string, userID string, id string, update func(tx pgx.Tx) error) (*Watchlist, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	var w *Watchlist
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE watchlists SET updated_at = now() WHERE id = $1 AND user_id = $2`,
+			id, userID,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		if err := update(tx); err != nil {
+			return err
+		}
+		w, err = r.get(ctx, tx, userID, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("watchlist :: %s :: %w", method, err)
+	}
+	return w, nil
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (r *PostgresRepository) get(ctx context.Context, q querier, userID string, id string) (*Watchlist, error) {
+	var w Watchlist
+	err := q.QueryRow(ctx, selectWatchlists+`
+		WHERE w.id = $1 AND w.user_id = $2
+		GROUP BY w.id`,
+		id, userID,
+	).Scan(&w.ID, &w.Name, &w.TadawulIDs, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("watchlist :: Get :: %w", err)
+	}
+	return &w, nil
+}
+
+func insertItems(ctx context.Context, tx pgx.Tx, id string, tadawulIDs []string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO watchlist_items (watchlist_id, tadawul_id)
+		SELECT $1, t.tadawul_id FROM unnest($2::text[]) WITH ORDINALITY AS t (tadawul_id, n) ORDER BY t.n
+		ON CONFLICT DO NOTHING`,
+		id, tadawulIDs,
+	)
+	return err
+}
+
+// mapError turns a unique name violation into ErrDuplicateName.
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicateName
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package watchlist
+
+import (
+	"math"
+	"patient-chatbot/internal/client/stock"
+	"patient-chatbot/internal/market"
+)
+
+type Quote struct {
+	market.Mover
+	// Stale marks companies missing from the market watch, priced from the
+	// company directory snapshot instead.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// Quotes is a watchlist priced with the latest market watch.
+type Quotes struct {
+	Watchlist               Watchlist      `json:"watchlist"`
+	Quotes                  []Quote        `json:"quotes"`
+	Breadth                 market.Breadth `json:"breadth"`
+	AverageChangePercentage float64        `json:"averageChangePercentage"`
+}
+
+// NewQuotes prices every company of w from marketWatch, falling back to
+// fallback for the ones it lacks. Companies neither knows are left out.
+func NewQuotes(
+	w Watchlist,
+	marketWatch map[string]stock.MarketWatchResponse,
+	fallback func(tadawulID string) (stock.MarketWatchResponse, bool),
+) Quotes {
+	quotes := Quotes{Watchlist: w, Quotes: make([]Quote, 0, len(w.TadawulIDs))}
+	priced := make([]stock.MarketWatchResponse, 0, len(w.TadawulIDs))
+	for _, id := range w.TadawulIDs {
+		q, ok := marketWatch[id]
+		stale := !ok
+		if !ok {
+			if q, ok = fallback(id); !ok {
+				continue
+			}
+		}
+		priced = append(priced, q)
+		quotes.Quotes = append(quotes.Quotes, Quote{Mover: market.NewMover(q), Stale: stale})
+	}
+
+	quotes.Breadth = market.NewBreadth(priced)
+	if len(priced) > 0 {
+		var sum float64
+		for _, q := range priced {
+			sum += q.ChangePercentage
+		}
+		quotes.AverageChangePercentage = math.Round(sum/float64(len(priced))*100) / 100
+	}
+	return quotes
+}
@@ -0,0 +1,57 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("watchlist not found")
+	ErrDuplicateName = errors.New("watchlist name already used")
+	ErrLimitReached  = errors.New("watchlist limit reached")
+	ErrEmptyName     = errors.New("watchlist name is empty")
+)
+
+const (
+	MaxWatchlists = 20
+	MaxItems      = 50
+	DefaultName   = "My Watchlist"
+)
+
+// Watchlist is a named list of companies owned by a user, kept in the order
+// they were added.
+type Watchlist struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	TadawulIDs []string  `json:"tadawulIds"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Repository stores watchlists per user, every method is scoped by userID so
+// a user can never reach another user's watchlist. Names are unique per user
+// regardless of case. Create and AddItems return ErrLimitReached past
+// MaxWatchlists and MaxItems.
+type Repository interface {
+	List(ctx context.Context, userID string) ([]Watchlist, error)
+	Get(ctx context.Context, userID string, id string) (*Watchlist, error)
+	Create(ctx context.Context, userID string, name string, tadawulIDs []string) (*Watchlist, error)
+	Rename(ctx context.Context, userID string, id string, name string) (*Watchlist, error)
+	Delete(ctx context.Context, userID string, id string) error
+	AddItems(ctx context.Context, userID string, id string, tadawulIDs ...string) (*Watchlist, error)
+	RemoveItems(ctx context.Context, userID string, id string, tadawulIDs ...string) (*Watchlist, error)
+}
+
+// dedupe drops repeated ids, keeping the first occurrence.
+func dedupe(tadawulIDs []string) []string {
+	seen := make(map[string]bool, len(tadawulIDs))
+	unique := make([]string, 0, len(tadawulIDs))
+	for _, id := range tadawulIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
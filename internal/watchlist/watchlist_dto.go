@@ -0,0 +1,16 @@
+package watchlist
+
+const (
+	FunctionGetWatchlist    = "GetWatchlist"
+	FunctionUpdateWatchlist = "UpdateWatchlist"
+)
+
+type GetWatchlistArguments struct {
+	Watchlist string `json:"watchlist,omitempty" description:"Name of the watchlist to show. Leave empty to show all of the user's watchlists"`
+}
+
+type UpdateWatchlistArguments struct {
+	Action    string   `json:"action" enum:"add,remove" description:"Whether to add the companies to the watchlist or remove them from it"`
+	Companies []string `json:"companies" description:"Companies to add or remove, by name, acronym or tadawul id, in English or Arabic"`
+	Watchlist string   `json:"watchlist,omitempty" description:"Name of the watchlist. Leave empty when the user has a single watchlist or none yet, a new one is created when adding to a name that does not exist"`
+}